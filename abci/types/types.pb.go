@@ -1897,14 +1897,16 @@ func (m *ResponseBeginBlock) GetEvents() []Event {
 }
 
 type ResponseCheckTx struct {
-	Code      uint32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
-	Data      []byte  `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
-	Log       string  `protobuf:"bytes,3,opt,name=log,proto3" json:"log,omitempty"`
-	Info      string  `protobuf:"bytes,4,opt,name=info,proto3" json:"info,omitempty"`
-	GasWanted int64   `protobuf:"varint,5,opt,name=gas_wanted,proto3" json:"gas_wanted,omitempty"`
-	GasUsed   int64   `protobuf:"varint,6,opt,name=gas_used,proto3" json:"gas_used,omitempty"`
-	Events    []Event `protobuf:"bytes,7,rep,name=events,proto3" json:"events,omitempty"`
-	Codespace string  `protobuf:"bytes,8,opt,name=codespace,proto3" json:"codespace,omitempty"`
+	Code       uint32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Data       []byte  `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Log        string  `protobuf:"bytes,3,opt,name=log,proto3" json:"log,omitempty"`
+	Info       string  `protobuf:"bytes,4,opt,name=info,proto3" json:"info,omitempty"`
+	GasWanted  int64   `protobuf:"varint,5,opt,name=gas_wanted,proto3" json:"gas_wanted,omitempty"`
+	GasUsed    int64   `protobuf:"varint,6,opt,name=gas_used,proto3" json:"gas_used,omitempty"`
+	Events     []Event `protobuf:"bytes,7,rep,name=events,proto3" json:"events,omitempty"`
+	Codespace  string  `protobuf:"bytes,8,opt,name=codespace,proto3" json:"codespace,omitempty"`
+	Priority   int64   `protobuf:"varint,9,opt,name=priority,proto3" json:"priority,omitempty"`
+	ShareCount int64   `protobuf:"varint,10,opt,name=share_count,json=shareCount,proto3" json:"share_count,omitempty"`
 }
 
 func (m *ResponseCheckTx) Reset()         { *m = ResponseCheckTx{} }
@@ -1996,6 +1998,20 @@ func (m *ResponseCheckTx) GetCodespace() string {
 	return ""
 }
 
+func (m *ResponseCheckTx) GetPriority() int64 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+func (m *ResponseCheckTx) GetShareCount() int64 {
+	if m != nil {
+		return m.ShareCount
+	}
+	return 0
+}
+
 type ResponseDeliverTx struct {
 	Code      uint32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
 	Data      []byte  `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
@@ -3189,182 +3205,183 @@ func init() {
 func init() { proto.RegisterFile("tendermint/abci/types.proto", fileDescriptor_252557cfdd89a31a) }
 
 var fileDescriptor_252557cfdd89a31a = []byte{
-	// 2788 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe4, 0x5a, 0xcd, 0x77, 0x23, 0xc5,
-	0x11, 0xd7, 0xe8, 0x5b, 0x25, 0x4b, 0x96, 0x7b, 0x97, 0x45, 0x88, 0xc5, 0x5e, 0x86, 0x07, 0x59,
-	0x36, 0x60, 0x83, 0x79, 0x6c, 0xe0, 0x41, 0x02, 0x96, 0xd0, 0x22, 0xb3, 0xc6, 0x76, 0xda, 0xda,
-	0x25, 0x5f, 0xec, 0xd0, 0xd2, 0xb4, 0xa5, 0x61, 0xa5, 0x99, 0x61, 0x66, 0x64, 0x6c, 0x8e, 0xf9,
-	0xb8, 0x90, 0x43, 0x38, 0xe6, 0xc2, 0xff, 0x91, 0x53, 0x2e, 0xb9, 0xf0, 0x5e, 0x2e, 0x1c, 0x73,
-	0x22, 0x79, 0xec, 0x2d, 0xb7, 0x9c, 0x72, 0xca, 0x4b, 0x5e, 0x7f, 0xcc, 0x97, 0xa4, 0x91, 0xe4,
-	0x90, 0x5b, 0x6e, 0xdd, 0x35, 0x55, 0xa5, 0xe9, 0x9a, 0xee, 0x5f, 0xfd, 0xaa, 0x5a, 0xf0, 0xa4,
-	0x47, 0x4d, 0x9d, 0x3a, 0x63, 0xc3, 0xf4, 0x76, 0x48, 0xaf, 0x6f, 0xec, 0x78, 0x17, 0x36, 0x75,
-	0xb7, 0x6d, 0xc7, 0xf2, 0x2c, 0xb4, 0x1e, 0x3e, 0xdc, 0x66, 0x0f, 0x1b, 0x4f, 0x45, 0xb4, 0xfb,
-	0xce, 0x85, 0xed, 0x59, 0x3b, 0xb6, 0x63, 0x59, 0xa7, 0x42, 0xbf, 0x71, 0x3d, 0xf2, 0x98, 0xfb,
-	0x89, 0x7a, 0x8b, 0x3d, 0x95, 0xc6, 0x0f, 0xe9, 0x85, 0xff, 0xf4, 0xa9, 0x19, 0x5b, 0x9b, 0x38,
-	0x64, 0xec, 0x3f, 0xde, 0x1a, 0x58, 0xd6, 0x60, 0x44, 0x77, 0xf8, 0xac, 0x37, 0x39, 0xdd, 0xf1,
-	0x8c, 0x31, 0x75, 0x3d, 0x32, 0xb6, 0xa5, 0xc2, 0xd5, 0x81, 0x35, 0xb0, 0xf8, 0x70, 0x87, 0x8d,
-	0x84, 0x54, 0xfd, 0xb2, 0x08, 0x05, 0x4c, 0x3f, 0x99, 0x50, 0xd7, 0x43, 0xbb, 0x90, 0xa5, 0xfd,
-	0xa1, 0x55, 0x57, 0x6e, 0x28, 0x37, 0xcb, 0xbb, 0xd7, 0xb7, 0xa7, 0x16, 0xb7, 0x2d, 0xf5, 0xda,
-	0xfd, 0xa1, 0xd5, 0x49, 0x61, 0xae, 0x8b, 0x5e, 0x85, 0xdc, 0xe9, 0x68, 0xe2, 0x0e, 0xeb, 0x69,
-	0x6e, 0xf4, 0x54, 0x92, 0xd1, 0x1d, 0xa6, 0xd4, 0x49, 0x61, 0xa1, 0xcd, 0x7e, 0xca, 0x30, 0x4f,
-	0xad, 0x7a, 0x66, 0xf1, 0x4f, 0xed, 0x9b, 0xa7, 0xfc, 0xa7, 0x98, 0x2e, 0x6a, 0x02, 0x18, 0xa6,
-	0xe1, 0x69, 0xfd, 0x21, 0x31, 0xcc, 0x7a, 0x96, 0x5b, 0x3e, 0x9d, 0x6c, 0x69, 0x78, 0x2d, 0xa6,
-	0xd8, 0x49, 0xe1, 0x92, 0xe1, 0x4f, 0xd8, 0xeb, 0x7e, 0x32, 0xa1, 0xce, 0x45, 0x3d, 0xb7, 0xf8,
-	0x75, 0x7f, 0xcc, 0x94, 0xd8, 0xeb, 0x72, 0x6d, 0xd4, 0x86, 0x72, 0x8f, 0x0e, 0x0c, 0x53, 0xeb,
-	0x8d, 0xac, 0xfe, 0xc3, 0x7a, 0x9e, 0x1b, 0xab, 0x49, 0xc6, 0x4d, 0xa6, 0xda, 0x64, 0x9a, 0x9d,
-	0x14, 0x86, 0x5e, 0x30, 0x43, 0x6f, 0x42, 0xb1, 0x3f, 0xa4, 0xfd, 0x87, 0x9a, 0x77, 0x5e, 0x2f,
-	0x70, 0x1f, 0x5b, 0x49, 0x3e, 0x5a, 0x4c, 0xaf, 0x7b, 0xde, 0x49, 0xe1, 0x42, 0x5f, 0x0c, 0xd9,
-	0xfa, 0x75, 0x3a, 0x32, 0xce, 0xa8, 0xc3, 0xec, 0x8b, 0x8b, 0xd7, 0xff, 0x8e, 0xd0, 0xe4, 0x1e,
-	0x4a, 0xba, 0x3f, 0x41, 0x6f, 0x41, 0x89, 0x9a, 0xba, 0x5c, 0x46, 0x89, 0xbb, 0xb8, 0x91, 0xf8,
-	0x9d, 0x4d, 0xdd, 0x5f, 0x44, 0x91, 0xca, 0x31, 0x7a, 0x0d, 0xf2, 0x7d, 0x6b, 0x3c, 0x36, 0xbc,
-	0x3a, 0x70, 0xeb, 0xcd, 0xc4, 0x05, 0x70, 0xad, 0x4e, 0x0a, 0x4b, 0x7d, 0x74, 0x08, 0xd5, 0x91,
-	0xe1, 0x7a, 0x9a, 0x6b, 0x12, 0xdb, 0x1d, 0x5a, 0x9e, 0x5b, 0x2f, 0x73, 0x0f, 0xcf, 0x26, 0x79,
-	0x38, 0x30, 0x5c, 0xef, 0xc4, 0x57, 0xee, 0xa4, 0x70, 0x65, 0x14, 0x15, 0x30, 0x7f, 0xd6, 0xe9,
-	0x29, 0x75, 0x02, 0x87, 0xf5, 0xb5, 0xc5, 0xfe, 0x8e, 0x98, 0xb6, 0x6f, 0xcf, 0xfc, 0x59, 0x51,
-	0x01, 0xfa, 0x39, 0x5c, 0x19, 0x59, 0x44, 0x0f, 0xdc, 0x69, 0xfd, 0xe1, 0xc4, 0x7c, 0x58, 0xaf,
-	0x70, 0xa7, 0xcf, 0x27, 0xbe, 0xa4, 0x45, 0x74, 0xdf, 0x45, 0x8b, 0x19, 0x74, 0x52, 0x78, 0x63,
-	0x34, 0x2d, 0x44, 0x0f, 0xe0, 0x2a, 0xb1, 0xed, 0xd1, 0xc5, 0xb4, 0xf7, 0x2a, 0xf7, 0x7e, 0x2b,
-	0xc9, 0xfb, 0x1e, 0xb3, 0x99, 0x76, 0x8f, 0xc8, 0x8c, 0x94, 0x05, 0xc3, 0x76, 0xa8, 0xed, 0x58,
-	0x7d, 0xea, 0xba, 0x9a, 0x77, 0xee, 0xd6, 0xd7, 0x17, 0x07, 0xe3, 0x38, 0xd0, 0xee, 0x9e, 0xf3,
-	0xe0, 0xda, 0x51, 0x41, 0xb3, 0x00, 0xb9, 0x33, 0x32, 0x9a, 0x50, 0xf5, 0x7b, 0x50, 0x8e, 0x1c,
-	0x7b, 0x54, 0x87, 0xc2, 0x98, 0xba, 0x2e, 0x19, 0x50, 0x8e, 0x12, 0x25, 0xec, 0x4f, 0xd5, 0x2a,
-	0xac, 0x45, 0x8f, 0xba, 0xfa, 0x85, 0x12, 0x58, 0xb2, 0x53, 0xcc, 0x2c, 0xcf, 0xa8, 0xe3, 0x1a,
-	0x96, 0xe9, 0x5b, 0xca, 0x29, 0x7a, 0x06, 0x2a, 0x7c, 0x3f, 0x6a, 0xfe, 0x73, 0x06, 0x25, 0x59,
-	0xbc, 0xc6, 0x85, 0xf7, 0xa5, 0xd2, 0x16, 0x94, 0xed, 0x5d, 0x3b, 0x50, 0xc9, 0x70, 0x15, 0xb0,
-	0x77, 0x6d, 0x5f, 0xe1, 0x69, 0x58, 0x63, 0xeb, 0x0b, 0x34, 0xb2, 0xfc, 0x47, 0xca, 0x4c, 0x26,
-	0x55, 0xd4, 0x3f, 0xa7, 0xa1, 0x36, 0x0d, 0x0f, 0xe8, 0x35, 0xc8, 0x32, 0xa4, 0x94, 0xa0, 0xd7,
-	0xd8, 0x16, 0x30, 0xba, 0xed, 0xc3, 0xe8, 0x76, 0xd7, 0x87, 0xd1, 0x66, 0xf1, 0xab, 0x6f, 0xb6,
-	0x52, 0x5f, 0xfc, 0x75, 0x4b, 0xc1, 0xdc, 0x02, 0x3d, 0xc1, 0x4e, 0x33, 0x31, 0x4c, 0xcd, 0xd0,
-	0xf9, 0x2b, 0x97, 0xd8, 0x51, 0x25, 0x86, 0xb9, 0xaf, 0xa3, 0xbb, 0x50, 0xeb, 0x5b, 0xa6, 0x4b,
-	0x4d, 0x77, 0xe2, 0x6a, 0x02, 0xa6, 0x25, 0xd4, 0xcd, 0x9e, 0xb6, 0x96, 0xaf, 0x78, 0xcc, 0xf5,
-	0xf0, 0x7a, 0x3f, 0x2e, 0x40, 0x77, 0x00, 0xce, 0xc8, 0xc8, 0xd0, 0x89, 0x67, 0x39, 0x6e, 0x3d,
-	0x7b, 0x23, 0x33, 0xd7, 0xcd, 0x7d, 0x5f, 0xe5, 0x9e, 0xad, 0x13, 0x8f, 0x36, 0xb3, 0xec, 0x6d,
-	0x71, 0xc4, 0x12, 0x3d, 0x07, 0xeb, 0xc4, 0xb6, 0x35, 0xd7, 0x23, 0x1e, 0xd5, 0x7a, 0x17, 0x1e,
-	0x75, 0x39, 0x0a, 0xae, 0xe1, 0x0a, 0xb1, 0xed, 0x13, 0x26, 0x6d, 0x32, 0x21, 0x7a, 0x16, 0xaa,
-	0x0c, 0x30, 0x0d, 0x32, 0xd2, 0x86, 0xd4, 0x18, 0x0c, 0x3d, 0x8e, 0x77, 0x19, 0x5c, 0x91, 0xd2,
-	0x0e, 0x17, 0xaa, 0x7a, 0xf0, 0xc1, 0x39, 0x58, 0x22, 0x04, 0x59, 0x9d, 0x78, 0x84, 0x07, 0x72,
-	0x0d, 0xf3, 0x31, 0x93, 0xd9, 0xc4, 0x1b, 0xca, 0xf0, 0xf0, 0x31, 0xba, 0x06, 0x79, 0xe9, 0x36,
-	0xc3, 0xdd, 0xca, 0x19, 0xba, 0x0a, 0x39, 0xdb, 0xb1, 0xce, 0x28, 0xff, 0x72, 0x45, 0x2c, 0x26,
-	0xea, 0xaf, 0xd3, 0xb0, 0x31, 0x03, 0xab, 0xcc, 0xef, 0x90, 0xb8, 0x43, 0xff, 0xb7, 0xd8, 0x18,
-	0xdd, 0x66, 0x7e, 0x89, 0x4e, 0x1d, 0x99, 0x8a, 0xea, 0xd1, 0x10, 0x89, 0x34, 0xdb, 0xe1, 0xcf,
-	0x65, 0x68, 0xa4, 0x36, 0x3a, 0x82, 0xda, 0x88, 0xb8, 0x9e, 0x26, 0x60, 0x4a, 0x8b, 0xa4, 0xa5,
-	0x59, 0x70, 0x3e, 0x20, 0x3e, 0xb0, 0xb1, 0x3d, 0x2d, 0x1d, 0x55, 0x47, 0x31, 0x29, 0xc2, 0x70,
-	0xb5, 0x77, 0xf1, 0x19, 0x31, 0x3d, 0xc3, 0xa4, 0xda, 0xcc, 0x97, 0x7b, 0x62, 0xc6, 0x69, 0xfb,
-	0xcc, 0xd0, 0xa9, 0xd9, 0xf7, 0x3f, 0xd9, 0x95, 0xc0, 0x38, 0xf8, 0xa4, 0xae, 0x8a, 0xa1, 0x1a,
-	0x4f, 0x0c, 0xa8, 0x0a, 0x69, 0xef, 0x5c, 0x06, 0x20, 0xed, 0x9d, 0xa3, 0x97, 0x20, 0xcb, 0x16,
-	0xc9, 0x17, 0x5f, 0x9d, 0x93, 0x51, 0xa5, 0x5d, 0xf7, 0xc2, 0xa6, 0x98, 0x6b, 0xaa, 0x6a, 0x70,
-	0x1a, 0x82, 0x64, 0x31, 0xed, 0x55, 0x7d, 0x1e, 0xd6, 0xa7, 0xb2, 0x41, 0xe4, 0xfb, 0x29, 0xd1,
-	0xef, 0xa7, 0xae, 0x43, 0x25, 0x06, 0xfd, 0xea, 0x35, 0xb8, 0x3a, 0x0f, 0xc9, 0xd5, 0x61, 0x20,
-	0x8f, 0x21, 0x32, 0x7a, 0x15, 0x8a, 0x01, 0x94, 0x8b, 0xd3, 0x38, 0x1b, 0x2b, 0x5f, 0x19, 0x07,
-	0xaa, 0xec, 0x18, 0xb2, 0x6d, 0xcd, 0xf7, 0x43, 0x9a, 0xbf, 0x78, 0x81, 0xd8, 0x76, 0x87, 0xb8,
-	0x43, 0xf5, 0x23, 0xa8, 0x27, 0xc1, 0xf4, 0xd4, 0x32, 0xb2, 0xc1, 0x36, 0xbc, 0x06, 0xf9, 0x53,
-	0xcb, 0x19, 0x13, 0x8f, 0x3b, 0xab, 0x60, 0x39, 0x63, 0xdb, 0x53, 0x40, 0x76, 0x86, 0x8b, 0xc5,
-	0x44, 0xd5, 0xe0, 0x89, 0x44, 0xa8, 0x66, 0x26, 0x86, 0xa9, 0x53, 0x11, 0xcf, 0x0a, 0x16, 0x93,
-	0xd0, 0x91, 0x78, 0x59, 0x31, 0x61, 0x3f, 0xeb, 0xf2, 0xb5, 0x72, 0xff, 0x25, 0x2c, 0x67, 0xea,
-	0xcd, 0x20, 0x58, 0x31, 0xc4, 0x46, 0x35, 0xc8, 0x30, 0x94, 0x57, 0x6e, 0x64, 0x6e, 0xae, 0x61,
-	0x36, 0x54, 0xff, 0x51, 0x84, 0x22, 0xa6, 0xae, 0xcd, 0xd0, 0x03, 0x35, 0xa1, 0x44, 0xcf, 0xfb,
-	0xd4, 0xf6, 0x7c, 0xbc, 0x9d, 0x4f, 0x57, 0x84, 0x76, 0xdb, 0xd7, 0x64, 0x5c, 0x21, 0x30, 0x43,
-	0xaf, 0x48, 0x3a, 0x98, 0xcc, 0xec, 0xa4, 0x79, 0x94, 0x0f, 0xde, 0xf6, 0xf9, 0x60, 0x26, 0x91,
-	0x1e, 0x08, 0xab, 0x29, 0x42, 0xf8, 0x8a, 0x24, 0x84, 0xd9, 0x25, 0x3f, 0x16, 0x63, 0x84, 0xad,
-	0x18, 0x23, 0xcc, 0x2d, 0x59, 0x66, 0x02, 0x25, 0xbc, 0xed, 0x53, 0xc2, 0xfc, 0x92, 0x37, 0x9e,
-	0xe2, 0x84, 0x77, 0xe2, 0x9c, 0x50, 0xf0, 0xb9, 0x67, 0x12, 0xad, 0x13, 0x49, 0xe1, 0x0f, 0x23,
-	0xa4, 0xb0, 0x98, 0xc8, 0xc8, 0x84, 0x93, 0x39, 0xac, 0xb0, 0x15, 0x63, 0x85, 0xa5, 0x25, 0x31,
-	0x48, 0xa0, 0x85, 0x6f, 0x47, 0x69, 0x21, 0x24, 0x32, 0x4b, 0xf9, 0xbd, 0xe7, 0xf1, 0xc2, 0xd7,
-	0x03, 0x5e, 0x58, 0x4e, 0x24, 0xb6, 0x72, 0x0d, 0xd3, 0xc4, 0xf0, 0x68, 0x86, 0x18, 0x0a, 0x22,
-	0xf7, 0x5c, 0xa2, 0x8b, 0x25, 0xcc, 0xf0, 0x68, 0x86, 0x19, 0x56, 0x96, 0x38, 0x5c, 0x42, 0x0d,
-	0x7f, 0x31, 0x9f, 0x1a, 0x26, 0x93, 0x37, 0xf9, 0x9a, 0xab, 0x71, 0x43, 0x2d, 0x81, 0x1b, 0x0a,
-	0x06, 0xf7, 0xfd, 0x44, 0xf7, 0x2b, 0x93, 0xc3, 0xa3, 0x19, 0x72, 0x58, 0x5b, 0x12, 0x8f, 0x55,
-	0xd9, 0xe1, 0xf3, 0x2c, 0x39, 0x4f, 0x81, 0x08, 0x03, 0x38, 0xea, 0x38, 0x96, 0x23, 0x79, 0x9e,
-	0x98, 0xa8, 0x37, 0x19, 0x5d, 0x08, 0x01, 0x63, 0x01, 0x93, 0xe4, 0x89, 0x24, 0x02, 0x12, 0xea,
-	0x1f, 0x94, 0xd0, 0x96, 0x67, 0xd8, 0x28, 0xd5, 0x28, 0x49, 0xaa, 0x11, 0xe1, 0x97, 0xe9, 0x38,
-	0xbf, 0xdc, 0x82, 0x32, 0x4b, 0x10, 0x53, 0xd4, 0x91, 0xd8, 0x01, 0x75, 0xbc, 0x05, 0x1b, 0x9c,
-	0x01, 0x08, 0x16, 0x2a, 0xb3, 0x42, 0x96, 0x27, 0xb7, 0x75, 0xf6, 0x40, 0xec, 0x76, 0x91, 0x1e,
-	0x5e, 0x84, 0x2b, 0x11, 0xdd, 0x20, 0xf1, 0x08, 0x22, 0x55, 0x0b, 0xb4, 0xf7, 0x64, 0x06, 0xfa,
-	0x93, 0x12, 0x46, 0x28, 0xe4, 0x9c, 0xf3, 0xe8, 0xa1, 0xf2, 0xbf, 0xa1, 0x87, 0xe9, 0xff, 0x9a,
-	0x1e, 0x46, 0xf3, 0x68, 0x26, 0x9e, 0x47, 0xff, 0xa9, 0x84, 0x9f, 0x24, 0x20, 0x7b, 0x7d, 0x4b,
-	0xa7, 0x32, 0xb3, 0xf1, 0x31, 0x4b, 0x49, 0x23, 0x6b, 0x20, 0xf3, 0x17, 0x1b, 0x32, 0xad, 0x00,
-	0xd4, 0x4b, 0x12, 0xb3, 0x83, 0xa4, 0x98, 0xe3, 0x01, 0x96, 0x49, 0xb1, 0x06, 0x99, 0x87, 0x54,
-	0x40, 0xf0, 0x1a, 0x66, 0x43, 0xa6, 0xc7, 0xf7, 0x18, 0x07, 0xd6, 0x35, 0x2c, 0x26, 0xe8, 0x35,
-	0x28, 0xf1, 0x7e, 0x8a, 0x66, 0xd9, 0xae, 0x44, 0xcb, 0x27, 0xa3, 0x6b, 0x15, 0x6d, 0x93, 0xed,
-	0x63, 0xa6, 0x73, 0x64, 0xbb, 0xb8, 0x68, 0xcb, 0x51, 0x24, 0xdf, 0x97, 0x62, 0xb4, 0xf3, 0x3a,
-	0x94, 0xd8, 0xdb, 0xbb, 0x36, 0xe9, 0x53, 0x0e, 0x7d, 0x25, 0x1c, 0x0a, 0xd4, 0x07, 0x80, 0x66,
-	0x01, 0x1c, 0x75, 0x20, 0x4f, 0xcf, 0xa8, 0xe9, 0x89, 0xfc, 0x5b, 0xde, 0xbd, 0x36, 0x87, 0xd3,
-	0x51, 0xd3, 0x6b, 0xd6, 0x59, 0x90, 0xff, 0xfe, 0xcd, 0x56, 0x4d, 0x68, 0xbf, 0x60, 0x8d, 0x0d,
-	0x8f, 0x8e, 0x6d, 0xef, 0x02, 0x4b, 0x7b, 0xf5, 0x57, 0x69, 0x46, 0xb0, 0x62, 0xe0, 0x3e, 0x37,
-	0xb6, 0xfe, 0x8e, 0x4f, 0x47, 0xc8, 0xf5, 0x6a, 0xf1, 0xde, 0x04, 0x18, 0x10, 0x57, 0xfb, 0x94,
-	0x98, 0x1e, 0xd5, 0x65, 0xd0, 0x23, 0x12, 0xd4, 0x80, 0x22, 0x9b, 0x4d, 0x5c, 0xaa, 0x4b, 0x9e,
-	0x1f, 0xcc, 0x23, 0xeb, 0x2c, 0x7c, 0xb7, 0x75, 0xc6, 0xa3, 0x5c, 0x9c, 0x8e, 0xf2, 0x6f, 0xd2,
-	0xe1, 0x29, 0x09, 0xb9, 0xe8, 0xff, 0x5f, 0x1c, 0x7e, 0xcb, 0x0b, 0xd4, 0x78, 0x96, 0x45, 0x27,
-	0xb0, 0x11, 0x9c, 0x52, 0x6d, 0xc2, 0x4f, 0xaf, 0xbf, 0xef, 0x56, 0x3d, 0xe6, 0xb5, 0xb3, 0xb8,
-	0xd8, 0x45, 0x3f, 0x81, 0xc7, 0xa7, 0x10, 0x28, 0x70, 0x9d, 0x5e, 0x11, 0x88, 0x1e, 0x8b, 0x03,
-	0x91, 0xef, 0x39, 0x8c, 0x55, 0xe6, 0x3b, 0x9e, 0x8d, 0x7d, 0x56, 0xf3, 0x44, 0x39, 0xc3, 0xdc,
-	0xaf, 0xff, 0x0c, 0x54, 0x1c, 0xea, 0xb1, 0x32, 0x3c, 0x56, 0x55, 0xae, 0x09, 0xa1, 0xac, 0x55,
-	0x8f, 0xe1, 0xb1, 0xb9, 0xdc, 0x01, 0xfd, 0x00, 0x4a, 0x21, 0xed, 0x50, 0x12, 0x0a, 0xb4, 0xa0,
-	0xe8, 0x08, 0x75, 0xd5, 0x3f, 0x2a, 0xa1, 0xcb, 0x78, 0x19, 0xd3, 0x86, 0xbc, 0x43, 0xdd, 0xc9,
-	0x48, 0x14, 0x16, 0xd5, 0xdd, 0x17, 0x57, 0x63, 0x1d, 0x4c, 0x3a, 0x19, 0x79, 0x58, 0x1a, 0xab,
-	0x0f, 0x20, 0x2f, 0x24, 0xa8, 0x0c, 0x85, 0x7b, 0x87, 0x77, 0x0f, 0x8f, 0x3e, 0x38, 0xac, 0xa5,
-	0x10, 0x40, 0x7e, 0xaf, 0xd5, 0x6a, 0x1f, 0x77, 0x6b, 0x0a, 0x2a, 0x41, 0x6e, 0xaf, 0x79, 0x84,
-	0xbb, 0xb5, 0x34, 0x13, 0xe3, 0xf6, 0x7b, 0xed, 0x56, 0xb7, 0x96, 0x41, 0x1b, 0x50, 0x11, 0x63,
-	0xed, 0xce, 0x11, 0x7e, 0x7f, 0xaf, 0x5b, 0xcb, 0x46, 0x44, 0x27, 0xed, 0xc3, 0x77, 0xda, 0xb8,
-	0x96, 0x53, 0x5f, 0x66, 0x95, 0x4b, 0x02, 0x4f, 0x09, 0x6b, 0x14, 0x25, 0x52, 0xa3, 0xa8, 0xbf,
-	0x4f, 0x43, 0x23, 0x99, 0x7c, 0xa0, 0xf7, 0xa6, 0x16, 0xbe, 0x7b, 0x09, 0xe6, 0x32, 0xb5, 0x7a,
-	0xf4, 0x2c, 0x54, 0x1d, 0x7a, 0x4a, 0xbd, 0xfe, 0x50, 0x90, 0x21, 0x91, 0xd8, 0x2a, 0xb8, 0x22,
-	0xa5, 0xdc, 0xc8, 0x15, 0x6a, 0x1f, 0xd3, 0xbe, 0xa7, 0x89, 0x72, 0x49, 0x6c, 0xba, 0x12, 0x53,
-	0x63, 0xd2, 0x13, 0x21, 0x54, 0x3f, 0xba, 0x54, 0x2c, 0x4b, 0x90, 0xc3, 0xed, 0x2e, 0xfe, 0x69,
-	0x2d, 0x83, 0x10, 0x54, 0xf9, 0x50, 0x3b, 0x39, 0xdc, 0x3b, 0x3e, 0xe9, 0x1c, 0xb1, 0x58, 0x5e,
-	0x81, 0x75, 0x3f, 0x96, 0xbe, 0x30, 0xa7, 0x92, 0x70, 0x37, 0x2c, 0xa9, 0xd3, 0xd0, 0x6d, 0x28,
-	0x4a, 0xa6, 0xe3, 0x9f, 0xb5, 0xc6, 0x6c, 0xa7, 0xe2, 0x7d, 0xa9, 0x81, 0x03, 0x5d, 0xf5, 0xdf,
-	0x0a, 0xac, 0x4f, 0x9d, 0x41, 0xb4, 0x0b, 0x39, 0xc1, 0xd9, 0x93, 0x5a, 0xf6, 0x1c, 0x42, 0xe4,
-	0x81, 0x15, 0xaa, 0xe8, 0x4d, 0x28, 0x52, 0xd9, 0x71, 0x98, 0x77, 0xd6, 0xc5, 0xef, 0xfb, 0x3d,
-	0x09, 0x69, 0x1a, 0x58, 0xa0, 0xb7, 0xa0, 0x14, 0x80, 0x89, 0xac, 0xf1, 0x9e, 0x9e, 0x35, 0x0f,
-	0x60, 0x48, 0xda, 0x87, 0x36, 0xe8, 0xf5, 0x90, 0xa7, 0x65, 0x67, 0x2b, 0x05, 0x69, 0x2e, 0x14,
-	0xa4, 0xb1, 0xaf, 0xaf, 0xb6, 0xa0, 0x1c, 0x59, 0x0f, 0x7a, 0x12, 0x4a, 0x63, 0x72, 0x2e, 0x3b,
-	0x59, 0xa2, 0x17, 0x51, 0x1c, 0x93, 0x73, 0xd1, 0xc4, 0x7a, 0x1c, 0x0a, 0xec, 0xe1, 0x80, 0x88,
-	0x20, 0x67, 0x70, 0x7e, 0x4c, 0xce, 0xdf, 0x25, 0xae, 0xfa, 0x21, 0x54, 0xe3, 0x5d, 0x1c, 0xb6,
-	0xd9, 0x1d, 0x6b, 0x62, 0xea, 0xdc, 0x47, 0x0e, 0x8b, 0x09, 0x7a, 0x15, 0x72, 0x67, 0x96, 0xc0,
-	0xc3, 0xf9, 0xa8, 0x70, 0xdf, 0xf2, 0x68, 0xa4, 0x0b, 0x24, 0xb4, 0xd5, 0xcf, 0x20, 0xc7, 0xf1,
-	0x8d, 0x61, 0x15, 0xef, 0xc7, 0x48, 0x8e, 0xca, 0xc6, 0xe8, 0x43, 0x00, 0xe2, 0x79, 0x8e, 0xd1,
-	0x9b, 0x84, 0x8e, 0xb7, 0xe6, 0xe3, 0xe3, 0x9e, 0xaf, 0xd7, 0xbc, 0x2e, 0x81, 0xf2, 0x6a, 0x68,
-	0x1a, 0x01, 0xcb, 0x88, 0x43, 0xf5, 0x10, 0xaa, 0x71, 0x5b, 0x9f, 0x56, 0x29, 0x73, 0x68, 0x55,
-	0x3a, 0x4a, 0xab, 0x02, 0x52, 0x96, 0x11, 0xbd, 0x37, 0x3e, 0x51, 0x3f, 0x57, 0xa0, 0xd8, 0x3d,
-	0x97, 0x27, 0x27, 0xa1, 0xed, 0x13, 0x9a, 0xa6, 0xa3, 0x4d, 0x0e, 0xd1, 0x47, 0xca, 0x04, 0xdd,
-	0xa9, 0xb7, 0x03, 0x6c, 0xc8, 0xae, 0x5a, 0xa1, 0xfa, 0x6d, 0x3a, 0x89, 0x87, 0x6f, 0x40, 0x29,
-	0xd8, 0x55, 0x8c, 0xec, 0x13, 0x5d, 0x77, 0xa8, 0xeb, 0xca, 0xb5, 0xf9, 0x53, 0xde, 0x45, 0xb4,
-	0x3e, 0x95, 0x6d, 0x94, 0x0c, 0x16, 0x13, 0x55, 0x87, 0xf5, 0xa9, 0xcc, 0x88, 0xde, 0x80, 0x82,
-	0x3d, 0xe9, 0x69, 0x7e, 0x78, 0xa6, 0x0e, 0x8f, 0xcf, 0x23, 0x27, 0xbd, 0x91, 0xd1, 0xbf, 0x4b,
-	0x2f, 0xfc, 0x97, 0xb1, 0x27, 0xbd, 0xbb, 0x22, 0x8a, 0xe2, 0x57, 0xd2, 0xd1, 0x5f, 0x39, 0x83,
-	0xa2, 0xbf, 0x29, 0xd0, 0x8f, 0xa2, 0xe7, 0x44, 0x99, 0x3d, 0xe6, 0xf1, 0x6c, 0x2d, 0xdd, 0x47,
-	0x8e, 0xc9, 0x2d, 0xd8, 0x70, 0x8d, 0x81, 0x49, 0x75, 0x2d, 0x2c, 0x37, 0xf8, 0xaf, 0x15, 0xf1,
-	0xba, 0x78, 0x70, 0xe0, 0xd7, 0x1a, 0xea, 0xbf, 0x14, 0x28, 0xfa, 0x07, 0x16, 0xbd, 0x1c, 0xd9,
-	0x77, 0xd5, 0x39, 0x8d, 0x14, 0x5f, 0x31, 0x6c, 0x04, 0xc6, 0xdf, 0x35, 0x7d, 0xf9, 0x77, 0x4d,
-	0xea, 0xe8, 0xfa, 0xad, 0xf5, 0xec, 0xa5, 0x5b, 0xeb, 0x2f, 0x00, 0xf2, 0x2c, 0x8f, 0x8c, 0xb4,
-	0x33, 0xcb, 0x33, 0xcc, 0x81, 0x26, 0x82, 0x2d, 0x48, 0x5b, 0x8d, 0x3f, 0xb9, 0xcf, 0x1f, 0x1c,
-	0xf3, 0xb8, 0xff, 0x52, 0x81, 0x62, 0x90, 0x7e, 0x2f, 0xdb, 0xd7, 0xbb, 0x06, 0x79, 0x99, 0x61,
-	0x44, 0x63, 0x4f, 0xce, 0x82, 0x16, 0x73, 0x36, 0xd2, 0x62, 0x6e, 0x30, 0xe8, 0xf6, 0x08, 0xe7,
-	0x20, 0xa2, 0xe2, 0x0b, 0xe6, 0xb7, 0x5e, 0x87, 0x72, 0xa4, 0xc5, 0xca, 0x4e, 0xde, 0x61, 0xfb,
-	0x83, 0x5a, 0xaa, 0x51, 0xf8, 0xfc, 0xcb, 0x1b, 0x99, 0x43, 0xfa, 0x29, 0xdb, 0xb3, 0xb8, 0xdd,
-	0xea, 0xb4, 0x5b, 0x77, 0x6b, 0x4a, 0xa3, 0xfc, 0xf9, 0x97, 0x37, 0x0a, 0x98, 0xf2, 0x26, 0xce,
-	0xad, 0x0e, 0xac, 0x45, 0xbf, 0x4a, 0x3c, 0x49, 0x21, 0xa8, 0xbe, 0x73, 0xef, 0xf8, 0x60, 0xbf,
-	0xb5, 0xd7, 0x6d, 0x6b, 0xf7, 0x8f, 0xba, 0xed, 0x9a, 0x82, 0x1e, 0x87, 0x2b, 0x07, 0xfb, 0xef,
-	0x76, 0xba, 0x5a, 0xeb, 0x60, 0xbf, 0x7d, 0xd8, 0xd5, 0xf6, 0xba, 0xdd, 0xbd, 0xd6, 0xdd, 0x5a,
-	0x7a, 0xf7, 0x77, 0x00, 0xeb, 0x7b, 0xcd, 0xd6, 0x3e, 0x4b, 0xb0, 0x46, 0x9f, 0xf0, 0x72, 0xbc,
-	0x05, 0x59, 0x5e, 0x70, 0x2f, 0xbc, 0xcf, 0x6d, 0x2c, 0x6e, 0xef, 0xa1, 0x3b, 0x90, 0xe3, 0xb5,
-	0x38, 0x5a, 0x7c, 0xc1, 0xdb, 0x58, 0xd2, 0xef, 0x63, 0x2f, 0xc3, 0x8f, 0xc7, 0xc2, 0x1b, 0xdf,
-	0xc6, 0xe2, 0xf6, 0x1f, 0xc2, 0x50, 0x0a, 0xab, 0x84, 0xe5, 0x37, 0xa0, 0x8d, 0x15, 0xc0, 0x06,
-	0x1d, 0x40, 0xc1, 0xaf, 0xbf, 0x96, 0xdd, 0xc9, 0x36, 0x96, 0xf6, 0xe7, 0x58, 0xb8, 0x44, 0x9d,
-	0xbc, 0xf8, 0x82, 0xb9, 0xb1, 0xa4, 0xd9, 0x88, 0xf6, 0x21, 0x2f, 0xa9, 0xef, 0x92, 0x7b, 0xd6,
-	0xc6, 0xb2, 0x7e, 0x1b, 0x0b, 0x5a, 0xd8, 0x80, 0x58, 0x7e, 0x6d, 0xde, 0x58, 0xa1, 0x8f, 0x8a,
-	0xee, 0x01, 0x44, 0xaa, 0xe2, 0x15, 0xee, 0xc3, 0x1b, 0xab, 0xf4, 0x47, 0xd1, 0x11, 0x14, 0x83,
-	0xea, 0x67, 0xe9, 0xed, 0x74, 0x63, 0x79, 0xa3, 0x12, 0x3d, 0x80, 0x4a, 0x9c, 0xf6, 0xaf, 0x76,
-	0xe7, 0xdc, 0x58, 0xb1, 0x03, 0xc9, 0xfc, 0xc7, 0x6b, 0x80, 0xd5, 0xee, 0xa0, 0x1b, 0x2b, 0x36,
-	0x24, 0xd1, 0xc7, 0xb0, 0x31, 0xcb, 0xd1, 0x57, 0xbf, 0x92, 0x6e, 0x5c, 0xa2, 0x45, 0x89, 0xc6,
-	0x80, 0xe6, 0x70, 0xfb, 0x4b, 0xdc, 0x50, 0x37, 0x2e, 0xd3, 0xb1, 0x64, 0xa1, 0x8b, 0x13, 0xe6,
-	0xd5, 0x6e, 0xac, 0x1b, 0x2b, 0xf6, 0x2e, 0x9b, 0xef, 0x7d, 0xf5, 0xed, 0xa6, 0xf2, 0xf5, 0xb7,
-	0x9b, 0xca, 0xdf, 0xbe, 0xdd, 0x54, 0xbe, 0x78, 0xb4, 0x99, 0xfa, 0xfa, 0xd1, 0x66, 0xea, 0x2f,
-	0x8f, 0x36, 0x53, 0x3f, 0x7b, 0x69, 0x60, 0x78, 0xc3, 0x49, 0x6f, 0xbb, 0x6f, 0x8d, 0x77, 0x46,
-	0xe4, 0xb3, 0x8b, 0x11, 0xd5, 0x07, 0xd4, 0x89, 0x0c, 0x5f, 0xec, 0x5b, 0x0e, 0x8d, 0xfc, 0xe7,
-	0xa7, 0x97, 0xe7, 0x99, 0xeb, 0x95, 0xff, 0x04, 0x00, 0x00, 0xff, 0xff, 0xb0, 0xf7, 0x58, 0xc2,
-	0x13, 0x24, 0x00, 0x00,
+	// 2816 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe4, 0x5a, 0xcd, 0x73, 0x23, 0xc5,
+	0x15, 0xd7, 0xf7, 0xc7, 0xd3, 0xa7, 0x7b, 0xbd, 0x8b, 0x56, 0x2c, 0xf6, 0x32, 0x14, 0x64, 0xd9,
+	0x80, 0x0d, 0xa6, 0xd8, 0x40, 0x91, 0x0f, 0x2c, 0xa1, 0x45, 0xc6, 0xc6, 0x76, 0xda, 0xda, 0x25,
+	0x5f, 0xec, 0x30, 0x9a, 0x69, 0x4b, 0xc3, 0x4a, 0x33, 0xc3, 0xcc, 0xc8, 0xd8, 0x1c, 0x53, 0xc9,
+	0x85, 0x1c, 0xc2, 0x31, 0x17, 0xfe, 0x8f, 0x9c, 0x52, 0xa9, 0xca, 0x85, 0xaa, 0x5c, 0x38, 0xe6,
+	0x44, 0x52, 0x70, 0xcb, 0x2d, 0xa7, 0x9c, 0x52, 0x49, 0xf5, 0xd7, 0x68, 0x46, 0xd2, 0x58, 0x72,
+	0xc8, 0x2d, 0xb7, 0xee, 0x37, 0xef, 0xbd, 0xe9, 0x7e, 0xd3, 0xfd, 0x7b, 0xbf, 0x7e, 0x3d, 0xf0,
+	0xa4, 0x4f, 0x2c, 0x83, 0xb8, 0x63, 0xd3, 0xf2, 0xb7, 0xb5, 0xbe, 0x6e, 0x6e, 0xfb, 0x17, 0x0e,
+	0xf1, 0xb6, 0x1c, 0xd7, 0xf6, 0x6d, 0x54, 0x9b, 0x3e, 0xdc, 0xa2, 0x0f, 0x9b, 0x4f, 0x85, 0xb4,
+	0x75, 0xf7, 0xc2, 0xf1, 0xed, 0x6d, 0xc7, 0xb5, 0xed, 0x53, 0xae, 0xdf, 0xbc, 0x15, 0x7a, 0xcc,
+	0xfc, 0x84, 0xbd, 0x35, 0x6f, 0xcd, 0x1b, 0x3f, 0x26, 0x17, 0xf2, 0xe9, 0x53, 0x73, 0xb6, 0x8e,
+	0xe6, 0x6a, 0x63, 0xf9, 0x78, 0x73, 0x60, 0xdb, 0x83, 0x11, 0xd9, 0x66, 0xbd, 0xfe, 0xe4, 0x74,
+	0xdb, 0x37, 0xc7, 0xc4, 0xf3, 0xb5, 0xb1, 0x23, 0x14, 0xd6, 0x07, 0xf6, 0xc0, 0x66, 0xcd, 0x6d,
+	0xda, 0xe2, 0x52, 0xe5, 0xf3, 0x02, 0xe4, 0x31, 0xf9, 0x68, 0x42, 0x3c, 0x1f, 0xed, 0x40, 0x86,
+	0xe8, 0x43, 0xbb, 0x91, 0xbc, 0x9d, 0xbc, 0x53, 0xda, 0xb9, 0xb5, 0x35, 0x33, 0xb9, 0x2d, 0xa1,
+	0xd7, 0xd1, 0x87, 0x76, 0x37, 0x81, 0x99, 0x2e, 0x7a, 0x15, 0xb2, 0xa7, 0xa3, 0x89, 0x37, 0x6c,
+	0xa4, 0x98, 0xd1, 0x53, 0x71, 0x46, 0xf7, 0xa9, 0x52, 0x37, 0x81, 0xb9, 0x36, 0x7d, 0x95, 0x69,
+	0x9d, 0xda, 0x8d, 0xf4, 0xe5, 0xaf, 0xda, 0xb3, 0x4e, 0xd9, 0xab, 0xa8, 0x2e, 0x6a, 0x01, 0x98,
+	0x96, 0xe9, 0xab, 0xfa, 0x50, 0x33, 0xad, 0x46, 0x86, 0x59, 0x3e, 0x1d, 0x6f, 0x69, 0xfa, 0x6d,
+	0xaa, 0xd8, 0x4d, 0xe0, 0xa2, 0x29, 0x3b, 0x74, 0xb8, 0x1f, 0x4d, 0x88, 0x7b, 0xd1, 0xc8, 0x5e,
+	0x3e, 0xdc, 0x1f, 0x53, 0x25, 0x3a, 0x5c, 0xa6, 0x8d, 0x3a, 0x50, 0xea, 0x93, 0x81, 0x69, 0xa9,
+	0xfd, 0x91, 0xad, 0x3f, 0x6e, 0xe4, 0x98, 0xb1, 0x12, 0x67, 0xdc, 0xa2, 0xaa, 0x2d, 0xaa, 0xd9,
+	0x4d, 0x60, 0xe8, 0x07, 0x3d, 0xf4, 0x7d, 0x28, 0xe8, 0x43, 0xa2, 0x3f, 0x56, 0xfd, 0xf3, 0x46,
+	0x9e, 0xf9, 0xd8, 0x8c, 0xf3, 0xd1, 0xa6, 0x7a, 0xbd, 0xf3, 0x6e, 0x02, 0xe7, 0x75, 0xde, 0xa4,
+	0xf3, 0x37, 0xc8, 0xc8, 0x3c, 0x23, 0x2e, 0xb5, 0x2f, 0x5c, 0x3e, 0xff, 0xb7, 0xb8, 0x26, 0xf3,
+	0x50, 0x34, 0x64, 0x07, 0xfd, 0x08, 0x8a, 0xc4, 0x32, 0xc4, 0x34, 0x8a, 0xcc, 0xc5, 0xed, 0xd8,
+	0xef, 0x6c, 0x19, 0x72, 0x12, 0x05, 0x22, 0xda, 0xe8, 0x35, 0xc8, 0xe9, 0xf6, 0x78, 0x6c, 0xfa,
+	0x0d, 0x60, 0xd6, 0x1b, 0xb1, 0x13, 0x60, 0x5a, 0xdd, 0x04, 0x16, 0xfa, 0xe8, 0x10, 0xaa, 0x23,
+	0xd3, 0xf3, 0x55, 0xcf, 0xd2, 0x1c, 0x6f, 0x68, 0xfb, 0x5e, 0xa3, 0xc4, 0x3c, 0x3c, 0x1b, 0xe7,
+	0xe1, 0xc0, 0xf4, 0xfc, 0x13, 0xa9, 0xdc, 0x4d, 0xe0, 0xca, 0x28, 0x2c, 0xa0, 0xfe, 0xec, 0xd3,
+	0x53, 0xe2, 0x06, 0x0e, 0x1b, 0xe5, 0xcb, 0xfd, 0x1d, 0x51, 0x6d, 0x69, 0x4f, 0xfd, 0xd9, 0x61,
+	0x01, 0xfa, 0x39, 0x5c, 0x1b, 0xd9, 0x9a, 0x11, 0xb8, 0x53, 0xf5, 0xe1, 0xc4, 0x7a, 0xdc, 0xa8,
+	0x30, 0xa7, 0xcf, 0xc7, 0x0e, 0xd2, 0xd6, 0x0c, 0xe9, 0xa2, 0x4d, 0x0d, 0xba, 0x09, 0xbc, 0x36,
+	0x9a, 0x15, 0xa2, 0x47, 0xb0, 0xae, 0x39, 0xce, 0xe8, 0x62, 0xd6, 0x7b, 0x95, 0x79, 0xbf, 0x1b,
+	0xe7, 0x7d, 0x97, 0xda, 0xcc, 0xba, 0x47, 0xda, 0x9c, 0x94, 0x06, 0xc3, 0x71, 0x89, 0xe3, 0xda,
+	0x3a, 0xf1, 0x3c, 0xd5, 0x3f, 0xf7, 0x1a, 0xb5, 0xcb, 0x83, 0x71, 0x1c, 0x68, 0xf7, 0xce, 0x59,
+	0x70, 0x9d, 0xb0, 0xa0, 0x95, 0x87, 0xec, 0x99, 0x36, 0x9a, 0x10, 0xe5, 0x3b, 0x50, 0x0a, 0x6d,
+	0x7b, 0xd4, 0x80, 0xfc, 0x98, 0x78, 0x9e, 0x36, 0x20, 0x0c, 0x25, 0x8a, 0x58, 0x76, 0x95, 0x2a,
+	0x94, 0xc3, 0x5b, 0x5d, 0xf9, 0x2c, 0x09, 0xa5, 0xd0, 0x2e, 0xa6, 0x96, 0x67, 0xc4, 0xf5, 0x4c,
+	0xdb, 0x92, 0x96, 0xa2, 0x8b, 0x9e, 0x81, 0x0a, 0x5b, 0x8f, 0xaa, 0x7c, 0x4e, 0xa1, 0x24, 0x83,
+	0xcb, 0x4c, 0xf8, 0x50, 0x28, 0x6d, 0x42, 0xc9, 0xd9, 0x71, 0x02, 0x95, 0x34, 0x53, 0x01, 0x67,
+	0xc7, 0x91, 0x0a, 0x4f, 0x43, 0x99, 0xce, 0x2f, 0xd0, 0xc8, 0xb0, 0x97, 0x94, 0xa8, 0x4c, 0xa8,
+	0x28, 0x7f, 0x4e, 0x41, 0x7d, 0x16, 0x1e, 0xd0, 0x6b, 0x90, 0xa1, 0x48, 0x29, 0x40, 0xaf, 0xb9,
+	0xc5, 0x61, 0x74, 0x4b, 0xc2, 0xe8, 0x56, 0x4f, 0xc2, 0x68, 0xab, 0xf0, 0xc5, 0x57, 0x9b, 0x89,
+	0xcf, 0xfe, 0xba, 0x99, 0xc4, 0xcc, 0x02, 0xdd, 0xa4, 0xbb, 0x59, 0x33, 0x2d, 0xd5, 0x34, 0xd8,
+	0x90, 0x8b, 0x74, 0xab, 0x6a, 0xa6, 0xb5, 0x67, 0xa0, 0x7d, 0xa8, 0xeb, 0xb6, 0xe5, 0x11, 0xcb,
+	0x9b, 0x78, 0x2a, 0x87, 0xe9, 0x46, 0x3a, 0x66, 0xb7, 0xb5, 0xa5, 0xe2, 0x31, 0xd3, 0xc3, 0x35,
+	0x3d, 0x2a, 0x40, 0xf7, 0x01, 0xce, 0xb4, 0x91, 0x69, 0x68, 0xbe, 0xed, 0x7a, 0x8d, 0xcc, 0xed,
+	0xf4, 0x42, 0x37, 0x0f, 0xa5, 0xca, 0x03, 0xc7, 0xd0, 0x7c, 0xd2, 0xca, 0xd0, 0xd1, 0xe2, 0x90,
+	0x25, 0x7a, 0x0e, 0x6a, 0x9a, 0xe3, 0xa8, 0x9e, 0xaf, 0xf9, 0x44, 0xed, 0x5f, 0xf8, 0xc4, 0x63,
+	0x28, 0x58, 0xc6, 0x15, 0xcd, 0x71, 0x4e, 0xa8, 0xb4, 0x45, 0x85, 0xe8, 0x59, 0xa8, 0x52, 0xc0,
+	0x34, 0xb5, 0x91, 0x3a, 0x24, 0xe6, 0x60, 0xe8, 0x33, 0xbc, 0x4b, 0xe3, 0x8a, 0x90, 0x76, 0x99,
+	0x50, 0x31, 0xa0, 0x1c, 0x06, 0x4b, 0x84, 0x20, 0x63, 0x68, 0xbe, 0xc6, 0x02, 0x59, 0xc6, 0xac,
+	0x4d, 0x65, 0x8e, 0xe6, 0x0f, 0x45, 0x78, 0x58, 0x1b, 0xdd, 0x80, 0x9c, 0x70, 0x9b, 0x66, 0x6e,
+	0x45, 0x0f, 0xad, 0x43, 0xd6, 0x71, 0xed, 0x33, 0xc2, 0xbe, 0x5c, 0x01, 0xf3, 0x8e, 0xf2, 0xab,
+	0x14, 0xac, 0xcd, 0xc1, 0x2a, 0xf5, 0x3b, 0xd4, 0xbc, 0xa1, 0x7c, 0x17, 0x6d, 0xa3, 0x7b, 0xd4,
+	0xaf, 0x66, 0x10, 0x57, 0xa4, 0xa2, 0x46, 0x38, 0x44, 0x3c, 0xcd, 0x76, 0xd9, 0x73, 0x11, 0x1a,
+	0xa1, 0x8d, 0x8e, 0xa0, 0x3e, 0xd2, 0x3c, 0x5f, 0xe5, 0x30, 0xa5, 0x86, 0xd2, 0xd2, 0x3c, 0x38,
+	0x1f, 0x68, 0x12, 0xd8, 0xe8, 0x9a, 0x16, 0x8e, 0xaa, 0xa3, 0x88, 0x14, 0x61, 0x58, 0xef, 0x5f,
+	0x7c, 0xa2, 0x59, 0xbe, 0x69, 0x11, 0x75, 0xee, 0xcb, 0xdd, 0x9c, 0x73, 0xda, 0x39, 0x33, 0x0d,
+	0x62, 0xe9, 0xf2, 0x93, 0x5d, 0x0b, 0x8c, 0x83, 0x4f, 0xea, 0x29, 0x18, 0xaa, 0xd1, 0xc4, 0x80,
+	0xaa, 0x90, 0xf2, 0xcf, 0x45, 0x00, 0x52, 0xfe, 0x39, 0x7a, 0x09, 0x32, 0x74, 0x92, 0x6c, 0xf2,
+	0xd5, 0x05, 0x19, 0x55, 0xd8, 0xf5, 0x2e, 0x1c, 0x82, 0x99, 0xa6, 0xa2, 0x40, 0x7d, 0x36, 0x59,
+	0xcc, 0x7a, 0x55, 0x9e, 0x87, 0xda, 0x4c, 0x36, 0x08, 0x7d, 0xbf, 0x64, 0xf8, 0xfb, 0x29, 0x35,
+	0xa8, 0x44, 0xa0, 0x5f, 0xb9, 0x01, 0xeb, 0x8b, 0x90, 0x5c, 0x19, 0xc2, 0xfa, 0x22, 0x44, 0x46,
+	0xaf, 0x42, 0x21, 0x80, 0x72, 0xbe, 0x1b, 0xe7, 0x63, 0x25, 0x95, 0x71, 0xa0, 0x4a, 0xb7, 0x21,
+	0x5d, 0xd6, 0x6c, 0x3d, 0xa4, 0xd8, 0xc0, 0xf3, 0x9a, 0xe3, 0x74, 0x35, 0x6f, 0xa8, 0x7c, 0x00,
+	0x8d, 0x38, 0x98, 0x9e, 0x99, 0x46, 0x26, 0x58, 0x86, 0x37, 0x20, 0x77, 0x6a, 0xbb, 0x63, 0xcd,
+	0x67, 0xce, 0x2a, 0x58, 0xf4, 0xe8, 0xf2, 0xe4, 0x90, 0x9d, 0x66, 0x62, 0xde, 0x51, 0x54, 0xb8,
+	0x19, 0x0b, 0xd5, 0xd4, 0xc4, 0xb4, 0x0c, 0xc2, 0xe3, 0x59, 0xc1, 0xbc, 0x33, 0x75, 0xc4, 0x07,
+	0xcb, 0x3b, 0xf4, 0xb5, 0x1e, 0x9b, 0x2b, 0xf3, 0x5f, 0xc4, 0xa2, 0xa7, 0xdc, 0x81, 0xf5, 0x45,
+	0x88, 0x8d, 0xea, 0x90, 0xa6, 0x28, 0x9f, 0xbc, 0x9d, 0xbe, 0x53, 0xc6, 0xb4, 0xa9, 0xfc, 0xa3,
+	0x00, 0x05, 0x4c, 0x3c, 0x87, 0xa2, 0x07, 0x6a, 0x41, 0x91, 0x9c, 0xeb, 0xc4, 0xf1, 0x25, 0xde,
+	0x2e, 0xa6, 0x2b, 0x5c, 0xbb, 0x23, 0x35, 0x29, 0x57, 0x08, 0xcc, 0xd0, 0x2b, 0x82, 0x0e, 0xc6,
+	0x33, 0x3b, 0x61, 0x1e, 0xe6, 0x83, 0xf7, 0x24, 0x1f, 0x4c, 0xc7, 0xd2, 0x03, 0x6e, 0x35, 0x43,
+	0x08, 0x5f, 0x11, 0x84, 0x30, 0xb3, 0xe4, 0x65, 0x11, 0x46, 0xd8, 0x8e, 0x30, 0xc2, 0xec, 0x92,
+	0x69, 0xc6, 0x50, 0xc2, 0x7b, 0x92, 0x12, 0xe6, 0x96, 0x8c, 0x78, 0x86, 0x13, 0xde, 0x8f, 0x72,
+	0x42, 0xce, 0xe7, 0x9e, 0x89, 0xb5, 0x8e, 0x25, 0x85, 0x3f, 0x08, 0x91, 0xc2, 0x42, 0x2c, 0x23,
+	0xe3, 0x4e, 0x16, 0xb0, 0xc2, 0x76, 0x84, 0x15, 0x16, 0x97, 0xc4, 0x20, 0x86, 0x16, 0xbe, 0x19,
+	0xa6, 0x85, 0x10, 0xcb, 0x2c, 0xc5, 0xf7, 0x5e, 0xc4, 0x0b, 0x5f, 0x0f, 0x78, 0x61, 0x29, 0x96,
+	0xd8, 0x8a, 0x39, 0xcc, 0x12, 0xc3, 0xa3, 0x39, 0x62, 0xc8, 0x89, 0xdc, 0x73, 0xb1, 0x2e, 0x96,
+	0x30, 0xc3, 0xa3, 0x39, 0x66, 0x58, 0x59, 0xe2, 0x70, 0x09, 0x35, 0xfc, 0xc5, 0x62, 0x6a, 0x18,
+	0x4f, 0xde, 0xc4, 0x30, 0x57, 0xe3, 0x86, 0x6a, 0x0c, 0x37, 0xe4, 0x0c, 0xee, 0xbb, 0xb1, 0xee,
+	0x57, 0x26, 0x87, 0x47, 0x73, 0xe4, 0xb0, 0xbe, 0x24, 0x1e, 0xab, 0xb2, 0xc3, 0xe7, 0x61, 0x4d,
+	0x9a, 0x04, 0x20, 0x42, 0x01, 0x8e, 0xb8, 0xae, 0xed, 0x0a, 0x9e, 0xc7, 0x3b, 0xca, 0x1d, 0x28,
+	0x07, 0xaa, 0x97, 0x33, 0x49, 0x96, 0x48, 0x42, 0x20, 0xa1, 0xfc, 0x3e, 0x09, 0xe5, 0xf0, 0xfe,
+	0x8f, 0x50, 0x8d, 0xa2, 0xa0, 0x1a, 0x21, 0x7e, 0x99, 0x8a, 0xf2, 0xcb, 0x4d, 0x28, 0xd1, 0x04,
+	0x31, 0x43, 0x1d, 0x35, 0x27, 0xa0, 0x8e, 0x77, 0x61, 0x8d, 0x31, 0x00, 0xce, 0x42, 0x45, 0x56,
+	0xc8, 0xb0, 0xe4, 0x56, 0xa3, 0x0f, 0xf8, 0x6a, 0x67, 0x62, 0xf4, 0x22, 0x5c, 0x0b, 0xe9, 0x06,
+	0x89, 0x87, 0x13, 0xa9, 0x7a, 0xa0, 0xbd, 0x2b, 0x32, 0xd0, 0x9f, 0x92, 0xb0, 0x36, 0x87, 0x3f,
+	0x0b, 0xe9, 0x61, 0xf2, 0x7f, 0x43, 0x0f, 0x53, 0xff, 0x35, 0x3d, 0x0c, 0xe7, 0xd1, 0x74, 0x34,
+	0x8f, 0xfe, 0x33, 0x09, 0x95, 0x08, 0x0a, 0xd2, 0x2f, 0xa0, 0xdb, 0x06, 0x11, 0x99, 0x8d, 0xb5,
+	0x69, 0x4a, 0x1a, 0xd9, 0x03, 0x91, 0xbf, 0x68, 0x93, 0x6a, 0x05, 0xa0, 0x5e, 0x14, 0x98, 0x1d,
+	0x24, 0xc5, 0x2c, 0x0b, 0x30, 0xef, 0x50, 0xdb, 0xc7, 0x84, 0x43, 0x70, 0x19, 0xd3, 0x26, 0x5a,
+	0x17, 0x6b, 0x8c, 0x01, 0x6b, 0x19, 0xf3, 0x0e, 0x7a, 0x0d, 0x8a, 0xac, 0x9e, 0xa2, 0xda, 0x8e,
+	0x27, 0xd0, 0xf2, 0xc9, 0xf0, 0x5c, 0x79, 0xd9, 0x64, 0xeb, 0x98, 0xea, 0x1c, 0x39, 0x1e, 0x2e,
+	0x38, 0xa2, 0x15, 0xca, 0xf7, 0xc5, 0x08, 0xed, 0xbc, 0x05, 0x45, 0x3a, 0x7a, 0xcf, 0xd1, 0x74,
+	0xc2, 0xa0, 0xaf, 0x88, 0xa7, 0x02, 0xe5, 0x11, 0xa0, 0x79, 0x00, 0x47, 0x5d, 0xc8, 0x91, 0x33,
+	0x62, 0xf9, 0x3c, 0xff, 0x96, 0x76, 0x6e, 0x2c, 0xe0, 0x74, 0xc4, 0xf2, 0x5b, 0x0d, 0x1a, 0xe4,
+	0xbf, 0x7f, 0xb5, 0x59, 0xe7, 0xda, 0x2f, 0xd8, 0x63, 0xd3, 0x27, 0x63, 0xc7, 0xbf, 0xc0, 0xc2,
+	0x5e, 0xf9, 0x63, 0x0a, 0x6a, 0xf2, 0x05, 0x92, 0xd9, 0x2d, 0x8a, 0xad, 0x5c, 0xf1, 0xa9, 0x10,
+	0xb9, 0x5e, 0x2d, 0xde, 0x1b, 0x00, 0x03, 0xcd, 0x53, 0x3f, 0xd6, 0x2c, 0x9f, 0x18, 0x22, 0xe8,
+	0x21, 0x09, 0x6a, 0x42, 0x81, 0xf6, 0x26, 0x1e, 0x31, 0x04, 0xcf, 0x0f, 0xfa, 0xa1, 0x79, 0xe6,
+	0xbf, 0xdd, 0x3c, 0xa3, 0x51, 0x2e, 0xcc, 0x44, 0x99, 0x8e, 0xc1, 0x71, 0x4d, 0xdb, 0x35, 0xfd,
+	0x0b, 0xf1, 0x75, 0x82, 0x3e, 0xdd, 0xbd, 0xde, 0x50, 0x73, 0x89, 0xaa, 0xdb, 0x13, 0x8b, 0x57,
+	0x1d, 0xd2, 0x18, 0x98, 0xa8, 0x4d, 0x25, 0xca, 0xaf, 0x53, 0xb0, 0x36, 0x97, 0xde, 0xfe, 0xff,
+	0x82, 0xa8, 0xfc, 0x86, 0x9d, 0x6e, 0xa3, 0x29, 0x1a, 0x9d, 0xc0, 0x5a, 0xb0, 0xc5, 0xd5, 0x09,
+	0xdb, 0xfa, 0x72, 0xd1, 0xae, 0x8a, 0x11, 0xf5, 0xb3, 0xa8, 0xd8, 0x43, 0x3f, 0x81, 0x27, 0x66,
+	0xe0, 0x2b, 0x70, 0x9d, 0x5a, 0x11, 0xc5, 0xae, 0x47, 0x51, 0x4c, 0x7a, 0x9e, 0xc6, 0x2a, 0xfd,
+	0x2d, 0x37, 0xd6, 0x1e, 0x54, 0x65, 0x30, 0x38, 0xe1, 0x58, 0xf8, 0xf5, 0x9f, 0x81, 0x8a, 0x4b,
+	0x7c, 0x7a, 0x86, 0x8f, 0x1c, 0x49, 0xcb, 0x5c, 0x28, 0x0e, 0xba, 0xc7, 0x70, 0x7d, 0x21, 0xf1,
+	0x40, 0xdf, 0x83, 0xe2, 0x94, 0xb3, 0x24, 0x63, 0x4e, 0x77, 0x52, 0x1d, 0x4f, 0x75, 0x95, 0x3f,
+	0x24, 0xe1, 0xfa, 0x42, 0xea, 0x81, 0x3a, 0x90, 0x73, 0x89, 0x37, 0x19, 0xf1, 0x53, 0x49, 0x75,
+	0xe7, 0xc5, 0xd5, 0x28, 0x0b, 0x95, 0x4e, 0x46, 0x3e, 0x16, 0xc6, 0xca, 0x23, 0xc8, 0x71, 0x09,
+	0x2a, 0x41, 0xfe, 0xc1, 0xe1, 0xfe, 0xe1, 0xd1, 0x7b, 0x87, 0xf5, 0x04, 0x02, 0xc8, 0xed, 0xb6,
+	0xdb, 0x9d, 0xe3, 0x5e, 0x3d, 0x89, 0x8a, 0x90, 0xdd, 0x6d, 0x1d, 0xe1, 0x5e, 0x3d, 0x45, 0xc5,
+	0xb8, 0xf3, 0x4e, 0xa7, 0xdd, 0xab, 0xa7, 0xd1, 0x1a, 0x54, 0x78, 0x5b, 0xbd, 0x7f, 0x84, 0xdf,
+	0xdd, 0xed, 0xd5, 0x33, 0x21, 0xd1, 0x49, 0xe7, 0xf0, 0xad, 0x0e, 0xae, 0x67, 0x95, 0x97, 0xe1,
+	0xa6, 0x1c, 0xc7, 0xfc, 0xc9, 0x2a, 0x38, 0xe0, 0x24, 0x43, 0x07, 0x1c, 0xe5, 0x77, 0x29, 0x68,
+	0xc6, 0x33, 0x17, 0xf4, 0xce, 0xcc, 0xc4, 0x77, 0xae, 0x40, 0x7b, 0x66, 0x66, 0x4f, 0x0b, 0x18,
+	0x2e, 0x39, 0x25, 0xbe, 0x3e, 0xe4, 0x4c, 0x8a, 0x67, 0xc5, 0x0a, 0xae, 0x08, 0x29, 0x33, 0xf2,
+	0xb8, 0xda, 0x87, 0x44, 0xf7, 0x55, 0x7e, 0xd6, 0xe2, 0x8b, 0xae, 0x88, 0x2b, 0x5c, 0x7a, 0xc2,
+	0x85, 0xca, 0x07, 0x57, 0x8a, 0x65, 0x11, 0xb2, 0xb8, 0xd3, 0xc3, 0x3f, 0xad, 0xa7, 0x11, 0x82,
+	0x2a, 0x6b, 0xaa, 0x27, 0x87, 0xbb, 0xc7, 0x27, 0xdd, 0x23, 0x1a, 0xcb, 0x6b, 0x50, 0x93, 0xb1,
+	0x94, 0xc2, 0xac, 0xa2, 0xc1, 0xf5, 0x85, 0xc4, 0x6b, 0xfe, 0x90, 0x87, 0xee, 0x41, 0x41, 0xd0,
+	0x24, 0xb9, 0xd7, 0x9a, 0xf3, 0x65, 0x8e, 0x77, 0x85, 0x06, 0x0e, 0x74, 0x95, 0x7f, 0x27, 0xa1,
+	0x36, 0xb3, 0x07, 0xd1, 0x0e, 0x64, 0x39, 0xe1, 0x8f, 0xab, 0xf7, 0x33, 0x08, 0x11, 0x1b, 0x36,
+	0xdb, 0x97, 0x15, 0x6c, 0x22, 0xca, 0x15, 0x8b, 0xf6, 0x3a, 0x7f, 0xbf, 0x2c, 0x68, 0x08, 0xd3,
+	0xc0, 0x82, 0x56, 0x9f, 0x03, 0x30, 0x69, 0xa4, 0xe7, 0x8f, 0x19, 0xdc, 0x3c, 0x80, 0x21, 0x61,
+	0x3f, 0xb5, 0x41, 0xaf, 0x4f, 0x49, 0x5e, 0x66, 0xfe, 0x98, 0x21, 0xcc, 0xb9, 0x82, 0x30, 0x96,
+	0xfa, 0x4a, 0x1b, 0x4a, 0xa1, 0xf9, 0xa0, 0x27, 0xa1, 0x38, 0xd6, 0xce, 0x45, 0x19, 0x8c, 0x17,
+	0x32, 0x0a, 0x63, 0xed, 0x9c, 0x57, 0xc0, 0x9e, 0x80, 0x3c, 0x7d, 0x38, 0xd0, 0x78, 0x90, 0xd3,
+	0x38, 0x37, 0xd6, 0xce, 0xdf, 0xd6, 0x3c, 0xe5, 0x7d, 0xa8, 0x46, 0x4b, 0x40, 0x74, 0xb1, 0xbb,
+	0xf6, 0xc4, 0x32, 0x98, 0x8f, 0x2c, 0xe6, 0x1d, 0x7a, 0xcd, 0x70, 0x66, 0x73, 0x3c, 0x5c, 0x8c,
+	0x0a, 0x0f, 0x6d, 0x9f, 0x84, 0x4a, 0x48, 0x5c, 0x5b, 0xf9, 0x04, 0xb2, 0x0c, 0xdf, 0x28, 0x56,
+	0xb1, 0x62, 0x8e, 0x20, 0xb8, 0xb4, 0x8d, 0xde, 0x07, 0xd0, 0x7c, 0xdf, 0x35, 0xfb, 0x93, 0xa9,
+	0xe3, 0xcd, 0xc5, 0xf8, 0xb8, 0x2b, 0xf5, 0x5a, 0xb7, 0x04, 0x50, 0xae, 0x4f, 0x4d, 0x43, 0x60,
+	0x19, 0x72, 0xa8, 0x1c, 0x42, 0x35, 0x6a, 0x2b, 0x39, 0x59, 0x72, 0x01, 0x27, 0x4b, 0x85, 0x39,
+	0x59, 0xc0, 0xe8, 0xd2, 0xbc, 0x70, 0xc7, 0x3a, 0xca, 0xa7, 0x49, 0x28, 0xf4, 0xce, 0xc5, 0xce,
+	0x89, 0xa9, 0x19, 0x4d, 0x4d, 0x53, 0xe1, 0x0a, 0x09, 0x2f, 0x42, 0xa5, 0x83, 0xd2, 0xd6, 0x9b,
+	0x01, 0x36, 0x64, 0x56, 0x3d, 0xde, 0xca, 0x1a, 0x9f, 0xc0, 0xc3, 0x37, 0xa0, 0x18, 0xac, 0x2a,
+	0x7a, 0x52, 0xd0, 0x0c, 0xc3, 0x25, 0x9e, 0x27, 0xe6, 0x26, 0xbb, 0x74, 0x38, 0x8e, 0xfd, 0xb1,
+	0xa8, 0xc1, 0xa4, 0x31, 0xef, 0x28, 0x06, 0xd4, 0x66, 0x32, 0x23, 0x7a, 0x03, 0xf2, 0xce, 0xa4,
+	0xaf, 0xca, 0xf0, 0xcc, 0x6c, 0x1e, 0x49, 0x42, 0x27, 0xfd, 0x91, 0xa9, 0xef, 0x93, 0x0b, 0x39,
+	0x18, 0x67, 0xd2, 0xdf, 0xe7, 0x51, 0xe4, 0x6f, 0x49, 0x85, 0xdf, 0x72, 0x06, 0x05, 0xb9, 0x28,
+	0xd0, 0x0f, 0xc3, 0xfb, 0x24, 0x39, 0xbf, 0xcd, 0xa3, 0xd9, 0x5a, 0xb8, 0x9f, 0x9a, 0xd0, 0x03,
+	0x8d, 0x67, 0x0e, 0x2c, 0x62, 0xa8, 0xd3, 0xb3, 0x0a, 0x7b, 0x5b, 0x01, 0xd7, 0xf8, 0x83, 0x03,
+	0x79, 0x50, 0x51, 0xfe, 0x95, 0x84, 0x82, 0xdc, 0xb0, 0xe8, 0xe5, 0xd0, 0xba, 0xab, 0x2e, 0xa8,
+	0xc2, 0x48, 0xc5, 0x69, 0x15, 0x31, 0x3a, 0xd6, 0xd4, 0xd5, 0xc7, 0x1a, 0x57, 0x0e, 0x96, 0x75,
+	0xf9, 0xcc, 0x95, 0xeb, 0xf2, 0x2f, 0x00, 0xf2, 0x6d, 0x5f, 0x1b, 0xa9, 0x67, 0xb6, 0x6f, 0x5a,
+	0x03, 0x95, 0x07, 0x9b, 0x93, 0xb6, 0x3a, 0x7b, 0xf2, 0x90, 0x3d, 0x38, 0x66, 0x71, 0xff, 0x65,
+	0x12, 0x0a, 0x41, 0xfa, 0xbd, 0x6a, 0x51, 0xf0, 0x06, 0xe4, 0x44, 0x86, 0xe1, 0x55, 0x41, 0xd1,
+	0x0b, 0xea, 0xd3, 0x99, 0x50, 0x7d, 0xba, 0x49, 0xa1, 0xdb, 0xd7, 0x18, 0x07, 0xe1, 0xc7, 0xc5,
+	0xa0, 0x7f, 0xf7, 0x75, 0x28, 0x85, 0xea, 0xb3, 0x74, 0xe7, 0x1d, 0x76, 0xde, 0xab, 0x27, 0x9a,
+	0xf9, 0x4f, 0x3f, 0xbf, 0x9d, 0x3e, 0x24, 0x1f, 0xd3, 0x35, 0x8b, 0x3b, 0xed, 0x6e, 0xa7, 0xbd,
+	0x5f, 0x4f, 0x36, 0x4b, 0x9f, 0x7e, 0x7e, 0x3b, 0x8f, 0x09, 0xab, 0x00, 0xdd, 0xed, 0x42, 0x39,
+	0xfc, 0x55, 0xa2, 0x49, 0x0a, 0x41, 0xf5, 0xad, 0x07, 0xc7, 0x07, 0x7b, 0xed, 0xdd, 0x5e, 0x47,
+	0x7d, 0x78, 0xd4, 0xeb, 0xd4, 0x93, 0xe8, 0x09, 0xb8, 0x76, 0xb0, 0xf7, 0x76, 0xb7, 0xa7, 0xb6,
+	0x0f, 0xf6, 0x3a, 0x87, 0x3d, 0x75, 0xb7, 0xd7, 0xdb, 0x6d, 0xef, 0xd7, 0x53, 0x3b, 0xbf, 0x05,
+	0xa8, 0xed, 0xb6, 0xda, 0x7b, 0x34, 0xc1, 0x9a, 0xba, 0xc6, 0xce, 0xf2, 0x6d, 0xc8, 0xb0, 0xd3,
+	0xfa, 0xa5, 0x97, 0xc1, 0xcd, 0xcb, 0x6b, 0x83, 0xe8, 0x3e, 0x64, 0xd9, 0x41, 0x1e, 0x5d, 0x7e,
+	0x3b, 0xdc, 0x5c, 0x52, 0x2c, 0xa4, 0x83, 0x61, 0xdb, 0xe3, 0xd2, 0xeb, 0xe2, 0xe6, 0xe5, 0xb5,
+	0x43, 0x84, 0xa1, 0x38, 0x3d, 0x25, 0x2c, 0xbf, 0x3e, 0x6d, 0xae, 0x00, 0x36, 0xe8, 0x00, 0xf2,
+	0xf2, 0xf0, 0xb6, 0xec, 0x42, 0xb7, 0xb9, 0xb4, 0xb8, 0x47, 0xc3, 0xc5, 0x0f, 0xd9, 0x97, 0xdf,
+	0x4e, 0x37, 0x97, 0x54, 0x2a, 0xd1, 0x1e, 0xe4, 0x04, 0xf5, 0x5d, 0x72, 0x49, 0xdb, 0x5c, 0x56,
+	0xac, 0xa3, 0x41, 0x9b, 0x56, 0x2f, 0x96, 0xdf, 0xb9, 0x37, 0x57, 0x28, 0xc2, 0xa2, 0x07, 0x00,
+	0xa1, 0x23, 0xf5, 0x0a, 0x97, 0xe9, 0xcd, 0x55, 0x8a, 0xab, 0xe8, 0x08, 0x0a, 0xc1, 0xe9, 0x67,
+	0xe9, 0xd5, 0x76, 0x73, 0x79, 0x95, 0x13, 0x3d, 0x82, 0x4a, 0x94, 0xf6, 0xaf, 0x76, 0x61, 0xdd,
+	0x5c, 0xb1, 0x7c, 0x49, 0xfd, 0x47, 0xcf, 0x00, 0xab, 0x5d, 0x60, 0x37, 0x57, 0xac, 0x66, 0xa2,
+	0x0f, 0x61, 0x6d, 0x9e, 0xa3, 0xaf, 0x7e, 0x9f, 0xdd, 0xbc, 0x42, 0x7d, 0x13, 0x8d, 0x01, 0x2d,
+	0xe0, 0xf6, 0x57, 0xb8, 0xde, 0x6e, 0x5e, 0xa5, 0xdc, 0x49, 0x43, 0x17, 0x25, 0xcc, 0xab, 0x5d,
+	0x77, 0x37, 0x57, 0x2c, 0x7c, 0xb6, 0xde, 0xf9, 0xe2, 0xeb, 0x8d, 0xe4, 0x97, 0x5f, 0x6f, 0x24,
+	0xff, 0xf6, 0xf5, 0x46, 0xf2, 0xb3, 0x6f, 0x36, 0x12, 0x5f, 0x7e, 0xb3, 0x91, 0xf8, 0xcb, 0x37,
+	0x1b, 0x89, 0x9f, 0xbd, 0x34, 0x30, 0xfd, 0xe1, 0xa4, 0xbf, 0xa5, 0xdb, 0xe3, 0xed, 0x91, 0xf6,
+	0xc9, 0xc5, 0x88, 0x18, 0x03, 0xe2, 0x86, 0x9a, 0x2f, 0xea, 0xb6, 0x4b, 0x42, 0x3f, 0x0c, 0xf5,
+	0x73, 0x2c, 0x73, 0xbd, 0xf2, 0x9f, 0x01, 0x00, 0x92, 0x7d, 0x06, 0xaf, 0x50, 0x24, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -5588,6 +5605,16 @@ func (m *ResponseCheckTx) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.ShareCount != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.ShareCount))
+		i--
+		dAtA[i] = 0x50
+	}
+	if m.Priority != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.Priority))
+		i--
+		dAtA[i] = 0x48
+	}
 	if len(m.Codespace) > 0 {
 		i -= len(m.Codespace)
 		copy(dAtA[i:], m.Codespace)
@@ -7369,6 +7396,12 @@ func (m *ResponseCheckTx) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovTypes(uint64(l))
 	}
+	if m.Priority != 0 {
+		n += 1 + sovTypes(uint64(m.Priority))
+	}
+	if m.ShareCount != 0 {
+		n += 1 + sovTypes(uint64(m.ShareCount))
+	}
 	return n
 }
 
@@ -11794,6 +11827,44 @@ func (m *ResponseCheckTx) Unmarshal(dAtA []byte) error {
 			}
 			m.Codespace = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Priority", wireType)
+			}
+			m.Priority = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Priority |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ShareCount", wireType)
+			}
+			m.ShareCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ShareCount |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
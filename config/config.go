@@ -641,6 +641,42 @@ type MempoolConfig struct {
 	// Maximum size of a batch of transactions to send to a peer
 	// Including space needed by encoding (one varint per transaction).
 	MaxBatchBytes int `mapstructure:"max-batch-bytes"`
+	// Size of the cache of CheckTx results, in transactions. Resubmitted txs
+	// whose result is cached are not checked by the app again. 0 disables it.
+	// NOTE: only enable it if the result of CheckTx for a given tx does not
+	// depend on the app state (within the TTL).
+	ResultCacheSize int `mapstructure:"result-cache-size"`
+	// Time a CheckTx result is kept in the result cache.
+	ResultCacheTTL time.Duration `mapstructure:"result-cache-ttl"`
+	// If true, a valid tx received while the mempool is full evicts the
+	// resident txs with the lowest priority (as reported by the app in
+	// CheckTx) to make room, as long as their priority is lower than its own.
+	EvictLowestPriority bool `mapstructure:"evict-lowest-priority"`
+	// Number of txs written to the WAL between syncs of the WAL to disk.
+	// 0 syncs it after every tx.
+	WALSyncEvery int `mapstructure:"wal-sync-every"`
+	// Path to a file with a secret used to sign each WAL entry with an HMAC,
+	// so tampering with the WAL can be detected. Empty disables signing.
+	WALHMACKeyFile string `mapstructure:"wal-hmac-key-file"`
+	// Maximum time to wait for the app to respond to the CheckTx of a new tx.
+	// If it doesn't respond in time, the tx is rejected. 0 waits forever.
+	CheckTxTimeout time.Duration `mapstructure:"check-tx-timeout"`
+	// If true, a bloom filter in front of the cache skips the cache lookup for
+	// txs that were definitely not seen before.
+	UseBloomFilter bool `mapstructure:"use-bloom-filter"`
+	// Number of txs the bloom filter is sized for. It is at least twice the
+	// cache-size.
+	BloomFilterSize int `mapstructure:"bloom-filter-size"`
+	// Target false positive rate of the bloom filter, between 0 and 1.
+	BloomFilterFPRate float64 `mapstructure:"bloom-filter-fp-rate"`
+	// Maximum random delay before a new tx is gossiped to peers, to spread the
+	// broadcast of txs arriving at once. 0 gossips txs immediately.
+	GossipDelay time.Duration `mapstructure:"gossip-delay"`
+	// If the height passed to Update is more than this many blocks above the
+	// last one (e.g. after the node caught up via block or state sync), the
+	// txs left in the mempool are dropped instead of rechecked, as they are
+	// likely stale. 0 disables it.
+	MaxHeightGap int64 `mapstructure:"max-height-gap"`
 }
 
 // DefaultMempoolConfig returns a default configuration for the Tendermint mempool
@@ -656,6 +692,10 @@ func DefaultMempoolConfig() *MempoolConfig {
 		CacheSize:     10000,
 		MaxTxBytes:    1024 * 1024,      // 1MB
 		MaxBatchBytes: 10 * 1024 * 1024, // 10MB
+		// disabled by default
+		ResultCacheSize:   0,
+		ResultCacheTTL:    1 * time.Second,
+		BloomFilterFPRate: 0.01,
 	}
 }
 
@@ -671,6 +711,12 @@ func (cfg *MempoolConfig) WalDir() string {
 	return rootify(cfg.WalPath, cfg.RootDir)
 }
 
+// WALHMACKeyFilePath returns the full path to the file with the secret used to
+// sign the WAL entries.
+func (cfg *MempoolConfig) WALHMACKeyFilePath() string {
+	return rootify(cfg.WALHMACKeyFile, cfg.RootDir)
+}
+
 // WalEnabled returns true if the WAL is enabled.
 func (cfg *MempoolConfig) WalEnabled() bool {
 	return cfg.WalPath != ""
@@ -697,6 +743,30 @@ func (cfg *MempoolConfig) ValidateBasic() error {
 	if cfg.MaxBatchBytes <= cfg.MaxTxBytes {
 		return errors.New("max-batch-bytes can't be less or equal to max-tx-bytes")
 	}
+	if cfg.ResultCacheSize < 0 {
+		return errors.New("result-cache-size can't be negative")
+	}
+	if cfg.ResultCacheTTL < 0 {
+		return errors.New("result-cache-ttl can't be negative")
+	}
+	if cfg.WALSyncEvery < 0 {
+		return errors.New("wal-sync-every can't be negative")
+	}
+	if cfg.CheckTxTimeout < 0 {
+		return errors.New("check-tx-timeout can't be negative")
+	}
+	if cfg.BloomFilterSize < 0 {
+		return errors.New("bloom-filter-size can't be negative")
+	}
+	if cfg.UseBloomFilter && (cfg.BloomFilterFPRate <= 0 || cfg.BloomFilterFPRate >= 1) {
+		return errors.New("bloom-filter-fp-rate must be between 0 and 1")
+	}
+	if cfg.GossipDelay < 0 {
+		return errors.New("gossip-delay can't be negative")
+	}
+	if cfg.MaxHeightGap < 0 {
+		return errors.New("max-height-gap can't be negative")
+	}
 	return nil
 }
 
@@ -114,6 +114,13 @@ func TestMempoolConfigValidateBasic(t *testing.T) {
 		"MaxTxsBytes",
 		"CacheSize",
 		"MaxTxBytes",
+		"ResultCacheSize",
+		"ResultCacheTTL",
+		"WALSyncEvery",
+		"CheckTxTimeout",
+		"BloomFilterSize",
+		"GossipDelay",
+		"MaxHeightGap",
 	}
 
 	for _, fieldName := range fieldsToTest {
@@ -121,6 +128,16 @@ func TestMempoolConfigValidateBasic(t *testing.T) {
 		assert.Error(t, cfg.ValidateBasic())
 		reflect.ValueOf(cfg).Elem().FieldByName(fieldName).SetInt(0)
 	}
+
+	// the false positive rate is only checked if the bloom filter is used
+	cfg.BloomFilterFPRate = 0
+	assert.NoError(t, cfg.ValidateBasic())
+	cfg.UseBloomFilter = true
+	assert.Error(t, cfg.ValidateBasic())
+	cfg.BloomFilterFPRate = 1
+	assert.Error(t, cfg.ValidateBasic())
+	cfg.BloomFilterFPRate = 0.01
+	assert.NoError(t, cfg.ValidateBasic())
 }
 
 func TestStateSyncConfigValidateBasic(t *testing.T) {
@@ -319,6 +319,16 @@ recheck = {{ .Mempool.Recheck }}
 broadcast = {{ .Mempool.Broadcast }}
 wal-dir = "{{ js .Mempool.WalPath }}"
 
+# Number of txs written to the WAL between syncs of the WAL to disk. Syncing
+# less often speeds up CheckTx, but the txs written since the last sync may be
+# lost if the machine crashes. 0 syncs the WAL after every tx.
+wal-sync-every = {{ .Mempool.WALSyncEvery }}
+
+# Path to a file with a secret (relative to the home dir, if not absolute) used
+# to sign each WAL entry with an HMAC, so tampering with the WAL can be detected.
+# If set, each tx is written hex encoded, followed by its HMAC, on its own line.
+wal-hmac-key-file = "{{ js .Mempool.WALHMACKeyFile }}"
+
 # Maximum number of transactions in the mempool
 size = {{ .Mempool.Size }}
 
@@ -338,6 +348,44 @@ max-tx-bytes = {{ .Mempool.MaxTxBytes }}
 # Including space needed by encoding (one varint per transaction).
 max-batch-bytes = {{ .Mempool.MaxBatchBytes }}
 
+# Size of the cache of CheckTx results (used to skip checking resubmitted
+# txs again) in transactions. 0 disables the cache.
+# NOTE: only enable it if the result of CheckTx for a given tx does not
+# depend on the app state (within result-cache-ttl).
+result-cache-size = {{ .Mempool.ResultCacheSize }}
+
+# Time a CheckTx result is kept in the result cache.
+result-cache-ttl = "{{ .Mempool.ResultCacheTTL }}"
+
+# If true, a valid tx received while the mempool is full evicts the txs with
+# the lowest priority (as reported by the app in CheckTx) to make room, as
+# long as their priority is lower than its own. Otherwise, the tx is rejected.
+evict-lowest-priority = {{ .Mempool.EvictLowestPriority }}
+
+# Maximum time to wait for the app to respond to the CheckTx of a new tx.
+# If it doesn't respond in time, the tx is rejected. 0 waits forever.
+check-tx-timeout = "{{ .Mempool.CheckTxTimeout }}"
+
+# If true, a bloom filter in front of the cache skips the cache lookup for txs
+# that were definitely not seen before.
+use-bloom-filter = {{ .Mempool.UseBloomFilter }}
+
+# Number of txs the bloom filter is sized for. It is at least twice cache-size.
+bloom-filter-size = {{ .Mempool.BloomFilterSize }}
+
+# Target false positive rate of the bloom filter, between 0 and 1.
+bloom-filter-fp-rate = {{ .Mempool.BloomFilterFPRate }}
+
+# Maximum random delay before a new tx is gossiped to peers, to spread the
+# broadcast of txs arriving at once. 0 gossips txs immediately.
+gossip-delay = "{{ .Mempool.GossipDelay }}"
+
+# If the height of a committed block is more than this many blocks above the
+# previous one (e.g. after the node caught up via block or state sync), the
+# txs left in the mempool are dropped instead of rechecked, as they are likely
+# stale. 0 disables it.
+max-height-gap = {{ .Mempool.MaxHeightGap }}
+
 #######################################################
 ###         State Sync Configuration Options        ###
 #######################################################
@@ -5,7 +5,9 @@ import (
 	"testing"
 
 	"github.com/lazyledger/lazyledger-core/abci/example/kvstore"
+	abci "github.com/lazyledger/lazyledger-core/abci/types"
 	"github.com/lazyledger/lazyledger-core/proxy"
+	"github.com/lazyledger/lazyledger-core/types"
 )
 
 func BenchmarkReap(b *testing.B) {
@@ -43,16 +45,47 @@ func BenchmarkCheckTx(b *testing.B) {
 	}
 }
 
+// BenchmarkCheckTxUpdateChurn adds txs large enough for hashing to matter
+// and removes them again by committing them, as under a steady tx load.
+func BenchmarkCheckTxUpdateChurn(b *testing.B) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	const batchSize = 100
+	txs := make(types.Txs, batchSize)
+	res := make([]*abci.ResponseDeliverTx, batchSize)
+	for i := range res {
+		res[i] = &abci.ResponseDeliverTx{Code: abci.CodeTypeOK}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range txs {
+			tx := make([]byte, 10*1024)
+			binary.BigEndian.PutUint64(tx, uint64(i*batchSize+j))
+			txs[j] = tx
+			if err := mempool.CheckTx(tx, nil, TxInfo{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := mempool.Update(int64(i+1), txs, res, nil, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func BenchmarkCacheInsertTime(b *testing.B) {
 	cache := newMapTxCache(b.N)
-	txs := make([][]byte, b.N)
+	txKeys := make([][TxKeySize]byte, b.N)
 	for i := 0; i < b.N; i++ {
-		txs[i] = make([]byte, 8)
-		binary.BigEndian.PutUint64(txs[i], uint64(i))
+		tx := make([]byte, 8)
+		binary.BigEndian.PutUint64(tx, uint64(i))
+		txKeys[i] = TxKey(tx)
 	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		cache.Push(txs[i])
+		cache.Push(txKeys[i])
 	}
 }
 
@@ -60,14 +93,46 @@ func BenchmarkCacheInsertTime(b *testing.B) {
 // txs in parallel, which may cause some overhead due to mutex locking.
 func BenchmarkCacheRemoveTime(b *testing.B) {
 	cache := newMapTxCache(b.N)
-	txs := make([][]byte, b.N)
+	txKeys := make([][TxKeySize]byte, b.N)
 	for i := 0; i < b.N; i++ {
-		txs[i] = make([]byte, 8)
-		binary.BigEndian.PutUint64(txs[i], uint64(i))
-		cache.Push(txs[i])
+		tx := make([]byte, 8)
+		binary.BigEndian.PutUint64(tx, uint64(i))
+		txKeys[i] = TxKey(tx)
+		cache.Push(txKeys[i])
 	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		cache.Remove(txs[i])
+		cache.Remove(txKeys[i])
+	}
+}
+
+// BenchmarkCacheBloomFilterChurn pushes new txs to a full cache, as under a
+// steady load of new txs, and reports the number of lookups in the cache map
+// per tx, with and without the bloom filter.
+func BenchmarkCacheBloomFilterChurn(b *testing.B) {
+	const cacheSize = 10000
+	for _, bc := range []struct {
+		name  string
+		cache *mapTxCache
+	}{
+		{"exact", newMapTxCache(cacheSize)},
+		{"bloom", newMapTxCacheWithBloomFilter(cacheSize, 0, 0.01)},
+	} {
+		bc := bc
+		b.Run(bc.name, func(b *testing.B) {
+			txKeys := make([][TxKeySize]byte, b.N)
+			for i := range txKeys {
+				tx := make([]byte, 8)
+				binary.BigEndian.PutUint64(tx, uint64(i))
+				txKeys[i] = TxKey(tx)
+			}
+			bc.cache.Reset()
+			bc.cache.lookups = 0
+			b.ResetTimer()
+			for i := range txKeys {
+				bc.cache.Push(txKeys[i])
+			}
+			b.ReportMetric(float64(bc.cache.lookups)/float64(b.N), "lookups/op")
+		})
 	}
 }
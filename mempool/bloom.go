@@ -0,0 +1,71 @@
+package mempool
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// bloomFilter is a bloom filter of TxKeys. As TxKeys are hashes, the positions
+// of a key in the filter are derived from its bytes directly.
+type bloomFilter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint64 // number of positions set for each key
+}
+
+// newBloomFilter returns an empty bloom filter sized for n keys with the
+// given false positive rate.
+func newBloomFilter(n int, fpRate float64) *bloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &bloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// add adds txKey to the filter.
+func (f *bloomFilter) add(txKey [TxKeySize]byte) {
+	h1, h2 := bloomHashes(txKey)
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		f.bits[pos/64] |= 1 << (pos % 64)
+	}
+}
+
+// has returns false if txKey was definitely not added to the filter since it
+// was last reset. If it returns true, txKey was likely added.
+func (f *bloomFilter) has(txKey [TxKeySize]byte) bool {
+	h1, h2 := bloomHashes(txKey)
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		if f.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// reset removes all keys from the filter.
+func (f *bloomFilter) reset() {
+	for i := range f.bits {
+		f.bits[i] = 0
+	}
+}
+
+// bloomHashes returns the two hashes of txKey combined to compute its
+// positions in the filter (double hashing). h2 is odd, so the positions of a
+// key don't all coincide.
+func bloomHashes(txKey [TxKeySize]byte) (h1, h2 uint64) {
+	return binary.LittleEndian.Uint64(txKey[0:8]), binary.LittleEndian.Uint64(txKey[8:16]) | 1
+}
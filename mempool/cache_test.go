@@ -23,13 +23,13 @@ func TestCacheRemove(t *testing.T) {
 		_, err := rand.Read(txBytes)
 		require.NoError(t, err)
 		txs[i] = txBytes
-		cache.Push(txBytes)
+		cache.Push(TxKey(txBytes))
 		// make sure its added to both the linked list and the map
 		require.Equal(t, i+1, len(cache.cacheMap))
 		require.Equal(t, i+1, cache.list.Len())
 	}
 	for i := 0; i < numTxs; i++ {
-		cache.Remove(txs[i])
+		cache.Remove(TxKey(txs[i]))
 		// make sure its removed from both the map and the linked list
 		require.Equal(t, numTxs-(i+1), len(cache.cacheMap))
 		require.Equal(t, numTxs-(i+1), cache.list.Len())
@@ -102,3 +102,39 @@ func TestCacheAfterUpdate(t *testing.T) {
 		mempool.Flush()
 	}
 }
+
+func TestCacheBloomFilter(t *testing.T) {
+	const cacheSize = 100
+	cache := newMapTxCacheWithBloomFilter(cacheSize, 0, 0.01)
+
+	// enough txs to evict most of them and rebuild the filter several times
+	txKeys := make([][TxKeySize]byte, 10*cacheSize)
+	for i := range txKeys {
+		txBytes := make([]byte, 32)
+		_, err := rand.Read(txBytes)
+		require.NoError(t, err)
+		txKeys[i] = TxKey(txBytes)
+
+		// new txs are never rejected
+		require.True(t, cache.Push(txKeys[i]), "tx %d", i)
+		require.False(t, cache.Push(txKeys[i]), "tx %d", i)
+		require.LessOrEqual(t, cache.bloomKeys, cache.bloomCapacity)
+	}
+
+	// the txs still in the cache are rejected, the evicted ones are not
+	for _, txKey := range txKeys[len(txKeys)-cacheSize:] {
+		require.False(t, cache.Push(txKey))
+	}
+	for _, txKey := range txKeys[:cacheSize] {
+		require.True(t, cache.Push(txKey))
+	}
+
+	// removed txs are not rejected even though they are still in the filter
+	cache.Remove(txKeys[0])
+	require.True(t, cache.Push(txKeys[0]))
+
+	cache.Reset()
+	for _, txKey := range txKeys {
+		require.True(t, cache.Push(txKey))
+	}
+}
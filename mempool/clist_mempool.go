@@ -6,9 +6,12 @@ import (
 	"context"
 	"crypto/sha256"
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	abcicli "github.com/lazyledger/lazyledger-core/abci/client"
 	abci "github.com/lazyledger/lazyledger-core/abci/types"
 	cfg "github.com/lazyledger/lazyledger-core/config"
 	auto "github.com/lazyledger/lazyledger-core/libs/autofile"
@@ -16,10 +19,11 @@ import (
 	"github.com/lazyledger/lazyledger-core/libs/log"
 	tmmath "github.com/lazyledger/lazyledger-core/libs/math"
 	tmos "github.com/lazyledger/lazyledger-core/libs/os"
+	tmrand "github.com/lazyledger/lazyledger-core/libs/rand"
 	tmsync "github.com/lazyledger/lazyledger-core/libs/sync"
-	"github.com/lazyledger/lazyledger-core/p2p"
 	"github.com/lazyledger/lazyledger-core/proxy"
 	"github.com/lazyledger/lazyledger-core/types"
+	"github.com/lazyledger/nmt/namespace"
 )
 
 // TxKeySize is the size of the transaction key index
@@ -36,12 +40,30 @@ var newline = []byte("\n")
 // be efficiently accessed by multiple concurrent readers.
 type CListMempool struct {
 	// Atomic integers
-	height   int64 // the last block Update()'d to
-	txsBytes int64 // total size of mempool, in bytes
+	height    int64 // the last block Update()'d to
+	txsBytes  int64 // total size of mempool, in bytes
+	txsGas    int64 // total gas wanted by all txs in mempool
+	txsShares int64 // total shares occupied by all txs in mempool
+	walTxs    int64 // number of txs written to the WAL
+
+	// Atomic integers, number of txs dropped for each dropReason
+	droppedTxs [numDropReasons]uint64
+
+	// Atomic integers, capacity reserved by Reserve and not yet used
+	reservedBytes int64
+	reservedTxs   int64
+
+	// Atomic boolean, CheckTx rejects all txs while set (see SetAcceptingTxs)
+	notAcceptingTxs int32
+
+	// Atomic boolean, set while the txs are rechecked after an Update, i.e.
+	// while recheckCursor is not nil
+	rechecking int32
 
 	// notify listeners (ie. consensus) when txs are available
 	notifiedTxsAvailable bool
 	txsAvailable         chan struct{} // fires once for each height, when the mempool is not empty
+	txsDrained           chan struct{} // fires when the mempool becomes empty
 
 	config *cfg.MempoolConfig
 
@@ -51,15 +73,30 @@ type CListMempool struct {
 	preCheck  PreCheckFunc
 	postCheck PostCheckFunc
 
+	// Serializes Reserve, so that concurrent reservations can't exceed the
+	// capacity of the mempool.
+	reserveMtx tmsync.Mutex
+
+	// Optional, used to record the namespace of each tx for ReapByNamespace.
+	namespaceExtractor NamespaceExtractor
+
+	// Optional, used by the reap methods to skip out of order txs.
+	// Protected by updateMtx.
+	orderingValidator OrderingValidator
+
+	// Optional, notified of the txs committed at each height by Update.
+	inclusionRecorder InclusionRecorder
+
 	wal          *auto.AutoFile // a log of mempool txs
+	walSecret    []byte         // signs the WAL entries, if set
 	txs          *clist.CList   // concurrent linked-list of good txs
 	proxyAppConn proxy.AppConnMempool
 
 	// Track whether we're rechecking txs.
 	// These are not protected by a mutex and are expected to be mutated in
 	// serial (ie. by abci responses which are called in serial).
-	recheckCursor *clist.CElement // next expected response
-	recheckEnd    *clist.CElement // re-checking stops here
+	recheckCursor *clist.CElement   // next expected response
+	recheckQueue  []*clist.CElement // txs to recheck after the cursor, in order
 
 	// Map for quick access to txs to record sender in CheckTx.
 	// txsMap: txKey -> CElement
@@ -69,9 +106,25 @@ type CListMempool struct {
 	// This reduces the pressure on the proxyApp.
 	cache txCache
 
+	// Optional cache of the latest CheckTx results, used to skip checking
+	// resubmitted txs again.
+	resultCache txResultCache
+
+	// Next nonce expected for each sender address with txs in the mempool,
+	// i.e. the nonce following the one of the sender's last committed tx. Only
+	// txs that went through this mempool are known, so if the sender's lowest
+	// nonce in the mempool is higher (e.g. the txs in between were committed
+	// in blocks proposed by others), the reaping starts there instead.
+	// Entries of senders without txs left are dropped by Update.
+	// sender -> nonce. Protected by updateMtx.
+	senderNonces map[string]uint64
+
 	logger log.Logger
 
 	metrics *Metrics
+
+	// now returns the current time, it is overridden in tests.
+	now func() time.Time
 }
 
 var _ Mempool = &CListMempool{}
@@ -92,15 +145,25 @@ func NewCListMempool(
 		txs:           clist.New(),
 		height:        height,
 		recheckCursor: nil,
-		recheckEnd:    nil,
+		recheckQueue:  nil,
 		logger:        log.NewNopLogger(),
 		metrics:       NopMetrics(),
+		now:           time.Now,
+		senderNonces:  make(map[string]uint64),
+		txsDrained:    make(chan struct{}, 1),
 	}
-	if config.CacheSize > 0 {
+	if config.CacheSize > 0 && config.UseBloomFilter {
+		mempool.cache = newMapTxCacheWithBloomFilter(config.CacheSize, config.BloomFilterSize, config.BloomFilterFPRate)
+	} else if config.CacheSize > 0 {
 		mempool.cache = newMapTxCache(config.CacheSize)
 	} else {
 		mempool.cache = nopTxCache{}
 	}
+	if config.ResultCacheSize > 0 {
+		mempool.resultCache = newMapTxResultCache(config.ResultCacheSize, config.ResultCacheTTL)
+	} else {
+		mempool.resultCache = nopTxResultCache{}
+	}
 	proxyAppConn.SetResponseCallback(mempool.globalCb)
 	for _, option := range options {
 		option(mempool)
@@ -132,11 +195,40 @@ func WithPostCheck(f PostCheckFunc) CListMempoolOption {
 	return func(mem *CListMempool) { mem.postCheck = f }
 }
 
+// WithNamespaceExtractor sets the function used to determine the namespace
+// of each tx added to the mempool. Without it, ReapByNamespace returns no txs.
+func WithNamespaceExtractor(f NamespaceExtractor) CListMempoolOption {
+	return func(mem *CListMempool) { mem.namespaceExtractor = f }
+}
+
+// WithInclusionRecorder sets the recorder Update reports the keys of the
+// committed txs to.
+func WithInclusionRecorder(r InclusionRecorder) CListMempoolOption {
+	return func(mem *CListMempool) { mem.inclusionRecorder = r }
+}
+
+// SetOrderingValidator sets the function used by ReapMaxBytesMaxGas and
+// ReapWithStrategy to check each tx against the previous reaped tx. A tx for
+// which it returns false is skipped, and the next tx is checked against the
+// same previous tx. The first reaped tx is not checked. A nil validator
+// disables the check.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) SetOrderingValidator(validator OrderingValidator) {
+	mem.updateMtx.Lock()
+	defer mem.updateMtx.Unlock()
+	mem.orderingValidator = validator
+}
+
 // WithMetrics sets the metrics.
 func WithMetrics(metrics *Metrics) CListMempoolOption {
 	return func(mem *CListMempool) { mem.metrics = metrics }
 }
 
+// InitWAL creates the WAL directory, if it doesn't exist, and opens the WAL in
+// it. It returns an error including the path if the directory can't be
+// created or isn't writable, in which case the WAL stays disabled and the
+// mempool remains usable without it.
 func (mem *CListMempool) InitWAL() error {
 	var (
 		walDir  = mem.config.WalDir()
@@ -145,6 +237,14 @@ func (mem *CListMempool) InitWAL() error {
 
 	const perm = 0700
 	if err := tmos.EnsureDir(walDir, perm); err != nil {
+		return fmt.Errorf("can't create mempool WAL directory %s: %w", walDir, err)
+	}
+	if err := checkWritableDir(walDir); err != nil {
+		return err
+	}
+
+	secret, err := mem.loadWALSecret()
+	if err != nil {
 		return err
 	}
 
@@ -154,10 +254,29 @@ func (mem *CListMempool) InitWAL() error {
 	}
 
 	mem.wal = af
+	mem.walSecret = secret
 	return nil
 }
 
+// syncWAL syncs the WAL to disk every config.WALSyncEvery writes.
+func (mem *CListMempool) syncWAL() error {
+	n := atomic.AddInt64(&mem.walTxs, 1)
+	if every := int64(mem.config.WALSyncEvery); every > 1 && n%every != 0 {
+		return nil
+	}
+	return mem.wal.Sync()
+}
+
+// CloseWAL closes and discards the WAL, if InitWAL was called. Otherwise it
+// does nothing.
 func (mem *CListMempool) CloseWAL() {
+	if mem.wal == nil {
+		return
+	}
+	// sync the txs written since the last sync
+	if err := mem.wal.Sync(); err != nil {
+		mem.logger.Error("Error syncing WAL", "err", err)
+	}
 	if err := mem.wal.Close(); err != nil {
 		mem.logger.Error("Error closing WAL", "err", err)
 	}
@@ -184,48 +303,351 @@ func (mem *CListMempool) TxsBytes() int64 {
 	return atomic.LoadInt64(&mem.txsBytes)
 }
 
+// TotalGasWanted returns the sum of the gas wanted by all txs in the mempool.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) TotalGasWanted() int64 {
+	return atomic.LoadInt64(&mem.txsGas)
+}
+
+// TotalShares returns the sum of the shares occupied by all txs in the
+// mempool, as reported by the app in the ShareCount of the CheckTx responses.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) TotalShares() int {
+	return int(atomic.LoadInt64(&mem.txsShares))
+}
+
+// TxHashes returns the TxKeys of all txs in the mempool, in the order they
+// were added, e.g. to reconcile the pending txs without reaping their bytes.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) TxHashes() [][TxKeySize]byte {
+	mem.updateMtx.RLock()
+	defer mem.updateMtx.RUnlock()
+
+	hashes := make([][TxKeySize]byte, 0, mem.txs.Len())
+	for e := mem.txs.Front(); e != nil; e = e.Next() {
+		hashes = append(hashes, e.Value.(*mempoolTx).key)
+	}
+	return hashes
+}
+
+// NumTxsBySender returns the number of txs in the mempool received from each
+// peer, keyed by the peer's internal SenderID. A tx received from several
+// peers counts towards each of them. The returned map is a copy.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) NumTxsBySender() map[uint16]int {
+	mem.updateMtx.RLock()
+	defer mem.updateMtx.RUnlock()
+
+	counts := make(map[uint16]int)
+	for e := mem.txs.Front(); e != nil; e = e.Next() {
+		e.Value.(*mempoolTx).senders.Range(func(key, _ interface{}) bool {
+			counts[key.(uint16)]++
+			return true
+		})
+	}
+	return counts
+}
+
+// TxsBytesByNamespace returns the total size of the txs in the mempool with
+// each namespace, i.e. their first nsSize bytes, as for GroupByNamespace. Txs
+// too short to contain a namespace are not counted.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) TxsBytesByNamespace(nsSize int) map[string]int64 {
+	mem.updateMtx.RLock()
+	defer mem.updateMtx.RUnlock()
+
+	sizes := make(map[string]int64)
+	for e := mem.txs.Front(); e != nil; e = e.Next() {
+		tx := e.Value.(*mempoolTx).tx
+		if len(tx) < nsSize {
+			continue
+		}
+		sizes[string(tx[:nsSize])] += int64(len(tx))
+	}
+	return sizes
+}
+
+// CurrentFilters returns the pre and post check filters currently in use,
+// either of which may be nil.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) CurrentFilters() (PreCheckFunc, PostCheckFunc) {
+	mem.updateMtx.RLock()
+	defer mem.updateMtx.RUnlock()
+
+	return mem.preCheck, mem.postCheck
+}
+
+// MempoolDiagnostics is a snapshot of the internals of the mempool for
+// operators, e.g. for an admin RPC endpoint. See Diagnostics.
+type MempoolDiagnostics struct {
+	Size           int   `json:"size"`
+	TxsBytes       int64 `json:"txs_bytes"`
+	TotalGasWanted int64 `json:"total_gas_wanted"`
+	// number of txs received from each peer, see NumTxsBySender
+	TxsBySender map[uint16]int `json:"txs_by_sender"`
+	// true while some txs still await their recheck after an Update
+	Rechecking      bool `json:"rechecking"`
+	PendingRechecks int  `json:"pending_rechecks"`
+	// the oldest txs in the mempool, oldest first
+	OldestTxs []TxDiagnostics `json:"oldest_txs"`
+}
+
+// TxDiagnostics describes a tx of MempoolDiagnostics.
+type TxDiagnostics struct {
+	Key  string        `json:"key"` // hex encoded TxKey
+	Size int           `json:"size"`
+	Age  time.Duration `json:"age"`
+}
+
+// Diagnostics returns a snapshot of the mempool, including its topN oldest
+// txs, taken under a single read lock so that the figures are consistent.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) Diagnostics(topN int) MempoolDiagnostics {
+	mem.updateMtx.RLock()
+	defer mem.updateMtx.RUnlock()
+
+	now := mem.now()
+	diag := MempoolDiagnostics{
+		Size:           mem.txs.Len(),
+		TxsBytes:       atomic.LoadInt64(&mem.txsBytes),
+		TotalGasWanted: atomic.LoadInt64(&mem.txsGas),
+		TxsBySender:    make(map[uint16]int),
+		OldestTxs:      make([]TxDiagnostics, 0, tmmath.MaxInt(0, tmmath.MinInt(topN, mem.txs.Len()))),
+	}
+	for e := mem.txs.Front(); e != nil; e = e.Next() {
+		memTx := e.Value.(*mempoolTx)
+		memTx.senders.Range(func(key, _ interface{}) bool {
+			diag.TxsBySender[key.(uint16)]++
+			return true
+		})
+		if atomic.LoadInt32(&memTx.recheckPending) == 1 {
+			diag.PendingRechecks++
+		}
+		if len(diag.OldestTxs) < topN {
+			diag.OldestTxs = append(diag.OldestTxs, TxDiagnostics{
+				Key:  fmt.Sprintf("%X", memTx.key),
+				Size: len(memTx.tx),
+				Age:  now.Sub(memTx.timestamp),
+			})
+		}
+	}
+	diag.Rechecking = diag.PendingRechecks > 0
+	return diag
+}
+
 // Lock() must be help by the caller during execution.
 func (mem *CListMempool) FlushAppConn() error {
 	return mem.proxyAppConn.FlushSync(context.Background())
 }
 
+// SwapAppConn replaces the connection to the app used to check txs, e.g. to
+// upgrade the app without restarting the node. It waits for the responses to
+// all the txs sent to the old connection before swapping, and CheckTx calls
+// block until it returns. The cached CheckTx results of the old app are
+// dropped. If config.Recheck is set, all the txs in the mempool are rechecked
+// against the new app, removing those it rejects.
+//
+// The old connection is not stopped.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) SwapAppConn(newConn proxy.AppConnMempool) error {
+	mem.updateMtx.Lock()
+	defer mem.updateMtx.Unlock()
+
+	if err := mem.proxyAppConn.FlushSync(context.Background()); err != nil {
+		return fmt.Errorf("flushing the old app connection: %w", err)
+	}
+
+	newConn.SetResponseCallback(mem.globalCb)
+	mem.proxyAppConn = newConn
+	mem.resultCache.Reset()
+
+	if mem.Size() > 0 && mem.config.Recheck {
+		mem.logger.Info("Recheck txs against the new app", "numtxs", mem.Size())
+		mem.recheckTxs()
+	}
+	return nil
+}
+
 // XXX: Unsafe! Calling Flush may leave mempool in inconsistent state.
 func (mem *CListMempool) Flush() {
-	mem.updateMtx.RLock()
-	defer mem.updateMtx.RUnlock()
+	mem.updateMtx.Lock()
+	defer mem.updateMtx.Unlock()
 
 	_ = atomic.SwapInt64(&mem.txsBytes, 0)
+	_ = atomic.SwapInt64(&mem.txsGas, 0)
+	_ = atomic.SwapInt64(&mem.txsShares, 0)
 	mem.cache.Reset()
 
+	wasEmpty := mem.Size() == 0
 	for e := mem.txs.Front(); e != nil; e = e.Next() {
+		atomic.StoreInt32(&e.Value.(*mempoolTx).removed, 1)
 		mem.txs.Remove(e)
 		e.DetachPrev()
 	}
+	if !wasEmpty {
+		mem.notifyTxsDrained()
+	}
 
 	mem.txsMap.Range(func(key, _ interface{}) bool {
 		mem.txsMap.Delete(key)
 		return true
 	})
+	mem.senderNonces = make(map[string]uint64)
+}
+
+// FlushExceptLocal removes all txs from the mempool, except those submitted
+// locally (i.e. by a client via RPC, with the UnknownPeerID sender). The
+// removed txs are also removed from the cache, so they can be received again.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) FlushExceptLocal() {
+	mem.updateMtx.Lock()
+	defer mem.updateMtx.Unlock()
+
+	for e := mem.txs.Front(); e != nil; {
+		next := e.Next()
+		memTx := e.Value.(*mempoolTx)
+		if _, local := memTx.senders.Load(UnknownPeerID); !local {
+			mem.removeTx(memTx.tx, e, true)
+		}
+		e = next
+	}
+
+	mem.metrics.Size.Set(float64(mem.Size()))
+}
+
+// RemoveMatching removes all txs for which match returns true from the
+// mempool and returns the number of removed txs. The removed txs are also
+// removed from the cache, so they can be received again.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) RemoveMatching(match func(tx types.Tx) bool) int {
+	mem.updateMtx.Lock()
+	defer mem.updateMtx.Unlock()
+
+	removed := 0
+	for e := mem.txs.Front(); e != nil; {
+		next := e.Next()
+		memTx := e.Value.(*mempoolTx)
+		if match(memTx.tx) {
+			mem.removeTx(memTx.tx, e, true)
+			removed++
+		}
+		e = next
+	}
+
+	mem.metrics.Size.Set(float64(mem.Size()))
+	return removed
 }
 
 // TxsFront returns the first transaction in the ordered list for peer
 // goroutines to call .NextWait() on.
 // FIXME: leaking implementation details!
+// NOTE: the returned element and its Value are internal types. Use PeekFront
+// to only read the first tx.
 //
 // Safe for concurrent use by multiple goroutines.
 func (mem *CListMempool) TxsFront() *clist.CElement {
 	return mem.txs.Front()
 }
 
+// GetTxMetadata returns the metadata passed in TxInfo when the tx with the
+// given TxKey was checked. It returns false if the tx is not in the mempool.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) GetTxMetadata(txKey [TxKeySize]byte) (interface{}, bool) {
+	e, ok := mem.txsMap.Load(txKey)
+	if !ok {
+		return nil, false
+	}
+	return e.(*clist.CElement).Value.(*mempoolTx).metadata, true
+}
+
+// GossipEligibleAt returns the time from which the tx with the given TxKey may
+// be gossiped to peers. To spread the broadcast of txs arriving at once, it is
+// a random time within GossipDelay after the tx was added. It returns false if
+// the tx is not in the mempool.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) GossipEligibleAt(txKey [TxKeySize]byte) (time.Time, bool) {
+	e, ok := mem.txsMap.Load(txKey)
+	if !ok {
+		return time.Time{}, false
+	}
+	return e.(*clist.CElement).Value.(*mempoolTx).gossipEligibleAt, true
+}
+
+// GetSidecar returns the sidecar attached to the tx with the given TxKey by
+// CheckTxWithSidecar. It returns false if the tx is not in the mempool.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) GetSidecar(txKey [TxKeySize]byte) ([]byte, bool) {
+	e, ok := mem.txsMap.Load(txKey)
+	if !ok {
+		return nil, false
+	}
+	return e.(*clist.CElement).Value.(*mempoolTx).sidecar, true
+}
+
+// PeekFront returns the first transaction in the mempool, without removing it.
+// It returns false if the mempool is empty.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) PeekFront() (types.Tx, bool) {
+	tx, _, _, ok := mem.PeekNext()
+	return tx, ok
+}
+
+// PeekNext returns the first transaction in the mempool, along with the gas it
+// wants and its size in bytes, without removing it. It returns false if the
+// mempool is empty. The first tx isn't necessarily the next one to be reaped:
+// reaping skips txs locked by LockTxs, withheld by the sender nonce order or
+// rejected by the OrderingValidator.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) PeekNext() (tx types.Tx, gasWanted int64, bytes int, ok bool) {
+	e := mem.txs.Front()
+	if e == nil {
+		return nil, 0, 0, false
+	}
+	memTx := e.Value.(*mempoolTx)
+	return memTx.tx, memTx.gasWanted, len(memTx.tx), true
+}
+
 // TxsWaitChan returns a channel to wait on transactions. It will be closed
 // once the mempool is not empty (ie. the internal `mem.txs` has at least one
 // element)
+// NOTE: it is meant to be used together with TxsFront, which returns
+// internal types.
 //
 // Safe for concurrent use by multiple goroutines.
 func (mem *CListMempool) TxsWaitChan() <-chan struct{} {
 	return mem.txs.WaitChan()
 }
 
+// SetAcceptingTxs controls whether CheckTx accepts new txs. It is meant to be
+// disabled while the node is syncing, when checking txs against an outdated
+// state is pointless, and re-enabled once the sync is complete. While
+// disabled, CheckTx returns ErrMempoolNotAccepting. Txs are accepted by
+// default.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) SetAcceptingTxs(accepting bool) {
+	if accepting {
+		atomic.StoreInt32(&mem.notAcceptingTxs, 0)
+	} else {
+		atomic.StoreInt32(&mem.notAcceptingTxs, 1)
+	}
+}
+
 // It blocks if we're waiting on Update() or Reap().
 // cb: A callback from the CheckTx command.
 //     It gets called from another goroutine.
@@ -233,23 +655,51 @@ func (mem *CListMempool) TxsWaitChan() <-chan struct{} {
 //
 // Safe for concurrent use by multiple goroutines.
 func (mem *CListMempool) CheckTx(tx types.Tx, cb func(*abci.Response), txInfo TxInfo) error {
+	wait, err := mem.checkTx(tx, cb, txInfo)
+	if wait != nil {
+		// The response is awaited without holding updateMtx, so that a slow
+		// app doesn't hold up Update and, behind it, all other CheckTx calls.
+		return wait()
+	}
+	return err
+}
+
+// checkTx submits tx to the app for CheckTx. If a CheckTxTimeout is set, it
+// returns a function to wait for the response, which must be called after
+// updateMtx was released.
+func (mem *CListMempool) checkTx(tx types.Tx, cb func(*abci.Response), txInfo TxInfo) (func() error, error) {
+	if atomic.LoadInt32(&mem.notAcceptingTxs) == 1 {
+		return nil, ErrMempoolNotAccepting
+	}
+
 	mem.updateMtx.RLock()
 	// use defer to unlock mutex because application (*local client*) might panic
 	defer mem.updateMtx.RUnlock()
 
 	txSize := len(tx)
 
-	if err := mem.isFull(txSize); err != nil {
-		return err
+	// If eviction is enabled, the tx may still make it into a full mempool,
+	// depending on its priority, which is only known after CheckTx. Txs
+	// checked within a reservation use the reserved capacity instead.
+	if txInfo.reservation == nil {
+		if err := mem.isFull(txSize); err != nil && !mem.config.EvictLowestPriority {
+			return nil, err
+		}
 	}
 
 	if txSize > mem.config.MaxTxBytes {
-		return ErrTxTooLarge{mem.config.MaxTxBytes, txSize}
+		return nil, ErrTxTooLarge{mem.config.MaxTxBytes, txSize}
 	}
 
 	if mem.preCheck != nil {
 		if err := mem.preCheck(tx); err != nil {
-			return ErrPreCheck{err}
+			return nil, ErrPreCheck{err}
+		}
+	}
+
+	if txInfo.reservation != nil {
+		if err := txInfo.reservation.consume(txSize); err != nil {
+			return nil, err
 		}
 	}
 
@@ -259,23 +709,35 @@ func (mem *CListMempool) CheckTx(tx types.Tx, cb func(*abci.Response), txInfo Tx
 	// all even once.
 	if mem.wal != nil {
 		// TODO: Notify administrators when WAL fails
-		_, err := mem.wal.Write(append([]byte(tx), newline...))
+		_, err := mem.wal.Write(walEntry(tx, mem.walSecret))
 		if err != nil {
-			return fmt.Errorf("wal.Write: %w", err)
+			return nil, fmt.Errorf("wal.Write: %w", err)
+		}
+		if err := mem.syncWAL(); err != nil {
+			return nil, fmt.Errorf("wal.Sync: %w", err)
 		}
 	}
 
 	// NOTE: proxyAppConn may error if tx buffer is full
 	if err := mem.proxyAppConn.Error(); err != nil {
-		return err
+		return nil, err
+	}
+
+	// hash the tx only once, the key is reused until the tx is removed
+	txKey := TxKey(tx)
+
+	if txInfo.skipCache {
+		if _, ok := mem.txsMap.Load(txKey); !ok {
+			mem.cache.Remove(txKey)
+		}
 	}
 
-	if !mem.cache.Push(tx) {
+	if !mem.cache.Push(txKey) {
 		// Record a new sender for a tx we've already seen.
 		// Note it's possible a tx is still in the cache but no longer in the mempool
 		// (eg. after committing a block, txs are removed from mempool but not cache),
 		// so we only record the sender for txs still in the mempool.
-		if e, ok := mem.txsMap.Load(TxKey(tx)); ok {
+		if e, ok := mem.txsMap.Load(txKey); ok {
 			memTx := e.(*clist.CElement).Value.(*mempoolTx)
 			memTx.senders.LoadOrStore(txInfo.SenderID, true)
 			// TODO: consider punishing peer for dups,
@@ -283,7 +745,18 @@ func (mem *CListMempool) CheckTx(tx types.Tx, cb func(*abci.Response), txInfo Tx
 			// but they can spam the same tx with little cost to them atm.
 		}
 
-		return ErrTxInCache
+		return nil, ErrTxInCache
+	}
+
+	// A cached result is handled right away, which must not interleave with
+	// the responses of a running recheck (e.g. with a socket client, a recheck
+	// may still be running after Update returned).
+	if !mem.isRechecking() {
+		if res, ok := mem.resultCache.Get(txKey, mem.now()); ok {
+			mem.logger.Debug("Using cached CheckTx result", "tx", txID(tx))
+			mem.reqResCb(tx, txKey, txInfo, cb)(abci.ToResponseCheckTx(*res))
+			return nil, nil
+		}
 	}
 
 	ctx := context.Background()
@@ -291,13 +764,211 @@ func (mem *CListMempool) CheckTx(tx types.Tx, cb func(*abci.Response), txInfo Tx
 		ctx = txInfo.Context
 	}
 
-	reqRes, err := mem.proxyAppConn.CheckTxAsync(ctx, abci.RequestCheckTx{Tx: tx})
+	reqRes, err := mem.proxyAppConn.CheckTxAsync(ctx, abci.RequestCheckTx{
+		Tx:   tx,
+		Type: abci.CheckTxType_New,
+	})
 	if err != nil {
-		mem.cache.Remove(tx)
-		return err
+		mem.cache.Remove(txKey)
+		return nil, err
+	}
+	if mem.config.CheckTxTimeout > 0 {
+		return mem.waitCheckTx(reqRes, tx, txKey, txInfo, cb), nil
+	}
+	reqRes.SetCallback(mem.reqResCb(tx, txKey, txInfo, cb))
+
+	return nil, nil
+}
+
+// waitCheckTx returns a function that waits up to CheckTxTimeout for the app
+// to respond to the CheckTx of tx. If it doesn't respond in time, tx is
+// removed from the cache, so it can be resubmitted, its late response is
+// ignored and ErrCheckTxTimeout is returned.
+func (mem *CListMempool) waitCheckTx(
+	reqRes *abcicli.ReqRes,
+	tx []byte,
+	txKey [TxKeySize]byte,
+	txInfo TxInfo,
+	cb func(*abci.Response),
+) func() error {
+	const (
+		checkTxPending int32 = iota
+		checkTxResponded
+		checkTxTimedOut
+	)
+	var (
+		state     = checkTxPending
+		responded = make(chan struct{})
+		resCb     = mem.reqResCb(tx, txKey, txInfo, cb)
+	)
+	reqRes.SetCallback(func(res *abci.Response) {
+		if !atomic.CompareAndSwapInt32(&state, checkTxPending, checkTxResponded) {
+			return
+		}
+		resCb(res)
+		close(responded)
+	})
+
+	return func() error {
+		timer := time.NewTimer(mem.config.CheckTxTimeout)
+		defer timer.Stop()
+		select {
+		case <-responded:
+			return nil
+		case <-timer.C:
+		}
+
+		if !atomic.CompareAndSwapInt32(&state, checkTxPending, checkTxTimedOut) {
+			// the response arrived just in time, wait for it to be processed
+			<-responded
+			return nil
+		}
+		mem.cache.Remove(txKey)
+		mem.logger.Info("Timed out waiting for CheckTx", "tx", txID(tx), "timeout", mem.config.CheckTxTimeout)
+		return ErrCheckTxTimeout
+	}
+}
+
+// CheckTxWithSidecar is like CheckTx, but attaches the given sidecar to the
+// tx, e.g. data that is needed to build a block but too large to gossip. The
+// sidecar is kept until the tx is removed from the mempool and can be
+// retrieved with GetSidecar. It doesn't count towards TxsBytes and isn't
+// broadcast to peers.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) CheckTxWithSidecar(
+	tx types.Tx,
+	sidecar []byte,
+	cb func(*abci.Response),
+	txInfo TxInfo,
+) error {
+	txInfo.sidecar = sidecar
+	return mem.CheckTx(tx, cb, txInfo)
+}
+
+// PreloadFromBlock checks the given txs of a block, e.g. the last block
+// proposed but not committed before a restart, and adds the valid ones to the
+// mempool, so a restarting proposer recovers its working set. The txs are
+// checked like local txs, but even if they are in the cache. Txs rejected by
+// CheckTx are skipped. It waits for the app to check all the txs and returns
+// how many of them were added to the mempool.
+//
+// It must not run concurrently with Update, e.g. it is meant to be called
+// before consensus starts, since it flushes the app connection without
+// holding Lock.
+func (mem *CListMempool) PreloadFromBlock(txs types.Txs) (int, error) {
+	var accepted int32
+	for _, tx := range txs {
+		txKey := TxKey(tx)
+		// the tx is added to the mempool, if at all, right before the
+		// callback is called
+		cb := func(*abci.Response) {
+			if _, ok := mem.txsMap.Load(txKey); ok {
+				atomic.AddInt32(&accepted, 1)
+			}
+		}
+		err := mem.CheckTx(tx, cb, TxInfo{SenderID: UnknownPeerID, skipCache: true})
+		if err == ErrMempoolNotAccepting {
+			return 0, err
+		}
+		if err != nil {
+			mem.logger.Debug("Skipping preloaded tx", "tx", txID(tx), "err", err)
+		}
+	}
+
+	if err := mem.FlushAppConn(); err != nil {
+		return 0, err
+	}
+	return int(atomic.LoadInt32(&accepted)), nil
+}
+
+// Reserve reserves room for count txs of bytes bytes in total, e.g. for a
+// burst of txs that should either fit into the mempool as a whole or be
+// rejected up front. The txs checked with the returned reservation's CheckTx
+// aren't rejected because the mempool is full, while other txs can't use the
+// reserved room, until it is used up or released. It returns ErrMempoolIsFull
+// if there isn't enough room left.
+//
+// A tx uses up its part of the reservation when it is submitted, even if it
+// is rejected later, e.g. by the app.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) Reserve(bytes int64, count int) (Reservation, error) {
+	if bytes <= 0 || count <= 0 {
+		return nil, fmt.Errorf("invalid reservation of %d txs and %d bytes, both must be positive", count, bytes)
+	}
+
+	mem.reserveMtx.Lock()
+	defer mem.reserveMtx.Unlock()
+
+	var (
+		memSize       = mem.Size()
+		txsBytes      = mem.TxsBytes()
+		reservedTxs   = atomic.LoadInt64(&mem.reservedTxs)
+		reservedBytes = atomic.LoadInt64(&mem.reservedBytes)
+	)
+	if int64(memSize)+reservedTxs+int64(count) > int64(mem.config.Size) ||
+		txsBytes+reservedBytes+bytes > mem.config.MaxTxsBytes {
+		return nil, ErrMempoolIsFull{
+			memSize, mem.config.Size,
+			txsBytes, mem.config.MaxTxsBytes,
+		}
 	}
-	reqRes.SetCallback(mem.reqResCb(tx, txInfo.SenderID, txInfo.SenderP2PID, cb))
 
+	atomic.AddInt64(&mem.reservedTxs, int64(count))
+	atomic.AddInt64(&mem.reservedBytes, bytes)
+	return &clistReservation{mem: mem, bytes: bytes, count: count}, nil
+}
+
+// clistReservation is the Reservation returned by CListMempool.Reserve.
+type clistReservation struct {
+	mem *CListMempool
+
+	mtx      tmsync.Mutex
+	bytes    int64
+	count    int
+	released bool
+}
+
+var _ Reservation = &clistReservation{}
+
+func (r *clistReservation) CheckTx(tx types.Tx, cb func(*abci.Response), txInfo TxInfo) error {
+	txInfo.reservation = r
+	return r.mem.CheckTx(tx, cb, txInfo)
+}
+
+func (r *clistReservation) Remaining() (bytes int64, count int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.bytes, r.count
+}
+
+func (r *clistReservation) Release() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.released {
+		return
+	}
+	r.released = true
+	atomic.AddInt64(&r.mem.reservedTxs, -int64(r.count))
+	atomic.AddInt64(&r.mem.reservedBytes, -r.bytes)
+	r.bytes, r.count = 0, 0
+}
+
+// consume takes the room for a tx of the given size from the reservation and
+// hands it over to the tx.
+func (r *clistReservation) consume(txSize int) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.released || r.count == 0 || int64(txSize) > r.bytes {
+		return ErrReservationExceeded
+	}
+	r.count--
+	r.bytes -= int64(txSize)
+	atomic.AddInt64(&r.mem.reservedTxs, -1)
+	atomic.AddInt64(&r.mem.reservedBytes, -int64(txSize))
 	return nil
 }
 
@@ -311,6 +982,10 @@ func (mem *CListMempool) CheckTx(tx types.Tx, cb func(*abci.Response), txInfo Tx
 // When rechecking, we don't need the peerID, so the recheck callback happens
 // here.
 func (mem *CListMempool) globalCb(req *abci.Request, res *abci.Response) {
+	if r, ok := res.Value.(*abci.Response_CheckTx); ok && mem.config.ResultCacheSize > 0 {
+		mem.resultCache.Set(TxKey(req.GetCheckTx().Tx), r.CheckTx, mem.now())
+	}
+
 	if mem.recheckCursor == nil {
 		return
 	}
@@ -333,8 +1008,8 @@ func (mem *CListMempool) globalCb(req *abci.Request, res *abci.Response) {
 // Used in CheckTx to record PeerID who sent us the tx.
 func (mem *CListMempool) reqResCb(
 	tx []byte,
-	peerID uint16,
-	peerP2PID p2p.ID,
+	txKey [TxKeySize]byte,
+	txInfo TxInfo,
 	externalCb func(*abci.Response),
 ) func(res *abci.Response) {
 	return func(res *abci.Response) {
@@ -343,7 +1018,7 @@ func (mem *CListMempool) reqResCb(
 			panic("recheck cursor is not nil in reqResCb")
 		}
 
-		mem.resCbFirstTime(tx, peerID, peerP2PID, res)
+		mem.resCbFirstTime(tx, txKey, txInfo, res)
 
 		// update metrics
 		mem.metrics.Size.Set(float64(mem.Size()))
@@ -358,43 +1033,205 @@ func (mem *CListMempool) reqResCb(
 // Called from:
 //  - resCbFirstTime (lock not held) if tx is valid
 func (mem *CListMempool) addTx(memTx *mempoolTx) {
+	atomic.StoreInt32(&memTx.removed, 0)
 	e := mem.txs.PushBack(memTx)
-	mem.txsMap.Store(TxKey(memTx.tx), e)
+	mem.txsMap.Store(memTx.key, e)
 	atomic.AddInt64(&mem.txsBytes, int64(len(memTx.tx)))
+	atomic.AddInt64(&mem.txsGas, memTx.gasWanted)
+	atomic.AddInt64(&mem.txsShares, memTx.shareCount)
 	mem.metrics.TxSizeBytes.Observe(float64(len(memTx.tx)))
 }
 
 // Called from:
 //  - Update (lock held) if tx was committed
 // 	- resCbRecheck (lock not held) if tx was invalidated
-func (mem *CListMempool) removeTx(tx types.Tx, elem *clist.CElement, removeFromCache bool) {
+//
+// It returns false if the tx was removed already, e.g. if a tx is removed while
+// its recheck is pending, by the time the response arrives.
+func (mem *CListMempool) removeTx(tx types.Tx, elem *clist.CElement, removeFromCache bool) bool {
+	memTx := elem.Value.(*mempoolTx)
+	if !atomic.CompareAndSwapInt32(&memTx.removed, 0, 1) {
+		return false
+	}
 	mem.txs.Remove(elem)
 	elem.DetachPrev()
-	mem.txsMap.Delete(TxKey(tx))
+	mem.txsMap.Delete(memTx.key)
 	atomic.AddInt64(&mem.txsBytes, int64(-len(tx)))
+	atomic.AddInt64(&mem.txsGas, -memTx.gasWanted)
+	atomic.AddInt64(&mem.txsShares, -memTx.shareCount)
+
+	if removeFromCache {
+		mem.cache.Remove(memTx.key)
+	}
+
+	if mem.Size() == 0 {
+		mem.notifyTxsDrained()
+	}
+	return true
+}
+
+// dropReason is the reason a tx that passed CheckTx was dropped, counted by
+// DroppedTxs.
+type dropReason int
+
+const (
+	// dropReasonFull: the mempool was full when the app accepted the tx
+	dropReasonFull dropReason = iota
+	// dropReasonEvicted: the tx was evicted for a tx of higher priority
+	dropReasonEvicted
+	// dropReasonRecheck: the tx was invalid when rechecked
+	dropReasonRecheck
+
+	numDropReasons
+)
+
+func (r dropReason) String() string {
+	switch r {
+	case dropReasonFull:
+		return "full"
+	case dropReasonEvicted:
+		return "evicted"
+	case dropReasonRecheck:
+		return "recheck"
+	default:
+		return fmt.Sprintf("dropReason(%d)", int(r))
+	}
+}
+
+// recordDrop counts tx as dropped for the given reason.
+func (mem *CListMempool) recordDrop(tx types.Tx, reason dropReason) {
+	dropped := atomic.AddUint64(&mem.droppedTxs[reason], 1)
+	mem.logger.Debug("Dropped transaction", "tx", txID(tx), "reason", reason, "droppedForReason", dropped)
+}
+
+// DroppedTxs returns the number of txs dropped by the mempool after the app
+// accepted them in CheckTx, because the mempool was full, to make room for a
+// tx of higher priority or because they were invalid when rechecked. Txs
+// removed because they were committed or by one of the Flush or Remove
+// methods are not counted.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) DroppedTxs() uint64 {
+	var total uint64
+	for reason := range mem.droppedTxs {
+		total += atomic.LoadUint64(&mem.droppedTxs[reason])
+	}
+	return total
+}
+
+// LockTxs locks the txs with the given TxKeys against reaping, e.g. to hold
+// them tentatively during a multi-step block construction, so a concurrent
+// proposal doesn't reap them as well. Locked txs are skipped by all the reap
+// methods until they are unlocked by UnlockTxs or removed from the mempool,
+// e.g. when committed. Keys of txs not in the mempool are ignored.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) LockTxs(keys [][TxKeySize]byte) {
+	mem.setTxsLocked(keys, 1)
+}
+
+// UnlockTxs unlocks the txs with the given TxKeys locked by LockTxs, so they
+// can be reaped again. Keys of txs not in the mempool or not locked are
+// ignored.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) UnlockTxs(keys [][TxKeySize]byte) {
+	mem.setTxsLocked(keys, 0)
+}
+
+func (mem *CListMempool) setTxsLocked(keys [][TxKeySize]byte, locked int32) {
+	for _, key := range keys {
+		if e, ok := mem.txsMap.Load(key); ok {
+			atomic.StoreInt32(&e.(*clist.CElement).Value.(*mempoolTx).locked, locked)
+		}
+	}
+}
+
+// RemoveTxByKey removes a transaction from the mempool by its TxKey index.
+func (mem *CListMempool) RemoveTxByKey(txKey [TxKeySize]byte, removeFromCache bool) {
+	if e, ok := mem.txsMap.Load(txKey); ok {
+		memTx := e.(*clist.CElement).Value.(*mempoolTx)
+		if memTx != nil {
+			mem.removeTx(memTx.tx, e.(*clist.CElement), removeFromCache)
+		}
+	}
+}
+
+// RecheckTx re-runs the transaction with the given TxKey through the
+// application's CheckTx and removes it from the mempool if it is no longer
+// valid. ErrTxNotFound is returned if the transaction is not in the mempool
+// and ErrRecheckInProgress if the txs are still being rechecked after the
+// last Update, whose responses the app's response would be mixed up with.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) RecheckTx(txKey [TxKeySize]byte) error {
+	mem.updateMtx.Lock()
+	defer mem.updateMtx.Unlock()
+
+	if mem.isRechecking() {
+		return ErrRecheckInProgress
+	}
 
-	if removeFromCache {
-		mem.cache.Remove(tx)
+	e, ok := mem.txsMap.Load(txKey)
+	if !ok {
+		return ErrTxNotFound
+	}
+	elem := e.(*clist.CElement)
+	memTx := elem.Value.(*mempoolTx)
+
+	res, err := mem.proxyAppConn.CheckTxSync(context.Background(), abci.RequestCheckTx{
+		Tx:   memTx.tx,
+		Type: abci.CheckTxType_Recheck,
+	})
+	if err != nil {
+		return err
+	}
+
+	var postCheckErr error
+	if mem.postCheck != nil {
+		postCheckErr = mem.postCheck(memTx.tx, res)
 	}
+	if res.Code != abci.CodeTypeOK || postCheckErr != nil {
+		mem.logger.Info("Tx is no longer valid", "tx", txID(memTx.tx), "res", res, "err", postCheckErr)
+		// NOTE: we remove tx from the cache because it might be good later
+		mem.removeTx(memTx.tx, elem, true)
+		mem.recordDrop(memTx.tx, dropReasonRecheck)
+		mem.metrics.Size.Set(float64(mem.Size()))
+	} else {
+		atomic.StoreInt32(&memTx.recheckPending, 0)
+	}
+
+	return nil
 }
 
-// RemoveTxByKey removes a transaction from the mempool by its TxKey index.
-func (mem *CListMempool) RemoveTxByKey(txKey [TxKeySize]byte, removeFromCache bool) {
-	if e, ok := mem.txsMap.Load(txKey); ok {
-		memTx := e.(*clist.CElement).Value.(*mempoolTx)
-		if memTx != nil {
-			mem.removeTx(memTx.tx, e.(*clist.CElement), removeFromCache)
-		}
+// RecheckStatus reports whether the tx with the given TxKey is in the mempool
+// and, if so, whether it was rechecked against the latest committed state.
+// While the txs are rechecked after an Update, rechecked is false for the txs
+// whose recheck is still pending. Txs added after the last recheck started are
+// always considered rechecked, as they were checked against the latest state.
+//
+// Safe for concurrent use by multiple goroutines, without waiting for a
+// running recheck.
+func (mem *CListMempool) RecheckStatus(txKey [TxKeySize]byte) (rechecked bool, present bool) {
+	e, ok := mem.txsMap.Load(txKey)
+	if !ok {
+		return false, false
 	}
+	memTx := e.(*clist.CElement).Value.(*mempoolTx)
+	return atomic.LoadInt32(&memTx.recheckPending) == 0, true
 }
 
 func (mem *CListMempool) isFull(txSize int) error {
 	var (
 		memSize  = mem.Size()
 		txsBytes = mem.TxsBytes()
+		// the reserved capacity isn't available to txs outside the reservations
+		reservedTxs   = atomic.LoadInt64(&mem.reservedTxs)
+		reservedBytes = atomic.LoadInt64(&mem.reservedBytes)
 	)
 
-	if memSize >= mem.config.Size || int64(txSize)+txsBytes > mem.config.MaxTxsBytes {
+	if int64(memSize)+reservedTxs >= int64(mem.config.Size) ||
+		int64(txSize)+txsBytes+reservedBytes > mem.config.MaxTxsBytes {
 		return ErrMempoolIsFull{
 			memSize, mem.config.Size,
 			txsBytes, mem.config.MaxTxsBytes,
@@ -404,14 +1241,59 @@ func (mem *CListMempool) isFull(txSize int) error {
 	return nil
 }
 
+// evictLowerPriority removes the txs with the lowest priority from the
+// mempool, until there is room for a tx of the given size. Only txs with a
+// lower priority than the given one are evicted, and nothing is evicted if
+// that doesn't make enough room. Among txs of equal priority, the most
+// recently added ones are evicted first. It returns true if there is room
+// for the tx.
+func (mem *CListMempool) evictLowerPriority(txSize int, priority int64) bool {
+	// collect the candidates newest first, so that the stable sort keeps the
+	// newer txs first among txs of equal priority
+	var candidates []*clist.CElement
+	for e := mem.txs.Back(); e != nil; e = e.Prev() {
+		if e.Value.(*mempoolTx).priority < priority {
+			candidates = append(candidates, e)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Value.(*mempoolTx).priority < candidates[j].Value.(*mempoolTx).priority
+	})
+
+	var (
+		memSize  = mem.Size()
+		txsBytes = mem.TxsBytes()
+		evict    = 0
+	)
+	for memSize >= mem.config.Size || int64(txSize)+txsBytes > mem.config.MaxTxsBytes {
+		if evict == len(candidates) {
+			return false
+		}
+		memSize--
+		txsBytes -= int64(len(candidates[evict].Value.(*mempoolTx).tx))
+		evict++
+	}
+
+	for _, e := range candidates[:evict] {
+		memTx := e.Value.(*mempoolTx)
+		mem.logger.Info("Evicted lower priority transaction",
+			"tx", txID(memTx.tx), "priority", memTx.priority, "newPriority", priority)
+		// NOTE: we remove tx from the cache because it might be good later
+		mem.removeTx(memTx.tx, e, true)
+		mem.metrics.EvictedTxs.Add(1)
+		mem.recordDrop(memTx.tx, dropReasonEvicted)
+	}
+	return true
+}
+
 // callback, which is called after the app checked the tx for the first time.
 //
 // The case where the app checks the tx for the second and subsequent times is
 // handled by the resCbRecheck callback.
 func (mem *CListMempool) resCbFirstTime(
 	tx []byte,
-	peerID uint16,
-	peerP2PID p2p.ID,
+	txKey [TxKeySize]byte,
+	txInfo TxInfo,
 	res *abci.Response,
 ) {
 	switch r := res.Value.(type) {
@@ -422,20 +1304,44 @@ func (mem *CListMempool) resCbFirstTime(
 		}
 		if (r.CheckTx.Code == abci.CodeTypeOK) && postCheckErr == nil {
 			// Check mempool isn't full again to reduce the chance of exceeding the
-			// limits.
-			if err := mem.isFull(len(tx)); err != nil {
-				// remove from cache (mempool might have a space later)
-				mem.cache.Remove(tx)
-				mem.logger.Error(err.Error())
-				return
+			// limits. Txs checked within a reservation have their room reserved.
+			if err := mem.isFull(len(tx)); err != nil && txInfo.reservation == nil {
+				if !mem.config.EvictLowestPriority || !mem.evictLowerPriority(len(tx), r.CheckTx.Priority) {
+					// remove from cache (mempool might have a space later)
+					mem.cache.Remove(txKey)
+					mem.logger.Error(err.Error())
+					mem.recordDrop(tx, dropReasonFull)
+					return
+				}
 			}
 
 			memTx := &mempoolTx{
+				key:       txKey,
 				height:    mem.height,
 				gasWanted: r.CheckTx.GasWanted,
+				priority:  r.CheckTx.Priority,
 				tx:        tx,
+				timestamp: mem.now(),
+				metadata:  txInfo.Metadata,
+				sidecar:   txInfo.sidecar,
+				sender:    string(txInfo.SenderAddress),
+				nonce:     txInfo.Nonce,
+			}
+			memTx.firstSender = txInfo.SenderID
+			memTx.shareCount = r.CheckTx.ShareCount
+			memTx.gossipEligibleAt = memTx.timestamp
+			if mem.config.GossipDelay > 0 {
+				jitter := time.Duration(tmrand.Int63n(int64(mem.config.GossipDelay)))
+				memTx.gossipEligibleAt = memTx.timestamp.Add(jitter)
 			}
-			memTx.senders.Store(peerID, true)
+			if mem.namespaceExtractor != nil {
+				nid, err := mem.namespaceExtractor(tx)
+				if err != nil {
+					mem.logger.Info("Can't extract namespace of tx", "tx", txID(tx), "err", err)
+				}
+				memTx.namespace = nid
+			}
+			memTx.senders.Store(txInfo.SenderID, true)
 			mem.addTx(memTx)
 			mem.logger.Info("Added good transaction",
 				"tx", txID(tx),
@@ -447,10 +1353,10 @@ func (mem *CListMempool) resCbFirstTime(
 		} else {
 			// ignore bad transaction
 			mem.logger.Info("Rejected bad transaction",
-				"tx", txID(tx), "peerID", peerP2PID, "res", r, "err", postCheckErr)
+				"tx", txID(tx), "peerID", txInfo.SenderP2PID, "res", r, "err", postCheckErr)
 			mem.metrics.FailedTxs.Add(1)
 			// remove from cache (it might be good later)
-			mem.cache.Remove(tx)
+			mem.cache.Remove(txKey)
 		}
 	default:
 		// ignore other messages
@@ -477,17 +1383,22 @@ func (mem *CListMempool) resCbRecheck(req *abci.Request, res *abci.Response) {
 			postCheckErr = mem.postCheck(tx, r.CheckTx)
 		}
 		if (r.CheckTx.Code == abci.CodeTypeOK) && postCheckErr == nil {
-			// Good, nothing to do.
+			// Good, the tx stays in the mempool.
+			atomic.StoreInt32(&memTx.recheckPending, 0)
 		} else {
 			// Tx became invalidated due to newly committed block.
-			mem.logger.Info("Tx is no longer valid", "tx", txID(tx), "res", r, "err", postCheckErr)
 			// NOTE: we remove tx from the cache because it might be good later
-			mem.removeTx(tx, mem.recheckCursor, true)
+			if mem.removeTx(tx, mem.recheckCursor, true) {
+				mem.logger.Info("Tx is no longer valid", "tx", txID(tx), "res", r, "err", postCheckErr)
+				mem.recordDrop(tx, dropReasonRecheck)
+			}
 		}
-		if mem.recheckCursor == mem.recheckEnd {
+		if len(mem.recheckQueue) == 0 {
 			mem.recheckCursor = nil
+			atomic.StoreInt32(&mem.rechecking, 0)
 		} else {
-			mem.recheckCursor = mem.recheckCursor.Next()
+			mem.recheckCursor = mem.recheckQueue[0]
+			mem.recheckQueue = mem.recheckQueue[1:]
 		}
 		if mem.recheckCursor == nil {
 			// Done!
@@ -503,6 +1414,11 @@ func (mem *CListMempool) resCbRecheck(req *abci.Request, res *abci.Response) {
 	}
 }
 
+// isRechecking returns true while the txs are rechecked after an Update.
+func (mem *CListMempool) isRechecking() bool {
+	return atomic.LoadInt32(&mem.rechecking) == 1
+}
+
 // Safe for concurrent use by multiple goroutines.
 func (mem *CListMempool) TxsAvailable() <-chan struct{} {
 	return mem.txsAvailable
@@ -522,25 +1438,206 @@ func (mem *CListMempool) notifyTxsAvailable() {
 	}
 }
 
+// TxsDrained returns a channel which fires each time the mempool becomes empty,
+// i.e. when the last tx is removed by Update, Flush or any other removal. It is
+// the counterpart of TxsAvailable. The channel has a capacity of 1, so
+// transitions are coalesced until it is read.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) TxsDrained() <-chan struct{} {
+	return mem.txsDrained
+}
+
+func (mem *CListMempool) notifyTxsDrained() {
+	select {
+	case mem.txsDrained <- struct{}{}:
+	default:
+	}
+}
+
+// ReapStrategy determines the order in which ReapWithStrategy reaps txs.
+type ReapStrategy int
+
+const (
+	// ReapStrategyFIFO reaps the txs in the order they were added, see
+	// ReapMaxBytesMaxGas.
+	ReapStrategyFIFO ReapStrategy = iota
+	// ReapStrategyNewestFirst reaps the most recently added txs first, see
+	// ReapNewestFirst.
+	ReapStrategyNewestFirst
+	// ReapStrategyFairByPeer reaps the local txs first, then round-robins
+	// across the peers the txs were received from, see ReapFairByPeer.
+	ReapStrategyFairByPeer
+)
+
+// ReapWithStrategy reaps txs in the order given by strategy, up to maxBytes
+// bytes and maxGas gas total. Negative ceilings are not enforced. It logs an
+// error and reaps no txs if strategy is unknown.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) ReapWithStrategy(strategy ReapStrategy, maxBytes, maxGas int64) types.Txs {
+	mem.updateMtx.RLock()
+	defer mem.updateMtx.RUnlock()
+
+	ctx := context.Background()
+
+	switch strategy {
+	case ReapStrategyFIFO:
+		return mem.reapMaxBytesMaxGas(ctx, maxBytes, maxGas, -1, mem.txs.Front(), (*clist.CElement).Next)
+	case ReapStrategyNewestFirst:
+		return mem.reapMaxBytesMaxGas(ctx, maxBytes, maxGas, -1, mem.txs.Back(), (*clist.CElement).Prev)
+	case ReapStrategyFairByPeer:
+		first, next := mem.fairByPeerOrder()
+		return mem.reapMaxBytesMaxGas(ctx, maxBytes, maxGas, -1, first, next)
+	default:
+		mem.logger.Error("Unknown reap strategy", "strategy", strategy)
+		return types.Txs{}
+	}
+}
+
 // Safe for concurrent use by multiple goroutines.
 func (mem *CListMempool) ReapMaxBytesMaxGas(maxBytes, maxGas int64) types.Txs {
+	return mem.ReapWithStrategy(ReapStrategyFIFO, maxBytes, maxGas)
+}
+
+// ReapWithDeadline is like ReapMaxBytesMaxGas, but stops reaping once ctx is
+// done, e.g. when its deadline is exceeded, and returns the txs reaped so far.
+// These respect the ceilings and the nonce order of each sender, like the
+// result of a complete reap.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) ReapWithDeadline(ctx context.Context, maxBytes, maxGas int64) types.Txs {
+	mem.updateMtx.RLock()
+	defer mem.updateMtx.RUnlock()
+
+	return mem.reapMaxBytesMaxGas(ctx, maxBytes, maxGas, -1, mem.txs.Front(), (*clist.CElement).Next)
+}
+
+// ReapMaxShares is like ReapMaxBytesMaxGas, but also stops before the reaped
+// txs occupy more than maxShares shares in total, as reported by the app in
+// the ShareCount of the CheckTx responses. A negative maxShares is not
+// enforced.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) ReapMaxShares(maxShares, maxBytes, maxGas int64) types.Txs {
 	mem.updateMtx.RLock()
 	defer mem.updateMtx.RUnlock()
 
-	var totalGas int64
+	return mem.reapMaxBytesMaxGas(context.Background(), maxBytes, maxGas, maxShares,
+		mem.txs.Front(), (*clist.CElement).Next)
+}
+
+// ReapNewestFirst is like ReapMaxBytesMaxGas, but reaps the txs in the
+// reverse order, starting with the most recently added tx.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) ReapNewestFirst(maxBytes, maxGas int64) types.Txs {
+	return mem.ReapWithStrategy(ReapStrategyNewestFirst, maxBytes, maxGas)
+}
+
+// ReapFairByPeer is like ReapMaxBytesMaxGas, but keeps the txs of a single
+// peer from taking up the whole block: the txs submitted locally (with the
+// UnknownPeerID sender) are reaped first, then one tx of each peer in turn,
+// with the peers ordered by their oldest tx. The txs of a peer are reaped in
+// FIFO order, and each tx is attributed to the peer it was first received
+// from.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) ReapFairByPeer(maxBytes, maxGas int64) types.Txs {
+	return mem.ReapWithStrategy(ReapStrategyFairByPeer, maxBytes, maxGas)
+}
+
+// fairByPeerOrder returns the first element and the iteration function of the
+// order in which ReapFairByPeer reaps the txs.
+func (mem *CListMempool) fairByPeerOrder() (*clist.CElement, func(*clist.CElement) *clist.CElement) {
+	var (
+		local  []*clist.CElement
+		peers  []uint16
+		byPeer = make(map[uint16][]*clist.CElement)
+		total  int
+	)
+	for e := mem.txs.Front(); e != nil; e = e.Next() {
+		total++
+		memTx := e.Value.(*mempoolTx)
+		if _, ok := memTx.senders.Load(UnknownPeerID); ok {
+			local = append(local, e)
+			continue
+		}
+		if _, ok := byPeer[memTx.firstSender]; !ok {
+			peers = append(peers, memTx.firstSender)
+		}
+		byPeer[memTx.firstSender] = append(byPeer[memTx.firstSender], e)
+	}
+
+	order := local
+	for round := 0; len(order) < total; round++ {
+		for _, peer := range peers {
+			if round < len(byPeer[peer]) {
+				order = append(order, byPeer[peer][round])
+			}
+		}
+	}
+	if len(order) == 0 {
+		return nil, nil
+	}
+
+	positions := make(map[*clist.CElement]int, len(order))
+	for i, e := range order {
+		positions[e] = i
+	}
+	next := func(e *clist.CElement) *clist.CElement {
+		if i := positions[e] + 1; i < len(order) {
+			return order[i]
+		}
+		return nil
+	}
+	return order[0], next
+}
+
+// reapMaxBytesMaxGas reaps txs starting at the given element and moving on
+// to the element returned by next, until the ceilings are reached or ctx is
+// done. Negative ceilings, including maxShares, are not enforced.
+//
+// Txs with a sender address are reaped in nonce order: a tx is withheld until
+// the tx with the preceding nonce of the same sender is either reaped or
+// committed, so a missing nonce withholds all later txs of the sender.
+//
+// Txs rejected by the OrderingValidator, if set, are skipped, as are txs
+// locked by LockTxs. A locked tx withholds the later txs of its sender.
+//
+// No tx is reaped twice: as a defensive invariant, a tx with the same TxKey as
+// a tx reaped before in the same call is skipped, even if the mempool happens
+// to contain it twice.
+//
+// maxBytes bounds the size of the reaped txs once proto encoded in the block
+// Data (see types.ComputeProtoSizeForTxs), not the sum of their lengths: the
+// empty Data takes 6 bytes and each tx adds a field tag and a varint length
+// prefix to its own length, e.g. a single 20 byte tx takes 6 + 1 + 1 + 20 = 28
+// bytes. Reaping stops at the first tx that doesn't fit, so if maxBytes is
+// smaller than the size of the first tx alone, no txs are reaped.
+func (mem *CListMempool) reapMaxBytesMaxGas(
+	ctx context.Context,
+	maxBytes, maxGas, maxShares int64,
+	first *clist.CElement,
+	next func(*clist.CElement) *clist.CElement,
+) types.Txs {
+	var totalGas, totalShares int64
 
 	// TODO: we will get a performance boost if we have a good estimate of avg
 	// size per tx, and set the initial capacity based off of that.
 	// txs := make([]types.Tx, 0, tmmath.MinInt(mem.txs.Len(), max/mem.avgTxSize))
 	txs := make([]types.Tx, 0, mem.txs.Len())
-	for e := mem.txs.Front(); e != nil; e = e.Next() {
-		memTx := e.Value.(*mempoolTx)
+	// keys of the reaped txs, so no tx is reaped twice
+	reaped := make(map[[TxKeySize]byte]struct{}, mem.txs.Len())
 
+	// reap appends memTx to txs and returns false if the ceilings are reached.
+	reap := func(memTx *mempoolTx) bool {
 		dataSize := types.ComputeProtoSizeForTxs(append(txs, memTx.tx))
 
-		// Check total size requirement
+		// Check total size requirement, including the proto overhead.
+		// A tx that doesn't fit is never included, even if it is the first.
 		if maxBytes > -1 && dataSize > maxBytes {
-			return txs
+			return false
 		}
 		// Check total gas requirement.
 		// If maxGas is negative, skip this check.
@@ -548,10 +1645,143 @@ func (mem *CListMempool) ReapMaxBytesMaxGas(maxBytes, maxGas int64) types.Txs {
 		// must be non-negative, it follows that this won't overflow.
 		newTotalGas := totalGas + memTx.gasWanted
 		if maxGas > -1 && newTotalGas > maxGas {
-			return txs
+			return false
+		}
+		// Check total shares requirement.
+		newTotalShares := totalShares + memTx.shareCount
+		if maxShares > -1 && newTotalShares > maxShares {
+			return false
 		}
 		totalGas = newTotalGas
+		totalShares = newTotalShares
+		txs = append(txs, memTx.tx)
+		reaped[memTx.key] = struct{}{}
+		return true
+	}
+	// inOrder returns false if memTx may not follow the last reaped tx, or if
+	// a tx with the same key was reaped already.
+	inOrder := func(memTx *mempoolTx) bool {
+		if _, ok := reaped[memTx.key]; ok {
+			return false
+		}
+		return mem.orderingValidator == nil || len(txs) == 0 ||
+			mem.orderingValidator(txs[len(txs)-1], memTx.tx)
+	}
+
+	nonces := mem.nextSenderNonces()
+	// txs seen before the tx with the preceding nonce: sender -> nonce -> tx
+	withheld := make(map[string]map[uint64]*mempoolTx)
+	for e := first; e != nil; e = next(e) {
+		if ctx.Err() != nil {
+			return txs
+		}
+		memTx := e.Value.(*mempoolTx)
+		if memTx.isLocked() {
+			continue
+		}
+
+		if memTx.sender != "" && memTx.nonce != nonces[memTx.sender] {
+			if memTx.nonce > nonces[memTx.sender] {
+				if withheld[memTx.sender] == nil {
+					withheld[memTx.sender] = make(map[uint64]*mempoolTx)
+				}
+				withheld[memTx.sender][memTx.nonce] = memTx
+			}
+			continue
+		}
+
+		if !inOrder(memTx) {
+			continue
+		}
+		if !reap(memTx) {
+			return txs
+		}
+
+		// Reap the sender's txs that were waiting for this one.
+		for memTx.sender != "" {
+			nonces[memTx.sender] = memTx.nonce + 1
+			waiting, ok := withheld[memTx.sender][memTx.nonce+1]
+			if !ok || !inOrder(waiting) {
+				break
+			}
+			delete(withheld[memTx.sender], waiting.nonce)
+			if !reap(waiting) {
+				return txs
+			}
+			memTx = waiting
+		}
+	}
+	return txs
+}
+
+// nextSenderNonces returns the nonce of the next tx to reap for every sender
+// with txs in the mempool.
+func (mem *CListMempool) nextSenderNonces() map[string]uint64 {
+	nonces := make(map[string]uint64)
+	for e := mem.txs.Front(); e != nil; e = e.Next() {
+		memTx := e.Value.(*mempoolTx)
+		if memTx.sender == "" {
+			continue
+		}
+		if lowest, ok := nonces[memTx.sender]; !ok || memTx.nonce < lowest {
+			nonces[memTx.sender] = memTx.nonce
+		}
+	}
+	for sender, lowest := range nonces {
+		if next, ok := mem.senderNonces[sender]; ok && next > lowest {
+			nonces[sender] = next
+		}
+	}
+	return nonces
+}
+
+// pruneSenderNonces drops the next nonces of the senders without txs left in
+// the mempool.
+func (mem *CListMempool) pruneSenderNonces() {
+	if len(mem.senderNonces) == 0 {
+		return
+	}
+	resident := make(map[string]struct{}, len(mem.senderNonces))
+	for e := mem.txs.Front(); e != nil; e = e.Next() {
+		if sender := e.Value.(*mempoolTx).sender; sender != "" {
+			resident[sender] = struct{}{}
+		}
+	}
+	for sender := range mem.senderNonces {
+		if _, ok := resident[sender]; !ok {
+			delete(mem.senderNonces, sender)
+		}
+	}
+}
+
+// ReapByNamespace reaps the txs with the given namespace, as determined by the
+// NamespaceExtractor (see WithNamespaceExtractor), in FIFO order up to
+// maxBytes bytes total. If maxBytes is negative, there is no cap on the size
+// of the returned txs. Unlike ReapMaxBytesMaxGas, the txs are not ordered by
+// sender nonce.
+//
+// Safe for concurrent use by multiple goroutines.
+func (mem *CListMempool) ReapByNamespace(nid namespace.ID, maxBytes int64) types.Txs {
+	mem.updateMtx.RLock()
+	defer mem.updateMtx.RUnlock()
+
+	var txs types.Txs
+	reaped := make(map[[TxKeySize]byte]struct{})
+	for e := mem.txs.Front(); e != nil; e = e.Next() {
+		memTx := e.Value.(*mempoolTx)
+		if memTx.isLocked() || memTx.namespace == nil || !memTx.namespace.Equal(nid) {
+			continue
+		}
+		if _, ok := reaped[memTx.key]; ok {
+			continue
+		}
+
+		dataSize := types.ComputeProtoSizeForTxs(append(txs, memTx.tx))
+		if maxBytes > -1 && dataSize > maxBytes {
+			return txs
+		}
 		txs = append(txs, memTx.tx)
+		reaped[memTx.key] = struct{}{}
 	}
 	return txs
 }
@@ -566,9 +1796,17 @@ func (mem *CListMempool) ReapMaxTxs(max int) types.Txs {
 	}
 
 	txs := make([]types.Tx, 0, tmmath.MinInt(mem.txs.Len(), max))
+	reaped := make(map[[TxKeySize]byte]struct{}, cap(txs))
 	for e := mem.txs.Front(); e != nil && len(txs) <= max; e = e.Next() {
 		memTx := e.Value.(*mempoolTx)
+		if memTx.isLocked() {
+			continue
+		}
+		if _, ok := reaped[memTx.key]; ok {
+			continue
+		}
 		txs = append(txs, memTx.tx)
+		reaped[memTx.key] = struct{}{}
 	}
 	return txs
 }
@@ -582,6 +1820,7 @@ func (mem *CListMempool) Update(
 	postCheck PostCheckFunc,
 ) error {
 	// Set height
+	heightGap := height - mem.height
 	mem.height = height
 	mem.notifiedTxsAvailable = false
 
@@ -592,13 +1831,15 @@ func (mem *CListMempool) Update(
 		mem.postCheck = postCheck
 	}
 
+	var includedKeys [][TxKeySize]byte
 	for i, tx := range txs {
+		txKey := TxKey(tx)
 		if deliverTxResponses[i].Code == abci.CodeTypeOK {
 			// Add valid committed tx to the cache (if missing).
-			_ = mem.cache.Push(tx)
+			_ = mem.cache.Push(txKey)
 		} else {
 			// Allow invalid transactions to be resubmitted.
-			mem.cache.Remove(tx)
+			mem.cache.Remove(txKey)
 		}
 
 		// Remove committed tx from the mempool.
@@ -611,11 +1852,36 @@ func (mem *CListMempool) Update(
 		// Mempool after:
 		//   100
 		// https://github.com/tendermint/tendermint/issues/3322.
-		if e, ok := mem.txsMap.Load(TxKey(tx)); ok {
+		if e, ok := mem.txsMap.Load(txKey); ok {
+			memTx := e.(*clist.CElement).Value.(*mempoolTx)
+			mem.metrics.TxLifetime.Observe(mem.now().Sub(memTx.timestamp).Seconds())
 			mem.removeTx(tx, e.(*clist.CElement), false)
+			includedKeys = append(includedKeys, txKey)
+			if memTx.sender != "" {
+				mem.senderNonces[memTx.sender] = memTx.nonce + 1
+			}
+		}
+	}
+
+	if mem.inclusionRecorder != nil {
+		mem.inclusionRecorder.RecordInclusion(height, includedKeys)
+	}
+
+	// After a large height gap (e.g. the node caught up via block or state
+	// sync), the txs left are likely stale, so drop them instead of rechecking.
+	if maxGap := mem.config.MaxHeightGap; maxGap > 0 && heightGap > maxGap && mem.Size() > 0 {
+		mem.logger.Info("Flush txs after height gap",
+			"numtxs", mem.Size(), "height", height, "gap", heightGap, "max-gap", maxGap)
+		for e := mem.txs.Front(); e != nil; {
+			next := e.Next()
+			memTx := e.Value.(*mempoolTx)
+			mem.removeTx(memTx.tx, e, true)
+			e = next
 		}
 	}
 
+	mem.pruneSenderNonces()
+
 	// Either recheck non-committed txs to see if they became invalid
 	// or just notify there're some txs left.
 	if mem.Size() > 0 {
@@ -641,14 +1907,26 @@ func (mem *CListMempool) recheckTxs() {
 		panic("recheckTxs is called, but the mempool is empty")
 	}
 
-	mem.recheckCursor = mem.txs.Front()
-	mem.recheckEnd = mem.txs.Back()
+	// Recheck the txs with the highest priority first, so they are validated
+	// first if the recheck is interrupted. Txs of equal priority are
+	// rechecked in FIFO order.
+	queue := make([]*clist.CElement, 0, mem.txs.Len())
+	for e := mem.txs.Front(); e != nil; e = e.Next() {
+		atomic.StoreInt32(&e.Value.(*mempoolTx).recheckPending, 1)
+		queue = append(queue, e)
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		return queue[i].Value.(*mempoolTx).priority > queue[j].Value.(*mempoolTx).priority
+	})
+	mem.recheckCursor = queue[0]
+	mem.recheckQueue = queue[1:]
+	atomic.StoreInt32(&mem.rechecking, 1)
 
 	ctx := context.Background()
 
 	// Push txs to proxyAppConn
 	// NOTE: globalCb may be called concurrently.
-	for e := mem.txs.Front(); e != nil; e = e.Next() {
+	for _, e := range queue {
 		memTx := e.Value.(*mempoolTx)
 		_, err := mem.proxyAppConn.CheckTxAsync(ctx, abci.RequestCheckTx{
 			Tx:   memTx.tx,
@@ -670,9 +1948,36 @@ func (mem *CListMempool) recheckTxs() {
 
 // mempoolTx is a transaction that successfully ran
 type mempoolTx struct {
-	height    int64    // height that this tx had been validated in
-	gasWanted int64    // amount of gas this tx states it will require
-	tx        types.Tx //
+	height    int64           // height that this tx had been validated in
+	gasWanted int64           // amount of gas this tx states it will require
+	priority  int64           // priority of this tx as reported by the app in CheckTx
+	tx        types.Tx        //
+	key       [TxKeySize]byte // TxKey of tx, computed once in CheckTx
+	timestamp time.Time       // time this tx was added to the mempool
+	metadata  interface{}     // optional metadata provided by the caller of CheckTx
+	sidecar   []byte          // optional data attached by CheckTxWithSidecar, not gossiped
+	sender    string          // address of the account that signed this tx, if known
+	nonce     uint64          // nonce of this tx, only meaningful if sender is set
+	namespace namespace.ID    // namespace of this tx, if a NamespaceExtractor is set
+
+	// time from which this tx may be gossiped, up to GossipDelay after timestamp
+	gossipEligibleAt time.Time
+
+	// SenderID of the peer this tx was first received from
+	firstSender uint16
+
+	// number of shares this tx occupies in the square, as reported by the app
+	// in CheckTx
+	shareCount int64
+
+	// Atomic boolean, set while this tx awaits its recheck after an Update
+	recheckPending int32
+
+	// Atomic boolean, set while this tx is locked against reaping by LockTxs
+	locked int32
+
+	// Atomic boolean, set once this tx was removed from the mempool
+	removed int32
 
 	// ids of peers who've sent us this tx (as a map for quick lookups).
 	// senders: PeerID -> bool
@@ -684,12 +1989,17 @@ func (memTx *mempoolTx) Height() int64 {
 	return atomic.LoadInt64(&memTx.height)
 }
 
+// isLocked returns true if this tx is locked against reaping by LockTxs.
+func (memTx *mempoolTx) isLocked() bool {
+	return atomic.LoadInt32(&memTx.locked) == 1
+}
+
 //--------------------------------------------------------------------------------
 
 type txCache interface {
 	Reset()
-	Push(tx types.Tx) bool
-	Remove(tx types.Tx)
+	Push(txKey [TxKeySize]byte) bool
+	Remove(txKey [TxKeySize]byte)
 }
 
 // mapTxCache maintains a LRU cache of transactions. This only stores the hash
@@ -699,6 +2009,15 @@ type mapTxCache struct {
 	size     int
 	cacheMap map[[TxKeySize]byte]*list.Element
 	list     *list.List
+
+	// Optional, a superset of the keys in the cache, used by Push to skip the
+	// lookup in cacheMap for keys that are definitely not in the cache.
+	bloom         *bloomFilter
+	bloomCapacity int // number of keys bloom is sized for
+	bloomKeys     int // number of keys added to bloom since it was last rebuilt
+
+	// number of lookups in cacheMap by Push
+	lookups int
 }
 
 var _ txCache = (*mapTxCache)(nil)
@@ -712,25 +2031,41 @@ func newMapTxCache(cacheSize int) *mapTxCache {
 	}
 }
 
+// newMapTxCacheWithBloomFilter returns a new mapTxCache with a bloom filter
+// in front of it, sized for bloomSize keys (but at least twice the cache
+// size) with the given false positive rate.
+func newMapTxCacheWithBloomFilter(cacheSize, bloomSize int, fpRate float64) *mapTxCache {
+	cache := newMapTxCache(cacheSize)
+	cache.bloomCapacity = tmmath.MaxInt(bloomSize, 2*cacheSize)
+	cache.bloom = newBloomFilter(cache.bloomCapacity, fpRate)
+	return cache
+}
+
 // Reset resets the cache to an empty state.
 func (cache *mapTxCache) Reset() {
 	cache.mtx.Lock()
 	cache.cacheMap = make(map[[TxKeySize]byte]*list.Element, cache.size)
 	cache.list.Init()
+	if cache.bloom != nil {
+		cache.bloom.reset()
+		cache.bloomKeys = 0
+	}
 	cache.mtx.Unlock()
 }
 
-// Push adds the given tx to the cache and returns true. It returns
-// false if tx is already in the cache.
-func (cache *mapTxCache) Push(tx types.Tx) bool {
+// Push adds the tx with the given TxKey to the cache and returns true. It
+// returns false if the tx is already in the cache.
+func (cache *mapTxCache) Push(txHash [TxKeySize]byte) bool {
 	cache.mtx.Lock()
 	defer cache.mtx.Unlock()
 
-	// Use the tx hash in the cache
-	txHash := TxKey(tx)
-	if moved, exists := cache.cacheMap[txHash]; exists {
-		cache.list.MoveToBack(moved)
-		return false
+	// the bloom filter is advisory: a key it may contain is looked up
+	if cache.bloom == nil || cache.bloom.has(txHash) {
+		cache.lookups++
+		if moved, exists := cache.cacheMap[txHash]; exists {
+			cache.list.MoveToBack(moved)
+			return false
+		}
 	}
 
 	if cache.list.Len() >= cache.size {
@@ -743,13 +2078,32 @@ func (cache *mapTxCache) Push(tx types.Tx) bool {
 	}
 	e := cache.list.PushBack(txHash)
 	cache.cacheMap[txHash] = e
+	if cache.bloom != nil {
+		cache.addToBloom(txHash)
+	}
 	return true
 }
 
-// Remove removes the given tx from the cache.
-func (cache *mapTxCache) Remove(tx types.Tx) {
+// addToBloom adds txHash to the bloom filter. Keys evicted or removed from the
+// cache can't be removed from the filter, so once it holds as many keys as it
+// was sized for, it is rebuilt from the keys in the cache instead.
+func (cache *mapTxCache) addToBloom(txHash [TxKeySize]byte) {
+	if cache.bloomKeys < cache.bloomCapacity {
+		cache.bloom.add(txHash)
+		cache.bloomKeys++
+		return
+	}
+
+	cache.bloom.reset()
+	for e := cache.list.Front(); e != nil; e = e.Next() {
+		cache.bloom.add(e.Value.([TxKeySize]byte))
+	}
+	cache.bloomKeys = cache.list.Len()
+}
+
+// Remove removes the tx with the given TxKey from the cache.
+func (cache *mapTxCache) Remove(txHash [TxKeySize]byte) {
 	cache.mtx.Lock()
-	txHash := TxKey(tx)
 	popped := cache.cacheMap[txHash]
 	delete(cache.cacheMap, txHash)
 	if popped != nil {
@@ -763,14 +2117,115 @@ type nopTxCache struct{}
 
 var _ txCache = (*nopTxCache)(nil)
 
-func (nopTxCache) Reset()             {}
-func (nopTxCache) Push(types.Tx) bool { return true }
-func (nopTxCache) Remove(types.Tx)    {}
+func (nopTxCache) Reset()                    {}
+func (nopTxCache) Push([TxKeySize]byte) bool { return true }
+func (nopTxCache) Remove([TxKeySize]byte)    {}
+
+//--------------------------------------------------------------------------------
+
+type txResultCache interface {
+	Get(txKey [TxKeySize]byte, now time.Time) (*abci.ResponseCheckTx, bool)
+	Set(txKey [TxKeySize]byte, res *abci.ResponseCheckTx, now time.Time)
+	Reset()
+}
+
+// mapTxResultCache maintains a LRU cache of the latest CheckTx result for each
+// tx. Results older than the TTL are ignored.
+type mapTxResultCache struct {
+	mtx      tmsync.Mutex
+	size     int
+	ttl      time.Duration
+	cacheMap map[[TxKeySize]byte]*list.Element
+	list     *list.List
+}
+
+type txResultCacheEntry struct {
+	txHash  [TxKeySize]byte
+	res     *abci.ResponseCheckTx
+	expires time.Time
+}
+
+var _ txResultCache = (*mapTxResultCache)(nil)
+
+// newMapTxResultCache returns a new mapTxResultCache.
+func newMapTxResultCache(cacheSize int, ttl time.Duration) *mapTxResultCache {
+	return &mapTxResultCache{
+		size:     cacheSize,
+		ttl:      ttl,
+		cacheMap: make(map[[TxKeySize]byte]*list.Element, cacheSize),
+		list:     list.New(),
+	}
+}
+
+// Get returns the cached result for the tx with the given TxKey, if any, and
+// whether it is still valid at the given time. Expired results are removed.
+func (cache *mapTxResultCache) Get(txHash [TxKeySize]byte, now time.Time) (*abci.ResponseCheckTx, bool) {
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
+	e, exists := cache.cacheMap[txHash]
+	if !exists {
+		return nil, false
+	}
+	entry := e.Value.(*txResultCacheEntry)
+	if !now.Before(entry.expires) {
+		delete(cache.cacheMap, txHash)
+		cache.list.Remove(e)
+		return nil, false
+	}
+	return entry.res, true
+}
+
+// Set stores the result for the tx with the given TxKey, replacing any
+// previous one.
+func (cache *mapTxResultCache) Set(txHash [TxKeySize]byte, res *abci.ResponseCheckTx, now time.Time) {
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
+	entry := &txResultCacheEntry{txHash: txHash, res: res, expires: now.Add(cache.ttl)}
+	if e, exists := cache.cacheMap[txHash]; exists {
+		e.Value = entry
+		cache.list.MoveToBack(e)
+		return
+	}
+
+	if cache.list.Len() >= cache.size {
+		popped := cache.list.Front()
+		if popped != nil {
+			delete(cache.cacheMap, popped.Value.(*txResultCacheEntry).txHash)
+			cache.list.Remove(popped)
+		}
+	}
+	cache.cacheMap[txHash] = cache.list.PushBack(entry)
+}
+
+// Reset removes all cached results.
+func (cache *mapTxResultCache) Reset() {
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
+	cache.cacheMap = make(map[[TxKeySize]byte]*list.Element, cache.size)
+	cache.list.Init()
+}
+
+type nopTxResultCache struct{}
+
+var _ txResultCache = (*nopTxResultCache)(nil)
+
+func (nopTxResultCache) Get([TxKeySize]byte, time.Time) (*abci.ResponseCheckTx, bool) {
+	return nil, false
+}
+func (nopTxResultCache) Set([TxKeySize]byte, *abci.ResponseCheckTx, time.Time) {}
+func (nopTxResultCache) Reset()                                                {}
 
 //--------------------------------------------------------------------------------
 
-// TxKey is the fixed length array hash used as the key in maps.
-func TxKey(tx types.Tx) [TxKeySize]byte {
+// TxKey is the fixed length array hash used as the key in maps. It defaults to
+// the sha256 hash of the tx. Apps that already compute a different canonical
+// hash of TxKeySize bytes can assign it at init, before any mempool is
+// created. It must not be changed while a mempool is in use, as txs are looked
+// up by the keys computed when they were added.
+var TxKey = func(tx types.Tx) [TxKeySize]byte {
 	return sha256.Sum256(tx)
 }
 
@@ -1,10 +1,13 @@
 package mempool
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -12,19 +15,23 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-kit/kit/metrics/generic"
 	"github.com/gogo/protobuf/proto"
 	gogotypes "github.com/gogo/protobuf/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	abcicli "github.com/lazyledger/lazyledger-core/abci/client"
 	"github.com/lazyledger/lazyledger-core/abci/example/counter"
 	"github.com/lazyledger/lazyledger-core/abci/example/kvstore"
 	abci "github.com/lazyledger/lazyledger-core/abci/types"
 	cfg "github.com/lazyledger/lazyledger-core/config"
 	"github.com/lazyledger/lazyledger-core/libs/log"
 	tmrand "github.com/lazyledger/lazyledger-core/libs/rand"
+	tmsync "github.com/lazyledger/lazyledger-core/libs/sync"
 	"github.com/lazyledger/lazyledger-core/proxy"
 	"github.com/lazyledger/lazyledger-core/types"
+	"github.com/lazyledger/nmt/namespace"
 )
 
 // A cleanupFunc cleans up any config / test files created for a particular
@@ -119,7 +126,12 @@ func TestReapMaxBytesMaxGas(t *testing.T) {
 		{20, 0, -1, 0},
 		{20, 0, 10, 0},
 		{20, 10, 10, 0},
+		// a single tx takes 28 bytes with the proto overhead, see reapMaxBytesMaxGas
+		{20, 20, -1, 0},
+		{20, 27, -1, 0},
 		{20, 28, 10, 1},
+		{20, 49, -1, 1},
+		{20, 50, -1, 2},
 		{20, 240, 5, 5},
 		{20, 240, -1, 10},
 		{20, 240, 10, 10},
@@ -137,6 +149,1031 @@ func TestReapMaxBytesMaxGas(t *testing.T) {
 	}
 }
 
+// priorityApp is a kvstore application that reports the first byte of each
+// tx as its priority.
+type priorityApp struct {
+	*kvstore.Application
+}
+
+func (app *priorityApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
+	res := app.Application.CheckTx(req)
+	res.Priority = int64(req.Tx[0])
+	return res
+}
+
+func TestMempoolTxPriority(t *testing.T) {
+	app := &priorityApp{kvstore.NewApplication()}
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	checkTxs(t, mempool, 1, UnknownPeerID)
+	tx0 := mempool.TxsFront().Value.(*mempoolTx)
+	require.Equal(t, app.CheckTx(abci.RequestCheckTx{Tx: tx0.tx}).Priority, int64(tx0.tx[0]))
+	require.Equal(t, int64(tx0.tx[0]), tx0.priority, "transactions priority was set incorrectly")
+}
+
+// recheckOrderApp is a priorityApp that records the txs it rechecks, in
+// order, and rejects the txs in invalid on recheck.
+type recheckOrderApp struct {
+	priorityApp
+	rechecked types.Txs
+	invalid   map[string]bool
+}
+
+func (app *recheckOrderApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
+	if req.Type == abci.CheckTxType_Recheck {
+		app.rechecked = append(app.rechecked, req.Tx)
+		if app.invalid[string(req.Tx)] {
+			return abci.ResponseCheckTx{Code: 1}
+		}
+	}
+	return app.priorityApp.CheckTx(req)
+}
+
+func TestMempoolRecheckPriorityOrder(t *testing.T) {
+	app := &recheckOrderApp{priorityApp: priorityApp{kvstore.NewApplication()}, invalid: map[string]bool{}}
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	// the first byte of each tx is its priority
+	txs := types.Txs{{1, 0}, {5, 0}, {3, 0}, {5, 1}, {9, 0}, {1, 1}, {0, 0}}
+	for _, tx := range txs {
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	}
+
+	app.invalid[string(types.Tx{3, 0})] = true
+	err := mempool.Update(1, types.Txs{{0, 0}}, abciResponses(1, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+
+	// txs of equal priority are rechecked in FIFO order
+	assert.Equal(t, types.Txs{{9, 0}, {5, 0}, {5, 1}, {3, 0}, {1, 0}, {1, 1}}, app.rechecked)
+	assert.Equal(t, types.Txs{{1, 0}, {5, 0}, {5, 1}, {9, 0}, {1, 1}}, mempool.ReapMaxTxs(-1))
+	for _, tx := range mempool.ReapMaxTxs(-1) {
+		rechecked, present := mempool.RecheckStatus(TxKey(tx))
+		assert.True(t, present)
+		assert.True(t, rechecked)
+	}
+}
+
+// gasApp is a kvstore application that reports the first byte of each tx as
+// the gas it wants and rejects the txs in invalid on recheck.
+type gasApp struct {
+	*kvstore.Application
+	invalid map[string]bool
+}
+
+func (app *gasApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
+	if req.Type == abci.CheckTxType_Recheck && app.invalid[string(req.Tx)] {
+		return abci.ResponseCheckTx{Code: 1}
+	}
+	res := app.Application.CheckTx(req)
+	res.GasWanted = int64(req.Tx[0])
+	return res
+}
+
+func TestMempoolTotalGasWanted(t *testing.T) {
+	app := &gasApp{kvstore.NewApplication(), map[string]bool{}}
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	sumGasWanted := func() int64 {
+		var sum int64
+		for e := mempool.TxsFront(); e != nil; e = e.Next() {
+			sum += e.Value.(*mempoolTx).gasWanted
+		}
+		return sum
+	}
+
+	// 1. zero by default
+	assert.EqualValues(t, 0, mempool.TotalGasWanted())
+
+	// 2. sum of gas wanted after CheckTx
+	txs := types.Txs{{0x01}, {0x02}, {0x03}, {0x04}, {0x05}, {0x06}}
+	for _, tx := range txs {
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	}
+	assert.EqualValues(t, 21, mempool.TotalGasWanted())
+
+	// 3. committed and rechecked txs are removed by Update
+	app.invalid[string(txs[5])] = true
+	err := mempool.Update(1, txs[:2], abciResponses(2, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, mempool.Size())
+	assert.EqualValues(t, 12, mempool.TotalGasWanted())
+	assert.Equal(t, sumGasWanted(), mempool.TotalGasWanted())
+
+	// 4. RemoveTxByKey
+	mempool.RemoveTxByKey(TxKey(txs[3]), true)
+	assert.EqualValues(t, 8, mempool.TotalGasWanted())
+	assert.Equal(t, sumGasWanted(), mempool.TotalGasWanted())
+
+	// 5. zero after Flush
+	mempool.Flush()
+	assert.EqualValues(t, 0, mempool.TotalGasWanted())
+}
+
+func TestMempoolPeekFront(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	tx, ok := mempool.PeekFront()
+	assert.False(t, ok)
+	assert.Nil(t, tx)
+
+	txs := checkTxs(t, mempool, 3, UnknownPeerID)
+	tx, ok = mempool.PeekFront()
+	require.True(t, ok)
+	assert.Equal(t, txs[0], tx)
+	assert.Equal(t, 3, mempool.Size(), "PeekFront should not remove the tx")
+
+	mempool.Flush()
+	_, ok = mempool.PeekFront()
+	assert.False(t, ok)
+}
+
+// shareApp is a kvstore application that reports the first byte of each tx as
+// the number of shares it occupies.
+type shareApp struct {
+	*kvstore.Application
+}
+
+func (app *shareApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
+	res := app.Application.CheckTx(req)
+	res.ShareCount = int64(req.Tx[0])
+	return res
+}
+
+func TestMempoolShareCount(t *testing.T) {
+	app := &shareApp{kvstore.NewApplication()}
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	assert.Zero(t, mempool.TotalShares())
+
+	txs := types.Txs{{0x01}, {0x02}, {0x03}, {0x04}}
+	for _, tx := range txs {
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	}
+	for e := mempool.TxsFront(); e != nil; e = e.Next() {
+		memTx := e.Value.(*mempoolTx)
+		assert.EqualValues(t, memTx.tx[0], memTx.shareCount)
+	}
+	assert.Equal(t, 10, mempool.TotalShares())
+
+	// the share ceiling is respected along with the others
+	assert.Equal(t, txs[:3], mempool.ReapMaxShares(6, -1, -1))
+	assert.Equal(t, txs[:2], mempool.ReapMaxShares(5, -1, -1))
+	assert.Empty(t, mempool.ReapMaxShares(0, -1, -1))
+	assert.Equal(t, txs[:1], mempool.ReapMaxShares(6, -1, 1))
+	assert.Equal(t, txs, mempool.ReapMaxShares(-1, -1, -1))
+
+	err := mempool.Update(1, txs[:1], abciResponses(1, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 9, mempool.TotalShares())
+
+	mempool.Flush()
+	assert.Zero(t, mempool.TotalShares())
+}
+
+func TestMempoolPeekNext(t *testing.T) {
+	app := &gasApp{kvstore.NewApplication(), map[string]bool{}}
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	_, _, _, ok := mempool.PeekNext()
+	assert.False(t, ok)
+
+	txs := types.Txs{{0x05, 0x00, 0x00}, {0x02}}
+	for _, tx := range txs {
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	}
+	tx, gasWanted, bytes, ok := mempool.PeekNext()
+	require.True(t, ok)
+	assert.Equal(t, txs[0], tx)
+	assert.EqualValues(t, 5, gasWanted)
+	assert.Equal(t, 3, bytes)
+	assert.Equal(t, 2, mempool.Size(), "PeekNext should not remove the tx")
+
+	mempool.Flush()
+	_, _, _, ok = mempool.PeekNext()
+	assert.False(t, ok)
+}
+
+func TestMempoolTxMetadata(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	type fee struct{ amount int }
+	tx0, tx1 := types.Tx{0x01}, types.Tx{0x02}
+	require.NoError(t, mempool.CheckTx(tx0, nil, TxInfo{Metadata: fee{10}}))
+	require.NoError(t, mempool.CheckTx(tx1, nil, TxInfo{}))
+
+	metadata, ok := mempool.GetTxMetadata(TxKey(tx0))
+	require.True(t, ok)
+	assert.Equal(t, fee{10}, metadata)
+
+	metadata, ok = mempool.GetTxMetadata(TxKey(tx1))
+	require.True(t, ok)
+	assert.Nil(t, metadata)
+
+	// the metadata is gone once the tx is removed
+	err := mempool.Update(1, types.Txs{tx0}, abciResponses(1, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	_, ok = mempool.GetTxMetadata(TxKey(tx0))
+	assert.False(t, ok)
+
+	mempool.RemoveTxByKey(TxKey(tx1), true)
+	_, ok = mempool.GetTxMetadata(TxKey(tx1))
+	assert.False(t, ok)
+}
+
+func TestMempoolTxSidecar(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	tx0, tx1 := types.Tx{0x01}, types.Tx{0x02}
+	blob := bytes.Repeat([]byte{0xFF}, 1024)
+	require.NoError(t, mempool.CheckTxWithSidecar(tx0, blob, nil, TxInfo{}))
+	require.NoError(t, mempool.CheckTx(tx1, nil, TxInfo{}))
+
+	// only the tx bytes are accounted for
+	assert.EqualValues(t, 2, mempool.TxsBytes())
+
+	sidecar, ok := mempool.GetSidecar(TxKey(tx0))
+	require.True(t, ok)
+	assert.Equal(t, blob, sidecar)
+
+	sidecar, ok = mempool.GetSidecar(TxKey(tx1))
+	require.True(t, ok)
+	assert.Nil(t, sidecar)
+
+	// the reaped txs don't include the sidecar
+	assert.Equal(t, types.Txs{tx0, tx1}, mempool.ReapMaxBytesMaxGas(-1, -1))
+
+	// the sidecar is gone once the tx is removed
+	err := mempool.Update(1, types.Txs{tx0}, abciResponses(1, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	_, ok = mempool.GetSidecar(TxKey(tx0))
+	assert.False(t, ok)
+	assert.EqualValues(t, 1, mempool.TxsBytes())
+}
+
+func TestReapNewestFirst(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	// each tx has 20 bytes and wants 1 gas
+	txs := checkTxs(t, mempool, 5, UnknownPeerID)
+	newestFirst := types.Txs{txs[4], txs[3], txs[2], txs[1], txs[0]}
+
+	assert.Equal(t, newestFirst, mempool.ReapNewestFirst(-1, -1))
+	assert.Equal(t, newestFirst[:2], mempool.ReapNewestFirst(-1, 2))
+	assert.Equal(t, newestFirst[:3], mempool.ReapNewestFirst(
+		types.ComputeProtoSizeForTxs(newestFirst[:3]), -1))
+	assert.Empty(t, mempool.ReapNewestFirst(0, -1))
+
+	// FIFO reap is unaffected
+	assert.Equal(t, txs[:2], mempool.ReapMaxBytesMaxGas(-1, 2))
+}
+
+func TestReapWithStrategy(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	// each tx has 20 bytes and wants 1 gas
+	txs := checkTxs(t, mempool, 5, UnknownPeerID)
+
+	ceilings := []struct{ maxBytes, maxGas int64 }{
+		{-1, -1},
+		{-1, 2},
+		{types.ComputeProtoSizeForTxs(txs[:3]), -1},
+		{0, -1},
+	}
+	for _, c := range ceilings {
+		assert.Equal(t, mempool.ReapMaxBytesMaxGas(c.maxBytes, c.maxGas),
+			mempool.ReapWithStrategy(ReapStrategyFIFO, c.maxBytes, c.maxGas))
+		assert.Equal(t, mempool.ReapNewestFirst(c.maxBytes, c.maxGas),
+			mempool.ReapWithStrategy(ReapStrategyNewestFirst, c.maxBytes, c.maxGas))
+	}
+	assert.Equal(t, txs, mempool.ReapWithStrategy(ReapStrategyFIFO, -1, -1))
+
+	// an unknown strategy reaps nothing
+	assert.Empty(t, mempool.ReapWithStrategy(ReapStrategy(-1), -1, -1))
+	assert.Equal(t, len(txs), mempool.Size())
+}
+
+func TestReapSenderNonceOrder(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	alice, bob := []byte("alice"), []byte("bob")
+	checkTx := func(tx types.Tx, sender []byte, nonce uint64) {
+		err := mempool.CheckTx(tx, nil, TxInfo{SenderAddress: sender, Nonce: nonce})
+		require.NoError(t, err)
+	}
+	alice2, alice0, bob1, anon, alice1, alice4 := types.Tx("alice2"), types.Tx("alice0"),
+		types.Tx("bob1"), types.Tx("anon"), types.Tx("alice1"), types.Tx("alice4")
+	checkTx(alice2, alice, 2)
+	checkTx(alice0, alice, 0)
+	checkTx(bob1, bob, 1)
+	checkTx(anon, nil, 0)
+	checkTx(alice1, alice, 1)
+	// nonce 3 is missing, so nonce 4 must be withheld
+	checkTx(alice4, alice, 4)
+
+	assert.Equal(t, types.Txs{alice0, bob1, anon, alice1, alice2}, mempool.ReapMaxBytesMaxGas(-1, -1))
+	assert.Equal(t, types.Txs{anon, bob1, alice0, alice1, alice2}, mempool.ReapNewestFirst(-1, -1))
+	// the ceilings also apply to txs reaped after their predecessor
+	assert.Equal(t, types.Txs{alice0, bob1, anon, alice1}, mempool.ReapMaxBytesMaxGas(-1, 4))
+
+	// once nonces 0 and 1 are committed, nonce 2 comes first
+	mempool.Lock()
+	err := mempool.Update(1, types.Txs{alice0, alice1}, abciResponses(2, abci.CodeTypeOK), nil, nil)
+	mempool.Unlock()
+	require.NoError(t, err)
+	assert.Equal(t, types.Txs{alice2, bob1, anon}, mempool.ReapMaxBytesMaxGas(-1, -1))
+
+	// a stale nonce is never reaped
+	checkTx(types.Tx("alice1-again"), alice, 1)
+	assert.Equal(t, types.Txs{alice2, bob1, anon}, mempool.ReapMaxBytesMaxGas(-1, -1))
+
+	// filling the gap releases the withheld tx
+	alice3 := types.Tx("alice3")
+	checkTx(alice3, alice, 3)
+	assert.Equal(t, types.Txs{alice2, bob1, anon, alice3, alice4}, mempool.ReapMaxBytesMaxGas(-1, -1))
+
+	// the next nonce of a sender is dropped once none of its txs are left
+	mempool.Lock()
+	err = mempool.Update(3, types.Txs{alice2, alice3, alice4, types.Tx("alice1-again")},
+		abciResponses(4, abci.CodeTypeOK), nil, nil)
+	mempool.Unlock()
+	require.NoError(t, err)
+	assert.NotContains(t, mempool.senderNonces, string(alice))
+
+	// nonces committed in blocks proposed by others don't withhold the
+	// sender's later txs
+	checkTx(types.Tx("bob3"), bob, 3)
+	mempool.Lock()
+	err = mempool.Update(4, types.Txs{bob1, types.Tx("bob2")}, abciResponses(2, abci.CodeTypeOK), nil, nil)
+	mempool.Unlock()
+	require.NoError(t, err)
+	assert.Equal(t, types.Txs{anon, types.Tx("bob3")}, mempool.ReapMaxBytesMaxGas(-1, -1))
+}
+
+func TestReapByNamespace(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	config := cfg.ResetTestRoot("mempool_test")
+	appConnMem, _ := cc.NewABCIClient()
+	require.NoError(t, appConnMem.Start())
+	defer os.RemoveAll(config.RootDir)
+
+	// the first byte of each tx is its namespace
+	extractor := func(tx types.Tx) (namespace.ID, error) {
+		if len(tx) == 0 {
+			return nil, errors.New("empty tx")
+		}
+		return bytes.Repeat(tx[:1], types.NamespaceSize), nil
+	}
+	mempool := NewCListMempool(config.Mempool, appConnMem, 0, WithNamespaceExtractor(extractor))
+	mempool.SetLogger(log.TestingLogger())
+
+	nid := func(b byte) namespace.ID { return bytes.Repeat([]byte{b}, types.NamespaceSize) }
+	txs := types.Txs{{1, 0}, {2, 0}, {1, 1}, {3, 0}, {1, 2}, {2, 1}}
+	for _, tx := range txs {
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	}
+
+	assert.Equal(t, types.Txs{{1, 0}, {1, 1}, {1, 2}}, mempool.ReapByNamespace(nid(1), -1))
+	assert.Equal(t, types.Txs{{2, 0}, {2, 1}}, mempool.ReapByNamespace(nid(2), -1))
+	assert.Empty(t, mempool.ReapByNamespace(nid(4), -1))
+
+	// the byte ceiling is honored
+	assert.Equal(t, types.Txs{{1, 0}, {1, 1}},
+		mempool.ReapByNamespace(nid(1), types.ComputeProtoSizeForTxs(types.Txs{{1, 0}, {1, 1}})))
+	assert.Empty(t, mempool.ReapByNamespace(nid(1), 0))
+
+	// without an extractor, no txs are reaped
+	mempool.namespaceExtractor = nil
+	require.NoError(t, mempool.CheckTx(types.Tx{1, 3}, nil, TxInfo{}))
+	assert.Equal(t, types.Txs{{1, 0}, {1, 1}, {1, 2}}, mempool.ReapByNamespace(nid(1), -1))
+}
+
+// inclusionLog is an InclusionRecorder that keeps the recorded keys in
+// memory.
+type inclusionLog map[int64][][TxKeySize]byte
+
+func (l inclusionLog) RecordInclusion(height int64, txKeys [][TxKeySize]byte) {
+	l[height] = txKeys
+}
+
+func TestUpdateRecordsInclusion(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	config := cfg.ResetTestRoot("mempool_test")
+	appConnMem, _ := cc.NewABCIClient()
+	require.NoError(t, appConnMem.Start())
+	defer os.RemoveAll(config.RootDir)
+
+	recorded := make(inclusionLog)
+	mempool := NewCListMempool(config.Mempool, appConnMem, 0, WithInclusionRecorder(recorded))
+	mempool.SetLogger(log.TestingLogger())
+
+	txs := types.Txs{{0x01}, {0x02}, {0x03}, {0x04}}
+	for _, tx := range txs {
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	}
+
+	err := mempool.Update(1, txs[:2], abciResponses(2, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][TxKeySize]byte{TxKey(txs[0]), TxKey(txs[1])}, recorded[1])
+
+	// txs that were never in the mempool are not recorded
+	err = mempool.Update(2, types.Txs{txs[3], {0x05}}, abciResponses(2, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][TxKeySize]byte{TxKey(txs[3])}, recorded[2])
+	assert.Len(t, recorded, 2)
+}
+
+// slowAppConnMempool is a mempool connection to an app that only responds to
+// CheckTx when told to.
+type slowAppConnMempool struct {
+	proxy.AppConnMempool
+
+	mtx     tmsync.Mutex
+	pending []*abcicli.ReqRes
+}
+
+func (c *slowAppConnMempool) CheckTxAsync(_ context.Context, req abci.RequestCheckTx) (*abcicli.ReqRes, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	reqRes := abcicli.NewReqRes(abci.ToRequestCheckTx(req))
+	c.pending = append(c.pending, reqRes)
+	return reqRes, nil
+}
+
+func (c *slowAppConnMempool) numPending() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return len(c.pending)
+}
+
+// respond responds to all pending CheckTx requests with res.
+func (c *slowAppConnMempool) respond(res abci.ResponseCheckTx) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, reqRes := range c.pending {
+		reqRes.Response = abci.ToResponseCheckTx(res)
+		reqRes.Done()
+		reqRes.SetDone()
+		if cb := reqRes.GetCallback(); cb != nil {
+			cb(reqRes.Response)
+		}
+	}
+	c.pending = nil
+}
+
+func TestMempoolCheckTxTimeout(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	config := cfg.ResetTestRoot("mempool_test")
+	config.Mempool.CheckTxTimeout = 50 * time.Millisecond
+	appConnMem, _ := cc.NewABCIClient()
+	require.NoError(t, appConnMem.Start())
+	defer os.RemoveAll(config.RootDir)
+
+	// an app responding in time is not affected by the timeout
+	mempool := NewCListMempool(config.Mempool, appConnMem, 0)
+	mempool.SetLogger(log.TestingLogger())
+	require.NoError(t, mempool.CheckTx(types.Tx{0x01}, nil, TxInfo{}))
+	assert.Equal(t, 1, mempool.Size())
+	assert.EqualValues(t, 1, mempool.TxsBytes())
+
+	slowConn := &slowAppConnMempool{AppConnMempool: appConnMem}
+	mempool = NewCListMempool(config.Mempool, slowConn, 0)
+	mempool.SetLogger(log.TestingLogger())
+
+	tx := types.Tx{0x02, 0x03}
+	assert.Equal(t, ErrCheckTxTimeout, mempool.CheckTx(tx, nil, TxInfo{}))
+	assert.Zero(t, mempool.Size())
+	assert.Zero(t, mempool.TxsBytes())
+
+	// the late response is ignored
+	slowConn.respond(abci.ResponseCheckTx{Code: abci.CodeTypeOK})
+	assert.Zero(t, mempool.Size())
+	assert.Zero(t, mempool.TxsBytes())
+
+	// the tx was removed from the cache, so it's checked again
+	assert.Equal(t, ErrCheckTxTimeout, mempool.CheckTx(tx, nil, TxInfo{}))
+	assert.Equal(t, 1, slowConn.numPending())
+	slowConn.respond(abci.ResponseCheckTx{Code: abci.CodeTypeOK})
+
+	// waiting for a response doesn't block Update
+	config.Mempool.CheckTxTimeout = time.Minute
+	checked := make(chan error, 1)
+	go func() { checked <- mempool.CheckTx(tx, nil, TxInfo{}) }()
+	require.Eventually(t, func() bool { return slowConn.numPending() == 1 }, time.Second, time.Millisecond)
+	mempool.Lock()
+	err := mempool.Update(1, nil, nil, nil, nil)
+	mempool.Unlock()
+	require.NoError(t, err)
+	slowConn.respond(abci.ResponseCheckTx{Code: abci.CodeTypeOK})
+	require.NoError(t, <-checked)
+	assert.Equal(t, 1, mempool.Size())
+}
+
+// recordingApp is a kvstore application that records the type of each
+// CheckTx request.
+type recordingApp struct {
+	*kvstore.Application
+	checkTxTypes []abci.CheckTxType
+}
+
+func (app *recordingApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
+	app.checkTxTypes = append(app.checkTxTypes, req.Type)
+	return app.Application.CheckTx(req)
+}
+
+func TestMempoolCheckTxType(t *testing.T) {
+	app := &recordingApp{Application: kvstore.NewApplication()}
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+	require.True(t, mempool.config.Recheck)
+
+	txs := checkTxs(t, mempool, 2, UnknownPeerID)
+	assert.Equal(t, []abci.CheckTxType{abci.CheckTxType_New, abci.CheckTxType_New}, app.checkTxTypes)
+
+	// the remaining tx is rechecked after the update
+	app.checkTxTypes = nil
+	err := mempool.Update(1, txs[:1], abciResponses(1, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []abci.CheckTxType{abci.CheckTxType_Recheck}, app.checkTxTypes)
+}
+
+func TestMempoolFlushExceptLocal(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	localTxs := checkTxs(t, mempool, 2, UnknownPeerID)
+	peerTxs := checkTxs(t, mempool, 3, 1)
+	localTxs = append(localTxs, checkTxs(t, mempool, 1, UnknownPeerID)...)
+	// a tx received from a peer and also submitted locally is kept
+	err := mempool.CheckTx(peerTxs[0], nil, TxInfo{SenderID: UnknownPeerID})
+	require.Equal(t, ErrTxInCache, err)
+	localTxs = append(localTxs, peerTxs[0])
+	require.Equal(t, 6, mempool.Size())
+
+	mempool.FlushExceptLocal()
+
+	assert.ElementsMatch(t, localTxs, mempool.ReapMaxTxs(-1))
+	assert.EqualValues(t, len(localTxs)*20, mempool.TxsBytes())
+
+	// the removed txs can be received again
+	err = mempool.CheckTx(peerTxs[1], nil, TxInfo{SenderID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, len(localTxs)+1, mempool.Size())
+}
+
+func TestMempoolRemoveMatching(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	var kept, dropped types.Txs
+	for i := 0; i < 10; i++ {
+		tx := make(types.Tx, 20)
+		_, err := rand.Read(tx[1:])
+		require.NoError(t, err)
+		if i%3 == 0 {
+			tx[0] = 0xFF
+			dropped = append(dropped, tx)
+		} else {
+			tx[0] = 0x01
+			kept = append(kept, tx)
+		}
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	}
+	require.Equal(t, 10, mempool.Size())
+
+	n := mempool.RemoveMatching(func(tx types.Tx) bool { return tx[0] == 0xFF })
+	assert.Equal(t, len(dropped), n)
+	assert.Equal(t, len(kept), mempool.Size())
+	assert.EqualValues(t, len(kept)*20, mempool.TxsBytes())
+	assert.ElementsMatch(t, kept, mempool.ReapMaxTxs(-1))
+
+	// nothing else matches
+	assert.Zero(t, mempool.RemoveMatching(func(tx types.Tx) bool { return tx[0] == 0xFF }))
+
+	// the removed txs can be received again, the kept ones are still cached
+	require.NoError(t, mempool.CheckTx(dropped[0], nil, TxInfo{}))
+	assert.Equal(t, ErrTxInCache, mempool.CheckTx(kept[0], nil, TxInfo{}))
+}
+
+func TestMempoolReserve(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	config := cfg.ResetTestRoot("mempool_test")
+	config.Mempool.Size = 10
+	config.Mempool.MaxTxsBytes = 200
+	mempool, cleanup := newMempoolWithAppAndConfig(cc, config)
+	defer cleanup()
+
+	checkTxs(t, mempool, 2, UnknownPeerID)
+
+	// more than the free capacity can't be reserved
+	_, err := mempool.Reserve(200, 1)
+	assert.IsType(t, ErrMempoolIsFull{}, err)
+	_, err = mempool.Reserve(20, 9)
+	assert.IsType(t, ErrMempoolIsFull{}, err)
+	_, err = mempool.Reserve(0, 1)
+	assert.Error(t, err)
+
+	res, err := mempool.Reserve(100, 5)
+	require.NoError(t, err)
+
+	// the reserved capacity isn't available to other txs or reservations
+	_, err = mempool.Reserve(80, 1)
+	assert.IsType(t, ErrMempoolIsFull{}, err)
+	checkTxs(t, mempool, 3, UnknownPeerID)
+	require.Equal(t, 5, mempool.Size())
+	err = mempool.CheckTx(make([]byte, 20), nil, TxInfo{})
+	assert.IsType(t, ErrMempoolIsFull{}, err)
+
+	// the reservation can be filled although the mempool is full otherwise
+	for i := 0; i < 5; i++ {
+		tx := make([]byte, 20)
+		_, err := rand.Read(tx)
+		require.NoError(t, err)
+		require.NoError(t, res.CheckTx(tx, nil, TxInfo{}))
+	}
+	assert.Equal(t, 10, mempool.Size())
+	assert.EqualValues(t, 200, mempool.TxsBytes())
+	bytes, count := res.Remaining()
+	assert.Zero(t, bytes)
+	assert.Zero(t, count)
+	assert.Equal(t, ErrReservationExceeded, res.CheckTx(make([]byte, 20), nil, TxInfo{}))
+
+	// releasing returns what is left of a reservation
+	mempool.Flush()
+	res, err = mempool.Reserve(150, 5)
+	require.NoError(t, err)
+	require.NoError(t, res.CheckTx(make([]byte, 50), nil, TxInfo{}))
+	_, err = mempool.Reserve(100, 1)
+	assert.IsType(t, ErrMempoolIsFull{}, err)
+	res.Release()
+	assert.Equal(t, ErrReservationExceeded, res.CheckTx(make([]byte, 10), nil, TxInfo{}))
+	_, err = mempool.Reserve(150, 9)
+	assert.NoError(t, err)
+}
+
+func TestMempoolPreloadFromBlock(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	txs := make(types.Txs, 5)
+	for i := range txs {
+		txs[i] = []byte(fmt.Sprintf("preload%d=%d", i, i))
+	}
+	n, err := mempool.PreloadFromBlock(txs)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, txs, mempool.ReapMaxTxs(-1))
+
+	// txs in the mempool aren't added again
+	n, err = mempool.PreloadFromBlock(txs[:2])
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, 5, mempool.Size())
+
+	// txs removed from the mempool, but still in the cache, are added again
+	err = mempool.Update(1, txs[:3], abciResponses(3, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, mempool.Size())
+	assert.Equal(t, ErrTxInCache, mempool.CheckTx(txs[0], nil, TxInfo{}))
+	n, err = mempool.PreloadFromBlock(txs[:3])
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.ElementsMatch(t, txs, mempool.ReapMaxTxs(-1))
+
+	// with an async app connection, the txs are counted as the app checks them
+	asyncMempool, asyncCleanup := newMempoolWithAsyncApp(kvstore.NewApplication(), cfg.ResetTestRoot("mempool_test"))
+	defer asyncCleanup()
+	n, err = asyncMempool.PreloadFromBlock(txs)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, txs, asyncMempool.ReapMaxTxs(-1))
+}
+
+func TestMempoolCustomTxKey(t *testing.T) {
+	// a digest that ignores everything after the first "=", so txs with the
+	// same key, but different values, are considered the same tx
+	defaultTxKey := TxKey
+	TxKey = func(tx types.Tx) [TxKeySize]byte {
+		var key [TxKeySize]byte
+		copy(key[:], bytes.SplitN(tx, []byte("="), 2)[0])
+		return key
+	}
+	defer func() { TxKey = defaultTxKey }()
+
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	require.NoError(t, mempool.CheckTx([]byte("a=1"), nil, TxInfo{}))
+	require.NoError(t, mempool.CheckTx([]byte("b=1"), nil, TxInfo{}))
+	assert.Equal(t, ErrTxInCache, mempool.CheckTx([]byte("a=2"), nil, TxInfo{}))
+	require.Equal(t, 2, mempool.Size())
+
+	var key [TxKeySize]byte
+	copy(key[:], "a")
+	mempool.RemoveTxByKey(key, true)
+	assert.Equal(t, types.Txs{[]byte("b=1")}, mempool.ReapMaxTxs(-1))
+
+	// removed from the cache as well, so a tx with the same key can be added
+	require.NoError(t, mempool.CheckTx([]byte("a=2"), nil, TxInfo{}))
+	assert.Equal(t, types.Txs{[]byte("b=1"), []byte("a=2")}, mempool.ReapMaxTxs(-1))
+}
+
+func TestMempoolLockTxs(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	txs := checkTxs(t, mempool, 4, UnknownPeerID)
+	locked := [][TxKeySize]byte{TxKey(txs[1]), TxKey(txs[2])}
+	mempool.LockTxs(locked)
+
+	unlocked := types.Txs{txs[0], txs[3]}
+	assert.Equal(t, unlocked, mempool.ReapMaxBytesMaxGas(-1, -1))
+	assert.Equal(t, unlocked, mempool.ReapMaxTxs(-1))
+	assert.Equal(t, types.Txs{txs[3], txs[0]}, mempool.ReapNewestFirst(-1, -1))
+	assert.Equal(t, unlocked, mempool.ReapFairByPeer(-1, -1))
+	assert.Equal(t, 4, mempool.Size(), "locked txs should stay in the mempool")
+
+	mempool.UnlockTxs(locked[:1])
+	assert.Equal(t, types.Txs{txs[0], txs[1], txs[3]}, mempool.ReapMaxBytesMaxGas(-1, -1))
+	mempool.UnlockTxs(locked[1:])
+	assert.Equal(t, txs, mempool.ReapMaxBytesMaxGas(-1, -1))
+
+	// locks are released when the tx is removed
+	mempool.LockTxs(locked)
+	mempool.RemoveTxByKey(TxKey(txs[1]), true)
+	require.NoError(t, mempool.CheckTx(txs[1], nil, TxInfo{}))
+	assert.Equal(t, types.Txs{txs[0], txs[3], txs[1]}, mempool.ReapMaxBytesMaxGas(-1, -1))
+}
+
+func TestReapSkipsDuplicateKeys(t *testing.T) {
+	// a digest that ignores everything after the first "=", so txs with the
+	// same key, but different values, collide
+	defaultTxKey := TxKey
+	TxKey = func(tx types.Tx) [TxKeySize]byte {
+		var key [TxKeySize]byte
+		copy(key[:], bytes.SplitN(tx, []byte("="), 2)[0])
+		return key
+	}
+	defer func() { TxKey = defaultTxKey }()
+
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	config := cfg.ResetTestRoot("mempool_test")
+	// without the cache, nothing keeps colliding txs out of the mempool
+	config.Mempool.CacheSize = 0
+	mempool, cleanup := newMempoolWithAppAndConfig(cc, config)
+	defer cleanup()
+
+	for _, tx := range []string{"a=1", "b=1", "a=2", "a=1"} {
+		require.NoError(t, mempool.CheckTx([]byte(tx), nil, TxInfo{}))
+	}
+	require.Equal(t, 4, mempool.Size())
+
+	assert.Equal(t, types.Txs{[]byte("a=1"), []byte("b=1")}, mempool.ReapMaxBytesMaxGas(-1, -1))
+	assert.Equal(t, types.Txs{[]byte("a=1"), []byte("b=1")}, mempool.ReapMaxTxs(-1))
+	assert.Equal(t, types.Txs{[]byte("a=1"), []byte("b=1")}, mempool.ReapFairByPeer(-1, -1))
+	assert.Equal(t, types.Txs{[]byte("a=1"), []byte("b=1")}, mempool.ReapNewestFirst(-1, -1))
+}
+
+func TestReapOrderingValidator(t *testing.T) {
+	app := counter.NewApplication(true)
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	counterTx := func(i uint64) types.Tx {
+		tx := make([]byte, 8)
+		binary.BigEndian.PutUint64(tx, i)
+		return tx
+	}
+	// the counter app accepts all txs not below its count in CheckTx, but
+	// only the next value at DeliverTx
+	for _, i := range []uint64{0, 1, 2, 4, 5} {
+		require.NoError(t, mempool.CheckTx(counterTx(i), nil, TxInfo{}))
+	}
+	require.Len(t, mempool.ReapMaxBytesMaxGas(-1, -1), 5)
+
+	mempool.SetOrderingValidator(func(prev, next types.Tx) bool {
+		return binary.BigEndian.Uint64(next) == binary.BigEndian.Uint64(prev)+1
+	})
+	assert.Equal(t, types.Txs{counterTx(0), counterTx(1), counterTx(2)}, mempool.ReapMaxBytesMaxGas(-1, -1))
+
+	// skipped txs don't stop the reap, later txs may still be in order
+	require.NoError(t, mempool.CheckTx(counterTx(3), nil, TxInfo{}))
+	assert.Equal(t, types.Txs{counterTx(0), counterTx(1), counterTx(2), counterTx(3)},
+		mempool.ReapMaxBytesMaxGas(-1, -1))
+
+	mempool.SetOrderingValidator(nil)
+	assert.Len(t, mempool.ReapMaxBytesMaxGas(-1, -1), 6)
+}
+
+func TestMempoolSwapAppConn(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	// the kvstore app accepts any tx, the counter app only 8 byte ones
+	var counterTxs types.Txs
+	for i := 0; i < 3; i++ {
+		tx := make([]byte, 8)
+		binary.BigEndian.PutUint64(tx, uint64(i))
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+		counterTxs = append(counterTxs, tx)
+	}
+	checkTxs(t, mempool, 2, UnknownPeerID)
+	require.Equal(t, 5, mempool.Size())
+
+	newConn, err := proxy.NewLocalClientCreator(counter.NewApplication(true)).NewABCIClient()
+	require.NoError(t, err)
+	require.NoError(t, newConn.Start())
+	t.Cleanup(func() {
+		if err := newConn.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	require.NoError(t, mempool.SwapAppConn(newConn))
+	require.NoError(t, mempool.FlushAppConn())
+
+	// the txs invalid under the new app were removed by the recheck
+	assert.Equal(t, counterTxs, mempool.ReapMaxTxs(-1))
+	assert.EqualValues(t, 3*8, mempool.TxsBytes())
+
+	// new txs are checked by the new app
+	err = mempool.CheckTx(make([]byte, 20), func(res *abci.Response) {
+		assert.NotEqual(t, abci.CodeTypeOK, res.GetCheckTx().Code)
+	}, TxInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, mempool.Size())
+}
+
+func TestGroupByNamespace(t *testing.T) {
+	nid := func(b byte) namespace.ID { return bytes.Repeat([]byte{b}, types.NamespaceSize) }
+	nsTx := func(b byte, data ...byte) types.Tx { return append(types.Tx(nid(b)), data...) }
+
+	txs := types.Txs{
+		nsTx(2, 1), nsTx(1, 1), nsTx(3, 1), nsTx(2, 2),
+		nsTx(1)[:types.NamespaceSize-1], // too short
+		nsTx(1, 2), nsTx(2, 3), nsTx(3, 2), nsTx(1),
+	}
+	groups := GroupByNamespace(txs, types.NamespaceSize)
+	assert.Equal(t, map[string]types.Txs{
+		string(nid(1)): {nsTx(1, 1), nsTx(1, 2), nsTx(1)},
+		string(nid(2)): {nsTx(2, 1), nsTx(2, 2), nsTx(2, 3)},
+		string(nid(3)): {nsTx(3, 1), nsTx(3, 2)},
+	}, groups)
+
+	assert.Empty(t, GroupByNamespace(nil, types.NamespaceSize))
+}
+
+func TestReapFairByPeer(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	peer1Txs := checkTxs(t, mempool, 4, 1)
+	peer2Txs := checkTxs(t, mempool, 2, 2)
+	peer3Txs := checkTxs(t, mempool, 2, 3)
+	localTxs := checkTxs(t, mempool, 1, UnknownPeerID)
+	// a tx received again from another peer still counts for the first one
+	require.Equal(t, ErrTxInCache, mempool.CheckTx(peer1Txs[1], nil, TxInfo{SenderID: 2}))
+
+	// each 20 byte tx takes 22 bytes in the block data, on top of 6 bytes, so
+	// the ceiling fits 4 txs, which in FIFO order are all from the first peer
+	assert.Equal(t, peer1Txs, mempool.ReapMaxBytesMaxGas(6+4*22, -1))
+	assert.Equal(t, types.Txs{localTxs[0], peer1Txs[0], peer2Txs[0], peer3Txs[0]},
+		mempool.ReapFairByPeer(6+4*22, -1))
+
+	assert.Equal(t, types.Txs{
+		localTxs[0],
+		peer1Txs[0], peer2Txs[0], peer3Txs[0],
+		peer1Txs[1], peer2Txs[1], peer3Txs[1],
+		peer1Txs[2], peer1Txs[3],
+	}, mempool.ReapFairByPeer(-1, -1))
+	assert.Equal(t, mempool.ReapFairByPeer(-1, -1), mempool.ReapWithStrategy(ReapStrategyFairByPeer, -1, -1))
+}
+
+func TestMempoolTxsBytesByNamespace(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	nid := func(b byte) namespace.ID { return bytes.Repeat([]byte{b}, types.NamespaceSize) }
+	want := make(map[string]int64)
+	for i := 0; i < 12; i++ {
+		ns := nid(byte(i % 3))
+		tx := append(types.Tx(ns), tmrand.Bytes(1+i)...)
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+		want[string(ns)] += int64(len(tx))
+	}
+	// too short to contain a namespace
+	require.NoError(t, mempool.CheckTx(types.Tx{0x01}, nil, TxInfo{}))
+
+	got := mempool.TxsBytesByNamespace(types.NamespaceSize)
+	assert.Equal(t, want, got)
+	var total int64
+	for _, size := range got {
+		total += size
+	}
+	assert.Equal(t, mempool.TxsBytes()-1, total)
+
+	// removed txs no longer count
+	mempool.RemoveMatching(func(tx types.Tx) bool { return bytes.HasPrefix(tx, nid(1)) })
+	delete(want, string(nid(1)))
+	assert.Equal(t, want, mempool.TxsBytesByNamespace(types.NamespaceSize))
+}
+
+// countdownContext is a context that is done after Err was called a given
+// number of times.
+type countdownContext struct {
+	context.Context
+	remaining int
+}
+
+func (ctx *countdownContext) Err() error {
+	if ctx.remaining <= 0 {
+		return context.DeadlineExceeded
+	}
+	ctx.remaining--
+	return nil
+}
+
+func TestReapWithDeadline(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	txs := checkTxs(t, mempool, 1000, UnknownPeerID)
+
+	// without a deadline, it's a complete reap
+	assert.Equal(t, mempool.ReapMaxBytesMaxGas(-1, -1), mempool.ReapWithDeadline(context.Background(), -1, -1))
+
+	// the deadline is exceeded after 10 txs
+	ctx := &countdownContext{Context: context.Background(), remaining: 10}
+	assert.Equal(t, txs[:10], mempool.ReapWithDeadline(ctx, -1, -1))
+
+	// the ceiling is still respected if it is reached first
+	ctx = &countdownContext{Context: context.Background(), remaining: 10}
+	assert.Equal(t, txs[:3], mempool.ReapWithDeadline(ctx, 6+3*22, -1))
+
+	// a real deadline
+	const maxBytes = 6 + 500*22
+	ctx2, cancel := context.WithTimeout(context.Background(), time.Microsecond)
+	defer cancel()
+	reaped := mempool.ReapWithDeadline(ctx2, maxBytes, -1)
+	assert.LessOrEqual(t, len(reaped), 500)
+	assert.LessOrEqual(t, types.ComputeProtoSizeForTxs(reaped), int64(maxBytes))
+	assert.Equal(t, txs[:len(reaped)], reaped)
+	<-ctx2.Done()
+	assert.Empty(t, mempool.ReapWithDeadline(ctx2, maxBytes, -1))
+}
+
 func TestMempoolFilters(t *testing.T) {
 	app := kvstore.NewApplication()
 	cc := proxy.NewLocalClientCreator(app)
@@ -177,6 +1214,111 @@ func TestMempoolFilters(t *testing.T) {
 	}
 }
 
+func TestMempoolSharesFilter(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+	emptyTxArr := []types.Tx{[]byte{}}
+
+	nopPostFilter := func(tx types.Tx, res *abci.ResponseCheckTx) error { return nil }
+
+	// each table driven test creates numTxsToCreate txs with checkTx, and at the end clears all remaining txs.
+	// each tx has 20 bytes, 21 bytes including the length prefix
+	tests := []struct {
+		numTxsToCreate int
+		preFilter      PreCheckFunc
+		postFilter     PostCheckFunc
+		expectedNumTxs int
+	}{
+		{10, PreCheckMaxShares(0, types.ShareSize), nopPostFilter, 0},
+		{10, PreCheckMaxShares(1, types.ShareSize), nopPostFilter, 10},
+		{10, PreCheckMaxShares(1, 21), nopPostFilter, 10},
+		{10, PreCheckMaxShares(1, 20), nopPostFilter, 0},
+		{10, PreCheckMaxShares(2, 20), nopPostFilter, 10},
+		{10, PreCheckMaxShares(2, 10), nopPostFilter, 0},
+		{10, PreCheckMaxShares(3, 10), nopPostFilter, 10},
+		{10, PreCheckMaxShares(3, 10), PostCheckMaxGas(0), 0},
+		{10, PreCheckMaxShares(3, 10), PostCheckMaxGas(1), 10},
+	}
+	for tcIndex, tt := range tests {
+		err := mempool.Update(1, emptyTxArr, abciResponses(len(emptyTxArr), abci.CodeTypeOK), tt.preFilter, tt.postFilter)
+		require.NoError(t, err)
+		checkTxs(t, mempool, tt.numTxsToCreate, UnknownPeerID)
+		require.Equal(t, tt.expectedNumTxs, mempool.Size(), "mempool had the incorrect size, on test case %d", tcIndex)
+		mempool.Flush()
+	}
+
+	// an invalid share size is caught when the filter is built, not per tx
+	assert.Panics(t, func() { PreCheckMaxShares(1, 0) })
+	assert.Panics(t, func() { PreCheckMaxShares(1, -1) })
+}
+
+func TestMempoolNamespacePrefixFilter(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+	emptyTxArr := []types.Tx{[]byte{}}
+
+	nopPostFilter := func(tx types.Tx, res *abci.ResponseCheckTx) error { return nil }
+	nid := func(b byte) namespace.ID { return bytes.Repeat([]byte{b}, types.NamespaceSize) }
+	nsTx := func(b byte, data ...byte) types.Tx { return append(types.Tx(nid(b)), data...) }
+	allowed := []namespace.ID{nid(1), nid(2)}
+
+	tests := []struct {
+		tx             types.Tx
+		preFilter      PreCheckFunc
+		postFilter     PostCheckFunc
+		expectedNumTxs int
+	}{
+		{nsTx(1, 0xAA), PreCheckNamespacePrefix(allowed, types.NamespaceSize), nopPostFilter, 1},
+		{nsTx(2, 0xAA), PreCheckNamespacePrefix(allowed, types.NamespaceSize), nopPostFilter, 1},
+		{nsTx(2), PreCheckNamespacePrefix(allowed, types.NamespaceSize), nopPostFilter, 1},
+		{nsTx(3, 0xAA), PreCheckNamespacePrefix(allowed, types.NamespaceSize), nopPostFilter, 0},
+		{nsTx(1)[:types.NamespaceSize-1], PreCheckNamespacePrefix(allowed, types.NamespaceSize), nopPostFilter, 0},
+		{types.Tx{}, PreCheckNamespacePrefix(allowed, types.NamespaceSize), nopPostFilter, 0},
+		{nsTx(1, 0xAA), PreCheckNamespacePrefix(nil, types.NamespaceSize), nopPostFilter, 0},
+		{nsTx(1, 0xAA), PreCheckNamespacePrefix(allowed, types.NamespaceSize), PostCheckMaxGas(0), 0},
+	}
+	for tcIndex, tt := range tests {
+		err := mempool.Update(1, emptyTxArr, abciResponses(len(emptyTxArr), abci.CodeTypeOK), tt.preFilter, tt.postFilter)
+		require.NoError(t, err)
+		err = mempool.CheckTx(tt.tx, nil, TxInfo{})
+		if err != nil {
+			assert.True(t, IsPreCheckError(err), "unexpected error on test case %d: %v", tcIndex, err)
+		}
+		require.Equal(t, tt.expectedNumTxs, mempool.Size(), "mempool had the incorrect size, on test case %d", tcIndex)
+		mempool.Flush()
+	}
+}
+
+func TestMempoolCurrentFilters(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	preCheck, postCheck := mempool.CurrentFilters()
+	assert.Nil(t, preCheck)
+	assert.Nil(t, postCheck)
+
+	wantPreCheck, wantPostCheck := PreCheckMaxBytes(22), PostCheckMaxGas(1)
+	err := mempool.Update(1, []types.Tx{}, abciResponses(0, abci.CodeTypeOK), wantPreCheck, wantPostCheck)
+	require.NoError(t, err)
+
+	preCheck, postCheck = mempool.CurrentFilters()
+	require.NotNil(t, preCheck)
+	require.NotNil(t, postCheck)
+	for _, tx := range []types.Tx{make([]byte, 10), make([]byte, 20), make([]byte, 30)} {
+		assert.Equal(t, wantPreCheck(tx), preCheck(tx))
+		for _, gasWanted := range []int64{-1, 0, 1, 2} {
+			res := &abci.ResponseCheckTx{GasWanted: gasWanted}
+			assert.Equal(t, wantPostCheck(tx, res), postCheck(tx, res))
+		}
+	}
+}
+
 func TestMempoolUpdate(t *testing.T) {
 	app := kvstore.NewApplication()
 	cc := proxy.NewLocalClientCreator(app)
@@ -259,6 +1401,46 @@ func TestTxsAvailable(t *testing.T) {
 	ensureNoFire(t, mempool.TxsAvailable(), timeoutMS)
 }
 
+func TestTxsDrained(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	timeoutMS := 100
+
+	// an empty mempool doesn't fire
+	ensureNoFire(t, mempool.TxsDrained(), timeoutMS)
+	mempool.Flush()
+	ensureNoFire(t, mempool.TxsDrained(), timeoutMS)
+
+	// committing only some of the txs doesn't fire
+	txs := checkTxs(t, mempool, 10, UnknownPeerID)
+	ensureNoFire(t, mempool.TxsDrained(), timeoutMS)
+	err := mempool.Update(1, txs[:5], abciResponses(5, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	ensureNoFire(t, mempool.TxsDrained(), timeoutMS)
+
+	// committing the rest fires once
+	err = mempool.Update(2, txs[5:], abciResponses(5, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	ensureFire(t, mempool.TxsDrained(), timeoutMS)
+	ensureNoFire(t, mempool.TxsDrained(), timeoutMS)
+
+	// removing the last tx fires
+	txs = checkTxs(t, mempool, 2, UnknownPeerID)
+	mempool.RemoveTxByKey(TxKey(txs[0]), true)
+	ensureNoFire(t, mempool.TxsDrained(), timeoutMS)
+	mempool.RemoveTxByKey(TxKey(txs[1]), true)
+	ensureFire(t, mempool.TxsDrained(), timeoutMS)
+
+	// flushing fires
+	checkTxs(t, mempool, 3, UnknownPeerID)
+	mempool.Flush()
+	ensureFire(t, mempool.TxsDrained(), timeoutMS)
+	ensureNoFire(t, mempool.TxsDrained(), timeoutMS)
+}
+
 func TestSerialReap(t *testing.T) {
 	app := counter.NewApplication(true)
 	cc := proxy.NewLocalClientCreator(app)
@@ -396,27 +1578,177 @@ func TestMempoolCloseWAL(t *testing.T) {
 	require.Nil(t, err, "successful globbing expected")
 	require.Equal(t, 1, len(m2), "expecting the wal match in")
 
-	// 5. Write some contents to the WAL
-	err = mempool.CheckTx(types.Tx([]byte("foo")), nil, TxInfo{})
-	require.NoError(t, err)
-	walFilepath := mempool.wal.Path
-	sum1 := checksumFile(walFilepath, t)
+	// 5. Write some contents to the WAL
+	err = mempool.CheckTx(types.Tx([]byte("foo")), nil, TxInfo{})
+	require.NoError(t, err)
+	walFilepath := mempool.wal.Path
+	sum1 := checksumFile(walFilepath, t)
+
+	// 6. Sanity check to ensure that the written TX matches the expectation.
+	require.Equal(t, sum1, checksumIt([]byte("foo\n")), "foo with a newline should be written")
+
+	// 7. Invoke CloseWAL() and ensure it discards the
+	// WAL thus any other write won't go through.
+	mempool.CloseWAL()
+	err = mempool.CheckTx(types.Tx([]byte("bar")), nil, TxInfo{})
+	require.NoError(t, err)
+	sum2 := checksumFile(walFilepath, t)
+	require.Equal(t, sum1, sum2, "expected no change to the WAL after invoking CloseWAL() since it was discarded")
+
+	// 8. Sanity check to ensure that the WAL file still exists
+	m3, err := filepath.Glob(filepath.Join(rootDir, "*"))
+	require.Nil(t, err, "successful globbing expected")
+	require.Equal(t, 1, len(m3), "expecting the wal match in")
+}
+
+func TestMempoolWALSyncEvery(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "mempool-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(rootDir)
+
+	wcfg := cfg.DefaultConfig()
+	wcfg.Mempool.RootDir = rootDir
+	wcfg.Mempool.WALSyncEvery = 5
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithAppAndConfig(cc, wcfg)
+	defer cleanup()
+	require.NoError(t, mempool.InitWAL())
+
+	// write txs up to a sync boundary
+	var want []byte
+	for i := 0; i < 4*wcfg.Mempool.WALSyncEvery; i++ {
+		tx := types.Tx(fmt.Sprintf("tx%02d", i))
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+		want = append(append(want, tx...), newline...)
+	}
+	require.EqualValues(t, 4*wcfg.Mempool.WALSyncEvery, mempool.walTxs)
+
+	// simulate a crash: the WAL is never closed and is read back by a new process
+	walFilepath := mempool.wal.Path
+	got, err := ioutil.ReadFile(walFilepath)
+	require.NoError(t, err)
+	assert.Equal(t, want, got, "all txs up to the last sync should be recoverable")
+
+	// txs written since the last sync are synced on close
+	require.NoError(t, mempool.CheckTx(types.Tx("tail"), nil, TxInfo{}))
+	mempool.CloseWAL()
+	got, err = ioutil.ReadFile(walFilepath)
+	require.NoError(t, err)
+	assert.Equal(t, append(want, "tail\n"...), got)
+}
+
+func TestMempoolVerifyWAL(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "mempool-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(rootDir)
+
+	secret := []byte("secret")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(rootDir, "wal-key"), secret, 0600))
+
+	wcfg := cfg.DefaultConfig()
+	wcfg.Mempool.RootDir = rootDir
+	wcfg.Mempool.WALHMACKeyFile = "wal-key"
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithAppAndConfig(cc, wcfg)
+	defer cleanup()
+	require.NoError(t, mempool.InitWAL())
+
+	// txs containing newlines are not split into several entries
+	var offsets []int64
+	for _, tx := range []types.Tx{types.Tx("foo"), types.Tx("bar\nbaz"), types.Tx("qux")} {
+		offset, err := mempool.wal.Size()
+		require.NoError(t, err)
+		offsets = append(offsets, offset)
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	}
+	require.NoError(t, mempool.VerifyWAL(secret))
+
+	err = mempool.VerifyWAL([]byte("wrong secret"))
+	assert.Equal(t, ErrWALEntriesInvalid{Offsets: offsets}, err)
+
+	// tamper with the second entry
+	walFilepath := mempool.wal.Path
+	mempool.CloseWAL()
+	wal, err := ioutil.ReadFile(walFilepath)
+	require.NoError(t, err)
+	wal[offsets[1]+1]++
+	require.NoError(t, ioutil.WriteFile(walFilepath, wal, 0600))
+
+	err = mempool.VerifyWAL(secret)
+	assert.Equal(t, ErrWALEntriesInvalid{Offsets: offsets[1:2]}, err)
+}
+
+func TestMempoolInitWALInvalidKeyFile(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "mempool-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(rootDir)
+
+	wcfg := cfg.DefaultConfig()
+	wcfg.Mempool.RootDir = rootDir
+	wcfg.Mempool.WALHMACKeyFile = "missing"
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithAppAndConfig(cc, wcfg)
+	defer cleanup()
+	assert.Error(t, mempool.InitWAL())
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(rootDir, "empty"), nil, 0600))
+	wcfg.Mempool.WALHMACKeyFile = "empty"
+	assert.Error(t, mempool.InitWAL())
+}
+
+func TestMempoolInitWALUnwritableDir(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "mempool-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(rootDir)
+
+	// a regular file where the WAL directory or one of its parents should be
+	file := filepath.Join(rootDir, "file")
+	require.NoError(t, ioutil.WriteFile(file, nil, 0600))
+
+	wcfg := cfg.DefaultConfig()
+	wcfg.Mempool.RootDir = rootDir
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithAppAndConfig(cc, wcfg)
+	defer cleanup()
+
+	for _, walPath := range []string{file, filepath.Join(file, "wal")} {
+		wcfg.Mempool.WalPath = walPath
+		err := mempool.InitWAL()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), walPath)
+	}
+
+	// the WAL stays disabled, but the mempool is usable
+	assert.Nil(t, mempool.wal)
+	checkTxs(t, mempool, 2, UnknownPeerID)
+	assert.Equal(t, 2, mempool.Size())
+}
 
-	// 6. Sanity check to ensure that the written TX matches the expectation.
-	require.Equal(t, sum1, checksumIt([]byte("foo\n")), "foo with a newline should be written")
+func TestMempoolWithoutWAL(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	config := cfg.ResetTestRoot("mempool_test")
+	config.Mempool.WalPath = "mempool.wal"
+	mempool, cleanup := newMempoolWithAppAndConfig(cc, config)
+	defer cleanup()
 
-	// 7. Invoke CloseWAL() and ensure it discards the
-	// WAL thus any other write won't go through.
-	mempool.CloseWAL()
-	err = mempool.CheckTx(types.Tx([]byte("bar")), nil, TxInfo{})
+	// InitWAL is never called
+	txs := checkTxs(t, mempool, 10, UnknownPeerID)
+	require.Equal(t, 10, mempool.Size())
+
+	mempool.Lock()
+	err := mempool.Update(1, txs, abciResponses(len(txs), abci.CodeTypeOK), nil, nil)
+	mempool.Unlock()
 	require.NoError(t, err)
-	sum2 := checksumFile(walFilepath, t)
-	require.Equal(t, sum1, sum2, "expected no change to the WAL after invoking CloseWAL() since it was discarded")
+	assert.Zero(t, mempool.Size())
 
-	// 8. Sanity check to ensure that the WAL file still exists
-	m3, err := filepath.Glob(filepath.Join(rootDir, "*"))
-	require.Nil(t, err, "successful globbing expected")
-	require.Equal(t, 1, len(m3), "expecting the wal match in")
+	assert.NotPanics(t, mempool.CloseWAL)
+	_, err = os.Stat(config.Mempool.WalDir())
+	assert.True(t, os.IsNotExist(err), "WAL dir should not be created")
 }
 
 func TestMempool_CheckTxChecksTxSize(t *testing.T) {
@@ -544,6 +1876,674 @@ func TestMempoolTxsBytes(t *testing.T) {
 
 }
 
+func TestMempoolTxKeyCached(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	txs := checkTxs(t, mempool, 5, UnknownPeerID)
+	for e := mempool.txs.Front(); e != nil; e = e.Next() {
+		memTx := e.Value.(*mempoolTx)
+		assert.Equal(t, TxKey(memTx.tx), memTx.key)
+		_, ok := mempool.txsMap.Load(memTx.key)
+		assert.True(t, ok)
+	}
+
+	// the cached key is used to remove the txs
+	err := mempool.Update(1, txs, abciResponses(len(txs), abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	assert.Zero(t, mempool.Size())
+	for _, tx := range txs {
+		_, ok := mempool.txsMap.Load(TxKey(tx))
+		assert.False(t, ok)
+	}
+}
+
+func TestMempoolTxHashes(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	assert.Empty(t, mempool.TxHashes())
+
+	txs := checkTxs(t, mempool, 10, UnknownPeerID)
+	want := make([][TxKeySize]byte, len(txs))
+	for i, tx := range txs {
+		want[i] = TxKey(tx)
+	}
+	assert.Equal(t, want, mempool.TxHashes())
+
+	err := mempool.Update(1, txs[:4], abciResponses(4, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, want[4:], mempool.TxHashes())
+}
+
+func TestMempoolNumTxsBySender(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	assert.Empty(t, mempool.NumTxsBySender())
+
+	for i, sender := range []uint16{1, 1, 1, 2, 2, 3} {
+		err := mempool.CheckTx([]byte{byte(i)}, nil, TxInfo{SenderID: sender})
+		require.NoError(t, err)
+	}
+	// a tx received from another peer counts for both
+	err := mempool.CheckTx([]byte{0x05}, nil, TxInfo{SenderID: 1})
+	assert.Equal(t, ErrTxInCache, err)
+
+	counts := mempool.NumTxsBySender()
+	assert.Equal(t, map[uint16]int{1: 4, 2: 2, 3: 1}, counts)
+
+	// the returned map is a copy
+	counts[1] = 0
+	assert.Equal(t, 4, mempool.NumTxsBySender()[1])
+
+	err = mempool.Update(1, []types.Tx{[]byte{0x00}, []byte{0x05}}, abciResponses(2, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[uint16]int{1: 2, 2: 2}, mempool.NumTxsBySender())
+}
+
+func TestMempoolDiagnostics(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	now := time.Unix(1600000000, 0)
+	mempool.now = func() time.Time { return now }
+
+	txs := types.Txs{{0x01}, {0x02, 0x02}, {0x03, 0x03, 0x03}}
+	for i, tx := range txs {
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{SenderID: uint16(i % 2)}))
+		now = now.Add(time.Second)
+	}
+	// the same tx received from another peer
+	assert.Equal(t, ErrTxInCache, mempool.CheckTx(txs[0], nil, TxInfo{SenderID: 1}))
+
+	diag := mempool.Diagnostics(2)
+	assert.Equal(t, 3, diag.Size)
+	assert.EqualValues(t, 6, diag.TxsBytes)
+	assert.EqualValues(t, 3, diag.TotalGasWanted)
+	assert.Equal(t, map[uint16]int{0: 2, 1: 2}, diag.TxsBySender)
+	assert.False(t, diag.Rechecking)
+	assert.Zero(t, diag.PendingRechecks)
+	assert.Equal(t, []TxDiagnostics{
+		{Key: fmt.Sprintf("%X", TxKey(txs[0])), Size: 1, Age: 3 * time.Second},
+		{Key: fmt.Sprintf("%X", TxKey(txs[1])), Size: 2, Age: 2 * time.Second},
+	}, diag.OldestTxs)
+
+	assert.Len(t, mempool.Diagnostics(10).OldestTxs, 3)
+	assert.Empty(t, mempool.Diagnostics(0).OldestTxs)
+	assert.Empty(t, mempool.Diagnostics(-1).OldestTxs)
+
+	_, err := json.Marshal(diag)
+	assert.NoError(t, err)
+}
+
+func TestMempoolSetAcceptingTxs(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	err := mempool.CheckTx([]byte{0x01}, nil, TxInfo{})
+	require.NoError(t, err)
+
+	mempool.SetAcceptingTxs(false)
+	err = mempool.CheckTx([]byte{0x02}, nil, TxInfo{})
+	assert.Equal(t, ErrMempoolNotAccepting, err)
+	// rejected before the cache is checked
+	err = mempool.CheckTx([]byte{0x01}, nil, TxInfo{})
+	assert.Equal(t, ErrMempoolNotAccepting, err)
+	assert.Equal(t, 1, mempool.Size())
+
+	mempool.SetAcceptingTxs(true)
+	err = mempool.CheckTx([]byte{0x02}, nil, TxInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, mempool.Size())
+}
+
+func TestMempoolSizeLimit(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	config := cfg.ResetTestRoot("mempool_test")
+	config.Mempool.Size = 10
+	mempool, cleanup := newMempoolWithAppAndConfig(cc, config)
+	defer cleanup()
+
+	// Fill the mempool up to its tx count limit with tiny txs.
+	for i := 0; i < config.Mempool.Size; i++ {
+		err := mempool.CheckTx([]byte{byte(i)}, nil, TxInfo{})
+		require.NoError(t, err)
+	}
+	require.Equal(t, config.Mempool.Size, mempool.Size())
+	require.Less(t, mempool.TxsBytes(), config.Mempool.MaxTxsBytes)
+
+	// The count limit is enforced independently of the bytes limit.
+	err := mempool.CheckTx([]byte{0xFF}, nil, TxInfo{})
+	if assert.Error(t, err) {
+		assert.IsType(t, ErrMempoolIsFull{}, err)
+	}
+	assert.Equal(t, config.Mempool.Size, mempool.Size())
+}
+
+func TestMempoolEvictLowestPriority(t *testing.T) {
+	app := &priorityApp{kvstore.NewApplication()}
+	cc := proxy.NewLocalClientCreator(app)
+	config := cfg.ResetTestRoot("mempool_test")
+	config.Mempool.Size = 4
+	config.Mempool.EvictLowestPriority = true
+	mempool, cleanup := newMempoolWithAppAndConfig(cc, config)
+	defer cleanup()
+	evicted := generic.NewCounter("evicted_txs")
+	mempool.metrics.EvictedTxs = evicted
+
+	// the first byte of each tx is its priority
+	txs := types.Txs{{0x03, 0x00}, {0x01, 0x00}, {0x02, 0x00}, {0x01, 0x01}}
+	for _, tx := range txs {
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	}
+	require.Equal(t, config.Mempool.Size, mempool.Size())
+
+	// 1. a higher priority tx evicts the newest tx with the lowest priority
+	require.NoError(t, mempool.CheckTx(types.Tx{0x05, 0x00}, nil, TxInfo{}))
+	assert.Equal(t, types.Txs{{0x03, 0x00}, {0x01, 0x00}, {0x02, 0x00}, {0x05, 0x00}},
+		mempool.ReapMaxTxs(-1))
+	assert.EqualValues(t, 1, evicted.Value())
+
+	// 2. a tx with the lowest priority is rejected
+	require.NoError(t, mempool.CheckTx(types.Tx{0x01, 0x02}, nil, TxInfo{}))
+	assert.Equal(t, config.Mempool.Size, mempool.Size())
+	assert.Len(t, mempool.ReapMaxTxs(-1), config.Mempool.Size)
+	assert.NotContains(t, mempool.ReapMaxTxs(-1), types.Tx{0x01, 0x02})
+	assert.EqualValues(t, 1, evicted.Value())
+
+	// 3. the evicted tx can be resubmitted once there is room again
+	mempool.RemoveTxByKey(TxKey(types.Tx{0x05, 0x00}), true)
+	require.NoError(t, mempool.CheckTx(types.Tx{0x01, 0x01}, nil, TxInfo{}))
+	assert.Equal(t, config.Mempool.Size, mempool.Size())
+
+	// 4. without eviction, a full mempool rejects any tx
+	config.Mempool.EvictLowestPriority = false
+	err := mempool.CheckTx(types.Tx{0x09, 0x00}, nil, TxInfo{})
+	assert.IsType(t, ErrMempoolIsFull{}, err)
+}
+
+func TestMempoolDroppedTxs(t *testing.T) {
+	app := &priorityApp{kvstore.NewApplication()}
+	cc := proxy.NewLocalClientCreator(app)
+	config := cfg.ResetTestRoot("mempool_test")
+	config.Mempool.Size = 2
+	config.Mempool.EvictLowestPriority = true
+	mempool, cleanup := newMempoolWithAppAndConfig(cc, config)
+	defer cleanup()
+
+	// the first byte of each tx is its priority
+	require.NoError(t, mempool.CheckTx(types.Tx{0x03, 0x00}, nil, TxInfo{}))
+	require.NoError(t, mempool.CheckTx(types.Tx{0x01, 0x00}, nil, TxInfo{}))
+	assert.Zero(t, mempool.DroppedTxs())
+
+	// a higher priority tx evicts a tx
+	require.NoError(t, mempool.CheckTx(types.Tx{0x05, 0x00}, nil, TxInfo{}))
+	assert.EqualValues(t, 1, mempool.droppedTxs[dropReasonEvicted])
+	assert.EqualValues(t, 1, mempool.DroppedTxs())
+
+	// a lower priority tx accepted by the app doesn't fit
+	require.NoError(t, mempool.CheckTx(types.Tx{0x01, 0x01}, nil, TxInfo{}))
+	assert.EqualValues(t, 1, mempool.droppedTxs[dropReasonFull])
+	assert.EqualValues(t, 2, mempool.DroppedTxs())
+
+	// a tx invalid when rechecked after an update
+	rejected := types.Tx{0x03, 0x00}
+	rejectTx := func(tx types.Tx, _ *abci.ResponseCheckTx) error {
+		if bytes.Equal(tx, rejected) {
+			return errors.New("rejected")
+		}
+		return nil
+	}
+	err := mempool.Update(1, types.Txs{}, abciResponses(0, abci.CodeTypeOK), nil, rejectTx)
+	require.NoError(t, err)
+	require.NoError(t, mempool.FlushAppConn())
+	assert.EqualValues(t, 1, mempool.droppedTxs[dropReasonRecheck])
+	assert.EqualValues(t, 3, mempool.DroppedTxs())
+	assert.Equal(t, types.Txs{{0x05, 0x00}}, mempool.ReapMaxTxs(-1))
+
+	// and when rechecked individually
+	require.NoError(t, mempool.CheckTx(types.Tx{0x03, 0x01}, nil, TxInfo{}))
+	rejected = types.Tx{0x03, 0x01}
+	require.NoError(t, mempool.RecheckTx(TxKey(types.Tx{0x03, 0x01})))
+	assert.EqualValues(t, 2, mempool.droppedTxs[dropReasonRecheck])
+	assert.EqualValues(t, 4, mempool.DroppedTxs())
+
+	// committed txs are not dropped
+	err = mempool.Update(2, types.Txs{{0x05, 0x00}}, abciResponses(1, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	assert.Zero(t, mempool.Size())
+	assert.EqualValues(t, 4, mempool.DroppedTxs())
+}
+
+func TestMempoolTxLifetimeMetric(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	txLifetime := generic.NewHistogram("tx_lifetime", 10)
+	mempool.metrics.TxLifetime = txLifetime
+	now := time.Unix(1600000000, 0)
+	mempool.now = func() time.Time { return now }
+
+	tx := types.Tx{0x01}
+	require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+
+	now = now.Add(5 * time.Second)
+	err := mempool.Update(1, []types.Tx{tx}, abciResponses(1, abci.CodeTypeOK), nil, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, txLifetime.Quantile(0.5))
+}
+
+func TestMempoolGossipDelay(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	config := cfg.ResetTestRoot("mempool_test")
+	const gossipDelay = 100 * time.Millisecond
+	config.Mempool.GossipDelay = gossipDelay
+	mempool, cleanup := newMempoolWithAppAndConfig(cc, config)
+	defer cleanup()
+
+	now := time.Unix(1600000000, 0)
+	mempool.now = func() time.Time { return now }
+
+	_, ok := mempool.GossipEligibleAt(TxKey([]byte{0x01}))
+	assert.False(t, ok)
+
+	txs := checkTxs(t, mempool, 100, UnknownPeerID)
+	for _, tx := range txs {
+		eligibleAt, ok := mempool.GossipEligibleAt(TxKey(tx))
+		require.True(t, ok)
+		assert.False(t, eligibleAt.Before(now), "eligible at %v, before insertion at %v", eligibleAt, now)
+		assert.True(t, eligibleAt.Before(now.Add(gossipDelay)), "eligible at %v, after the gossip delay", eligibleAt)
+	}
+
+	// without a delay, txs may be gossiped right away
+	config.Mempool.GossipDelay = 0
+	tx := types.Tx{0x01}
+	require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	eligibleAt, ok := mempool.GossipEligibleAt(TxKey(tx))
+	require.True(t, ok)
+	assert.Equal(t, now, eligibleAt)
+}
+
+func TestMempoolUpdateHeightGap(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	config := cfg.ResetTestRoot("mempool_test")
+	config.Mempool.MaxHeightGap = 10
+	mempool, cleanup := newMempoolWithAppAndConfig(cc, config)
+	defer cleanup()
+
+	txs := checkTxs(t, mempool, 5, UnknownPeerID)
+
+	// a gap within the limit keeps the txs left
+	mempool.Lock()
+	err := mempool.Update(10, txs[:1], abciResponses(1, abci.CodeTypeOK), nil, nil)
+	mempool.Unlock()
+	require.NoError(t, err)
+	assert.Equal(t, 4, mempool.Size())
+
+	// a larger gap drops them, but they can be submitted again
+	mempool.Lock()
+	err = mempool.Update(21, txs[1:2], abciResponses(1, abci.CodeTypeOK), nil, nil)
+	mempool.Unlock()
+	require.NoError(t, err)
+	assert.Zero(t, mempool.Size())
+	assert.Zero(t, mempool.TxsBytes())
+	assert.Equal(t, ErrTxInCache, mempool.CheckTx(txs[1], nil, TxInfo{}))
+	require.NoError(t, mempool.CheckTx(txs[2], nil, TxInfo{}))
+	assert.Equal(t, 1, mempool.Size())
+
+	// 0 disables it
+	config.Mempool.MaxHeightGap = 0
+	mempool.Lock()
+	err = mempool.Update(1000, nil, nil, nil, nil)
+	mempool.Unlock()
+	require.NoError(t, err)
+	assert.Equal(t, 1, mempool.Size())
+}
+
+func TestMempoolRecheckTx(t *testing.T) {
+	app := counter.NewApplication(true)
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	err := mempool.RecheckTx(TxKey([]byte{0x01}))
+	assert.Equal(t, ErrTxNotFound, err)
+
+	tx0 := make([]byte, 8)
+	binary.BigEndian.PutUint64(tx0, uint64(0))
+	tx1 := make([]byte, 8)
+	binary.BigEndian.PutUint64(tx1, uint64(1))
+	require.NoError(t, mempool.CheckTx(tx0, nil, TxInfo{}))
+	require.NoError(t, mempool.CheckTx(tx1, nil, TxInfo{}))
+
+	// still valid, nothing changes
+	require.NoError(t, mempool.RecheckTx(TxKey(tx0)))
+	require.Equal(t, 2, mempool.Size())
+
+	// deliver tx0 out-of-band so that it becomes invalid
+	appConnCon, _ := cc.NewABCIClient()
+	appConnCon.SetLogger(log.TestingLogger().With("module", "abci-client", "connection", "consensus"))
+	require.NoError(t, appConnCon.Start())
+	t.Cleanup(func() {
+		if err := appConnCon.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	res, err := appConnCon.DeliverTxSync(context.Background(), abci.RequestDeliverTx{Tx: tx0})
+	require.NoError(t, err)
+	require.EqualValues(t, abci.CodeTypeOK, res.Code)
+
+	require.NoError(t, mempool.RecheckTx(TxKey(tx0)))
+	assert.Equal(t, 1, mempool.Size())
+	assert.Equal(t, ErrTxNotFound, mempool.RecheckTx(TxKey(tx0)))
+
+	// tx1 is unaffected
+	require.NoError(t, mempool.RecheckTx(TxKey(tx1)))
+	assert.Equal(t, 1, mempool.Size())
+	assert.EqualValues(t, types.Txs{tx1}, mempool.ReapMaxTxs(-1))
+}
+
+// slowRecheckApp is a kvstore whose rechecks block until released.
+type slowRecheckApp struct {
+	*kvstore.Application
+	release chan struct{}
+}
+
+func (app *slowRecheckApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
+	if req.Type == abci.CheckTxType_Recheck {
+		<-app.release
+	}
+	return app.Application.CheckTx(req)
+}
+
+func TestMempoolRecheckStatus(t *testing.T) {
+	app := &slowRecheckApp{kvstore.NewApplication(), make(chan struct{})}
+	cc := proxy.NewLocalClientCreator(app)
+	mempool, cleanup := newMempoolWithApp(cc)
+	defer cleanup()
+
+	txs := checkTxs(t, mempool, 3, UnknownPeerID)
+	for _, tx := range txs {
+		rechecked, present := mempool.RecheckStatus(TxKey(tx))
+		assert.True(t, rechecked)
+		assert.True(t, present)
+	}
+	_, present := mempool.RecheckStatus(TxKey([]byte("unknown")))
+	assert.False(t, present)
+
+	// committing the first tx rechecks the other two
+	updated := make(chan error, 1)
+	go func() {
+		mempool.Lock()
+		defer mempool.Unlock()
+		updated <- mempool.Update(1, txs[:1], abciResponses(1, abci.CodeTypeOK), nil, nil)
+	}()
+
+	recheckStatus := func(tx types.Tx) bool {
+		rechecked, present := mempool.RecheckStatus(TxKey(tx))
+		require.True(t, present)
+		return rechecked
+	}
+	require.Eventually(t, func() bool { return !recheckStatus(txs[1]) }, time.Second, 10*time.Millisecond)
+	assert.False(t, recheckStatus(txs[2]))
+	_, present = mempool.RecheckStatus(TxKey(txs[0]))
+	assert.False(t, present)
+
+	app.release <- struct{}{}
+	require.Eventually(t, func() bool { return recheckStatus(txs[1]) }, time.Second, 10*time.Millisecond)
+	assert.False(t, recheckStatus(txs[2]))
+
+	app.release <- struct{}{}
+	require.NoError(t, <-updated)
+	assert.True(t, recheckStatus(txs[1]))
+	assert.True(t, recheckStatus(txs[2]))
+}
+
+// countingApp is a kvstore application that counts the calls to CheckTx.
+type countingApp struct {
+	*kvstore.Application
+	checkTxCalls int
+}
+
+func (app *countingApp) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
+	app.checkTxCalls++
+	return app.Application.CheckTx(req)
+}
+
+func TestMempoolResultCache(t *testing.T) {
+	app := &countingApp{Application: kvstore.NewApplication()}
+	cc := proxy.NewLocalClientCreator(app)
+	config := cfg.ResetTestRoot("mempool_test")
+	config.Mempool.ResultCacheSize = 10
+	config.Mempool.ResultCacheTTL = time.Minute
+	mempool, cleanup := newMempoolWithAppAndConfig(cc, config)
+	defer cleanup()
+
+	now := time.Now()
+	mempool.now = func() time.Time { return now }
+
+	tx := types.Tx{0x01}
+	require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	require.Equal(t, 1, app.checkTxCalls)
+	require.Equal(t, 1, mempool.Size())
+
+	// 1. within the TTL the cached result is reused
+	mempool.Flush()
+	now = now.Add(30 * time.Second)
+	var res *abci.Response
+	require.NoError(t, mempool.CheckTx(tx, func(r *abci.Response) { res = r }, TxInfo{}))
+	assert.Equal(t, 1, app.checkTxCalls)
+	assert.Equal(t, 1, mempool.Size())
+	if assert.NotNil(t, res) {
+		assert.EqualValues(t, abci.CodeTypeOK, res.GetCheckTx().Code)
+	}
+
+	// 2. after the TTL the app is asked again
+	mempool.Flush()
+	now = now.Add(time.Minute)
+	require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	assert.Equal(t, 2, app.checkTxCalls)
+	assert.Equal(t, 1, mempool.Size())
+}
+
+// asyncAppConn is a mempool connection that, like a socket client, processes
+// the requests in order in the background and returns before the response
+// arrives.
+type asyncAppConn struct {
+	app  abci.Application
+	reqs chan *abcicli.ReqRes
+
+	mtx tmsync.Mutex
+	cb  abcicli.Callback
+}
+
+var _ proxy.AppConnMempool = (*asyncAppConn)(nil)
+
+func newAsyncAppConn(app abci.Application) *asyncAppConn {
+	conn := &asyncAppConn{app: app, reqs: make(chan *abcicli.ReqRes, 100)}
+	go conn.run()
+	return conn
+}
+
+func (conn *asyncAppConn) run() {
+	for reqRes := range conn.reqs {
+		var res *abci.Response
+		switch req := reqRes.Request.Value.(type) {
+		case *abci.Request_CheckTx:
+			res = abci.ToResponseCheckTx(conn.app.CheckTx(*req.CheckTx))
+		case *abci.Request_Flush:
+			res = abci.ToResponseFlush()
+		}
+		reqRes.Response = res
+
+		conn.mtx.Lock()
+		cb := conn.cb
+		conn.mtx.Unlock()
+		if cb != nil {
+			cb(reqRes.Request, res)
+		}
+		reqRes.SetDone()
+		if cb := reqRes.GetCallback(); cb != nil {
+			cb(res)
+		}
+		// release the waiters only after the response was handled
+		reqRes.Done()
+	}
+}
+
+func (conn *asyncAppConn) stop() { close(conn.reqs) }
+
+func (conn *asyncAppConn) SetResponseCallback(cb abcicli.Callback) {
+	conn.mtx.Lock()
+	defer conn.mtx.Unlock()
+	conn.cb = cb
+}
+
+func (conn *asyncAppConn) Error() error { return nil }
+
+func (conn *asyncAppConn) CheckTxAsync(_ context.Context, req abci.RequestCheckTx) (*abcicli.ReqRes, error) {
+	reqRes := abcicli.NewReqRes(abci.ToRequestCheckTx(req))
+	conn.reqs <- reqRes
+	return reqRes, nil
+}
+
+func (conn *asyncAppConn) CheckTxSync(ctx context.Context, req abci.RequestCheckTx) (*abci.ResponseCheckTx, error) {
+	reqRes, _ := conn.CheckTxAsync(ctx, req)
+	reqRes.Wait()
+	return reqRes.Response.GetCheckTx(), nil
+}
+
+func (conn *asyncAppConn) FlushAsync(context.Context) (*abcicli.ReqRes, error) {
+	reqRes := abcicli.NewReqRes(abci.ToRequestFlush())
+	conn.reqs <- reqRes
+	return reqRes, nil
+}
+
+func (conn *asyncAppConn) FlushSync(ctx context.Context) error {
+	reqRes, _ := conn.FlushAsync(ctx)
+	reqRes.Wait()
+	return nil
+}
+
+// newMempoolWithAsyncApp returns a mempool connected to app through an
+// asyncAppConn, on which the rechecks keep running after Update returns.
+func newMempoolWithAsyncApp(app abci.Application, config *cfg.Config) (*CListMempool, cleanupFunc) {
+	conn := newAsyncAppConn(app)
+	mempool := NewCListMempool(config.Mempool, conn, 0)
+	mempool.SetLogger(log.TestingLogger())
+	return mempool, func() {
+		conn.stop()
+		os.RemoveAll(config.RootDir)
+	}
+}
+
+func TestMempoolResultCacheDuringRecheck(t *testing.T) {
+	app := &slowRecheckApp{kvstore.NewApplication(), make(chan struct{})}
+	config := cfg.ResetTestRoot("mempool_test")
+	config.Mempool.CacheSize = 0
+	config.Mempool.ResultCacheSize = 10
+	config.Mempool.ResultCacheTTL = time.Minute
+	mempool, cleanup := newMempoolWithAsyncApp(app, config)
+	defer cleanup()
+
+	txs := types.Txs{{0x01}, {0x02}, {0x03}}
+	for _, tx := range txs {
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	}
+	require.NoError(t, mempool.FlushAppConn())
+	require.Equal(t, 3, mempool.Size())
+
+	// committing the first tx starts a recheck of the others, which is still
+	// running after Update returns
+	mempool.Lock()
+	err := mempool.Update(1, txs[:1], abciResponses(1, abci.CodeTypeOK), nil, nil)
+	mempool.Unlock()
+	require.NoError(t, err)
+
+	// the cached result of the committed tx isn't used during the recheck,
+	// the tx is checked by the app after the rechecked txs
+	require.NoError(t, mempool.CheckTx(txs[0], nil, TxInfo{}))
+	assert.Equal(t, 2, mempool.Size())
+
+	app.release <- struct{}{}
+	app.release <- struct{}{}
+	require.NoError(t, mempool.FlushAppConn())
+	assert.Equal(t, 3, mempool.Size())
+}
+
+func TestMempoolRecheckTxDuringRecheck(t *testing.T) {
+	app := &slowRecheckApp{kvstore.NewApplication(), make(chan struct{})}
+	mempool, cleanup := newMempoolWithAsyncApp(app, cfg.ResetTestRoot("mempool_test"))
+	defer cleanup()
+
+	txs := types.Txs{{0x01}, {0x02}, {0x03}}
+	for _, tx := range txs {
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	}
+	require.NoError(t, mempool.FlushAppConn())
+
+	mempool.Lock()
+	err := mempool.Update(1, txs[:1], abciResponses(1, abci.CodeTypeOK), nil, nil)
+	mempool.Unlock()
+	require.NoError(t, err)
+
+	assert.Equal(t, ErrRecheckInProgress, mempool.RecheckTx(TxKey(txs[1])))
+
+	app.release <- struct{}{}
+	app.release <- struct{}{}
+	require.NoError(t, mempool.FlushAppConn())
+	go func() { app.release <- struct{}{} }()
+	require.NoError(t, mempool.RecheckTx(TxKey(txs[1])))
+	assert.Equal(t, 2, mempool.Size())
+}
+
+func TestMempoolRemoveDuringRecheck(t *testing.T) {
+	app := &slowRecheckApp{kvstore.NewApplication(), make(chan struct{})}
+	mempool, cleanup := newMempoolWithAsyncApp(app, cfg.ResetTestRoot("mempool_test"))
+	defer cleanup()
+
+	txs := types.Txs{{0x01}, {0x02}, {0x03}}
+	for _, tx := range txs {
+		require.NoError(t, mempool.CheckTx(tx, nil, TxInfo{}))
+	}
+	require.NoError(t, mempool.FlushAppConn())
+
+	// all the rechecked txs become invalid
+	invalid := func(types.Tx, *abci.ResponseCheckTx) error { return errors.New("invalid") }
+	mempool.Lock()
+	err := mempool.Update(1, txs[:1], abciResponses(1, abci.CodeTypeOK), nil, invalid)
+	mempool.Unlock()
+	require.NoError(t, err)
+
+	// a tx removed while its recheck is pending is only removed once
+	removed := mempool.RemoveMatching(func(tx types.Tx) bool { return bytes.Equal(tx, txs[1]) })
+	require.Equal(t, 1, removed)
+	assert.Equal(t, 1, mempool.Size())
+
+	app.release <- struct{}{}
+	app.release <- struct{}{}
+	require.NoError(t, mempool.FlushAppConn())
+	assert.Zero(t, mempool.Size())
+	assert.Zero(t, mempool.TxsBytes())
+	assert.EqualValues(t, 1, mempool.DroppedTxs())
+}
+
 func checksumIt(data []byte) string {
 	h := sha256.New()
 	h.Write(data) //nolint: errcheck // ignore errcheck
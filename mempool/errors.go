@@ -8,6 +8,27 @@ import (
 var (
 	// ErrTxInCache is returned to the client if we saw tx earlier
 	ErrTxInCache = errors.New("tx already exists in cache")
+
+	// ErrTxNotFound is returned if a tx is expected to be in the mempool but
+	// isn't
+	ErrTxNotFound = errors.New("tx not found in mempool")
+
+	// ErrMempoolNotAccepting is returned if a tx is submitted while the
+	// mempool is not accepting txs, e.g. during fast sync
+	ErrMempoolNotAccepting = errors.New("mempool is not accepting txs")
+
+	// ErrCheckTxTimeout is returned if the app doesn't respond to CheckTx
+	// within the configured timeout
+	ErrCheckTxTimeout = errors.New("timed out waiting for the app to check the tx")
+
+	// ErrReservationExceeded is returned if a tx checked within a reservation
+	// doesn't fit into what is left of the reservation, or the reservation was
+	// released
+	ErrReservationExceeded = errors.New("tx exceeds the mempool reservation")
+
+	// ErrRecheckInProgress is returned if a tx can't be rechecked, because the
+	// txs are still being rechecked after the last Update
+	ErrRecheckInProgress = errors.New("mempool recheck in progress")
 )
 
 // ErrTxTooLarge means the tx is too big to be sent in a message to other peers
@@ -36,6 +57,17 @@ func (e ErrMempoolIsFull) Error() string {
 		e.txsBytes, e.maxTxsBytes)
 }
 
+// ErrWALEntriesInvalid means some entries of the mempool WAL failed the HMAC
+// verification of VerifyWAL, e.g. because the WAL was tampered with
+type ErrWALEntriesInvalid struct {
+	// Offsets are the byte offsets of the invalid entries in the WAL file
+	Offsets []int64
+}
+
+func (e ErrWALEntriesInvalid) Error() string {
+	return fmt.Sprintf("%d mempool WAL entries failed verification, at offsets: %v", len(e.Offsets), e.Offsets)
+}
+
 // ErrPreCheck is returned when tx is too big
 type ErrPreCheck struct {
 	Reason error
@@ -7,6 +7,7 @@ import (
 	abci "github.com/lazyledger/lazyledger-core/abci/types"
 	"github.com/lazyledger/lazyledger-core/p2p"
 	"github.com/lazyledger/lazyledger-core/types"
+	"github.com/lazyledger/nmt/namespace"
 )
 
 // Mempool defines the mempool interface.
@@ -23,6 +24,7 @@ type Mempool interface {
 	// maxGas.
 	// If both maxes are negative, there is no cap on the size of all returned
 	// transactions (~ all available transactions).
+	// Transactions with a TxInfo.SenderAddress are reaped in nonce order.
 	ReapMaxBytesMaxGas(maxBytes, maxGas int64) types.Txs
 
 	// ReapMaxTxs reaps up to max transactions from the mempool.
@@ -91,6 +93,40 @@ type PreCheckFunc func(types.Tx) error
 // transaction doesn't require more gas than available for the block.
 type PostCheckFunc func(types.Tx, *abci.ResponseCheckTx) error
 
+// NamespaceExtractor is an optional function that returns the namespace of a
+// transaction, used to reap the transactions of a given namespace.
+type NamespaceExtractor func(types.Tx) (namespace.ID, error)
+
+// OrderingValidator is an optional function that returns true if next may
+// follow prev in a block, e.g. for apps requiring strictly increasing txs.
+type OrderingValidator func(prev, next types.Tx) bool
+
+// InclusionRecorder is an optional, durable record of the txs committed at
+// each height, e.g. for auditing.
+type InclusionRecorder interface {
+	// RecordInclusion is called by Update with the keys of the txs that were
+	// removed from the mempool as committed at the given height.
+	RecordInclusion(height int64, txKeys [][TxKeySize]byte)
+}
+
+// Reservation is mempool capacity reserved by Reserve for a burst of txs.
+// The txs checked with the reservation's CheckTx aren't rejected because the
+// mempool is full, as long as they fit into the reservation.
+type Reservation interface {
+	// CheckTx is like Mempool.CheckTx, except that the tx is accounted against
+	// the reservation instead of the free capacity of the mempool. It returns
+	// ErrReservationExceeded if the tx doesn't fit into what is left of the
+	// reservation.
+	CheckTx(tx types.Tx, callback func(*abci.Response), txInfo TxInfo) error
+
+	// Remaining returns the number of bytes and txs left in the reservation.
+	Remaining() (bytes int64, count int)
+
+	// Release returns what is left of the reservation to the mempool. The
+	// reservation can't be used afterwards.
+	Release()
+}
+
 // TxInfo are parameters that get passed when attempting to add a tx to the
 // mempool.
 type TxInfo struct {
@@ -101,6 +137,22 @@ type TxInfo struct {
 	SenderP2PID p2p.ID
 	// Context is the optional context to cancel CheckTx
 	Context context.Context
+	// Metadata is optional, opaque data the caller wants to associate with the
+	// tx while it is in the mempool, e.g. its decoded sender or fee.
+	Metadata interface{}
+	// SenderAddress is the optional address of the account that signed the tx.
+	// If set, txs of the same sender are reaped in the order of their Nonce.
+	SenderAddress []byte
+	// Nonce is the sequence number of the tx for SenderAddress.
+	Nonce uint64
+
+	// sidecar is the data attached to the tx by CheckTxWithSidecar.
+	sidecar []byte
+	// reservation is the reservation the tx is checked within, if any.
+	reservation *clistReservation
+	// skipCache makes CheckTx check the tx even if it is in the cache, as long
+	// as it isn't in the mempool.
+	skipCache bool
 }
 
 //--------------------------------------------------------------------------------
@@ -118,6 +170,47 @@ func PreCheckMaxBytes(maxBytes int64) PreCheckFunc {
 	}
 }
 
+// PreCheckMaxShares checks that the number of shares the length-delimited
+// transaction occupies, when split into shares of shareSize bytes, is smaller
+// or equal to the expected maxShares. It panics if shareSize is not positive.
+func PreCheckMaxShares(maxShares int, shareSize int) PreCheckFunc {
+	if shareSize <= 0 {
+		panic(fmt.Sprintf("share size must be positive, got %d", shareSize))
+	}
+	return func(tx types.Tx) error {
+		rawTx, err := tx.MarshalDelimited()
+		if err != nil {
+			return err
+		}
+		numShares := (len(rawTx) + shareSize - 1) / shareSize
+
+		if numShares > maxShares {
+			return fmt.Errorf("tx occupies too many shares: %d, max: %d",
+				numShares, maxShares)
+		}
+		return nil
+	}
+}
+
+// PreCheckNamespacePrefix checks that the first nsSize bytes of the
+// transaction are one of the given namespaces.
+func PreCheckNamespacePrefix(validNamespaces []namespace.ID, nsSize int) PreCheckFunc {
+	valid := make(map[string]struct{}, len(validNamespaces))
+	for _, nid := range validNamespaces {
+		valid[string(nid)] = struct{}{}
+	}
+	return func(tx types.Tx) error {
+		if len(tx) < nsSize {
+			return fmt.Errorf("tx is too short to contain a namespace: %d bytes, min: %d",
+				len(tx), nsSize)
+		}
+		if _, ok := valid[string(tx[:nsSize])]; !ok {
+			return fmt.Errorf("tx namespace %X is not allowed", tx[:nsSize])
+		}
+		return nil
+	}
+}
+
 // PostCheckMaxGas checks that the wanted gas is smaller or equal to the passed
 // maxGas. Returns nil if maxGas is -1.
 func PostCheckMaxGas(maxGas int64) PostCheckFunc {
@@ -136,3 +229,19 @@ func PostCheckMaxGas(maxGas int64) PostCheckFunc {
 		return nil
 	}
 }
+
+// GroupByNamespace buckets the given txs by their namespace, i.e. their first
+// nsSize bytes, keeping the order of the txs within each bucket, e.g. to
+// arrange reaped txs into the data square. Txs too short to contain a
+// namespace are left out.
+func GroupByNamespace(txs types.Txs, nsSize int) map[string]types.Txs {
+	groups := make(map[string]types.Txs)
+	for _, tx := range txs {
+		if len(tx) < nsSize {
+			continue
+		}
+		nid := string(tx[:nsSize])
+		groups[nid] = append(groups[nid], tx)
+	}
+	return groups
+}
@@ -22,8 +22,13 @@ type Metrics struct {
 	TxSizeBytes metrics.Histogram
 	// Number of failed transactions.
 	FailedTxs metrics.Counter
+	// Number of transactions evicted to make room for higher priority ones.
+	EvictedTxs metrics.Counter
 	// Number of times transactions are rechecked in the mempool.
 	RecheckTimes metrics.Counter
+	// Histogram of the time transactions spent in the mempool before being
+	// committed, in seconds.
+	TxLifetime metrics.Histogram
 }
 
 // PrometheusMetrics returns Metrics build using Prometheus client library.
@@ -54,12 +59,25 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 			Name:      "failed_txs",
 			Help:      "Number of failed transactions.",
 		}, labels).With(labelsAndValues...),
+		EvictedTxs: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "evicted_txs",
+			Help:      "Number of transactions evicted to make room for higher priority ones.",
+		}, labels).With(labelsAndValues...),
 		RecheckTimes: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
 			Namespace: namespace,
 			Subsystem: MetricsSubsystem,
 			Name:      "recheck_times",
 			Help:      "Number of times transactions are rechecked in the mempool.",
 		}, labels).With(labelsAndValues...),
+		TxLifetime: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "tx_lifetime_seconds",
+			Help:      "Time transactions spent in the mempool before being committed, in seconds.",
+			Buckets:   stdprometheus.ExponentialBuckets(0.1, 2, 14),
+		}, labels).With(labelsAndValues...),
 	}
 }
 
@@ -69,6 +87,8 @@ func NopMetrics() *Metrics {
 		Size:         discard.NewGauge(),
 		TxSizeBytes:  discard.NewHistogram(),
 		FailedTxs:    discard.NewCounter(),
+		EvictedTxs:   discard.NewCounter(),
 		RecheckTimes: discard.NewCounter(),
+		TxLifetime:   discard.NewHistogram(),
 	}
 }
@@ -234,7 +234,10 @@ func (memR *Reactor) broadcastTxRoutine(peer p2p.Peer) {
 			continue
 		}
 
-		txs := memR.txs(next, peerID, peerState.GetHeight()) // WARNING: mutates next!
+		// Txs that may not be gossiped yet, see GossipDelay, are skipped by
+		// txs, so a delayed tx doesn't hold up the ones after it.
+		now := memR.mempool.now()
+		txs := memR.txs(next, peerID, peerState.GetHeight(), now) // WARNING: mutates next!
 
 		// send txs
 		if len(txs) > 0 {
@@ -255,6 +258,19 @@ func (memR *Reactor) broadcastTxRoutine(peer p2p.Peer) {
 			}
 		}
 
+		// Don't move past next before it may be gossiped, otherwise it would
+		// never be sent to this peer.
+		if wait := memTx.gossipEligibleAt.Sub(now); wait > 0 {
+			select {
+			case <-time.After(wait):
+				continue
+			case <-peer.Quit():
+				return
+			case <-memR.Quit():
+				return
+			}
+		}
+
 		select {
 		case <-next.NextWaitChan():
 			// see the start of the for loop for nil check
@@ -268,15 +284,15 @@ func (memR *Reactor) broadcastTxRoutine(peer p2p.Peer) {
 }
 
 // txs iterates over the transaction list and builds a batch of txs. next is
-// included.
+// included. Txs that may not be gossiped at now are skipped.
 // WARNING: mutates next!
-func (memR *Reactor) txs(next *clist.CElement, peerID uint16, peerHeight int64) [][]byte {
+func (memR *Reactor) txs(next *clist.CElement, peerID uint16, peerHeight int64, now time.Time) [][]byte {
 	batch := make([][]byte, 0)
 
 	for {
 		memTx := next.Value.(*mempoolTx)
 
-		if _, ok := memTx.senders.Load(peerID); !ok {
+		if _, ok := memTx.senders.Load(peerID); !ok && !memTx.gossipEligibleAt.After(now) {
 			// If current batch + this tx size is greater than max => return.
 			batchMsg := protomem.Message{
 				Sum: &protomem.Message_Txs{
@@ -16,6 +16,7 @@ import (
 	"github.com/lazyledger/lazyledger-core/abci/example/kvstore"
 	abci "github.com/lazyledger/lazyledger-core/abci/types"
 	cfg "github.com/lazyledger/lazyledger-core/config"
+	"github.com/lazyledger/lazyledger-core/libs/clist"
 	"github.com/lazyledger/lazyledger-core/libs/log"
 	tmrand "github.com/lazyledger/lazyledger-core/libs/rand"
 	"github.com/lazyledger/lazyledger-core/p2p"
@@ -189,6 +190,34 @@ func TestReactor_MaxBatchBytes(t *testing.T) {
 	assert.Equal(t, 1, out+in)
 }
 
+func TestReactorTxsGossipDelay(t *testing.T) {
+	app := kvstore.NewApplication()
+	cc := proxy.NewLocalClientCreator(app)
+	config := cfg.ResetTestRoot("mempool_test")
+	config.Mempool.GossipDelay = time.Minute
+	mempool, cleanup := newMempoolWithAppAndConfig(cc, config)
+	defer cleanup()
+
+	now := time.Unix(1600000000, 0)
+	mempool.now = func() time.Time { return now }
+	reactor := NewReactor(config.Mempool, mempool)
+
+	// a delayed tx doesn't hold up the txs after it, even if they were added
+	// later
+	txs := checkTxs(t, mempool, 3, UnknownPeerID)
+	delays := []time.Duration{10 * time.Second, 0, time.Second}
+	for i, tx := range txs {
+		e, ok := mempool.txsMap.Load(TxKey(tx))
+		require.True(t, ok)
+		e.(*clist.CElement).Value.(*mempoolTx).gossipEligibleAt = now.Add(delays[i])
+	}
+
+	const peerID = 1
+	assert.Equal(t, [][]byte{txs[1]}, reactor.txs(mempool.TxsFront(), peerID, 0, now))
+	assert.Equal(t, [][]byte{txs[1], txs[2]}, reactor.txs(mempool.TxsFront(), peerID, 0, now.Add(time.Second)))
+	assert.Equal(t, [][]byte{txs[0], txs[1], txs[2]}, reactor.txs(mempool.TxsFront(), peerID, 0, now.Add(10*time.Second)))
+}
+
 func TestBroadcastTxForPeerStopsWhenPeerStops(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping test in short mode.")
@@ -0,0 +1,138 @@
+package mempool
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/lazyledger/lazyledger-core/types"
+)
+
+// loadWALSecret reads the secret used to sign the WAL entries from the file
+// configured with MempoolConfig.WALHMACKeyFile. It returns nil if signing is
+// disabled.
+func (mem *CListMempool) loadWALSecret() ([]byte, error) {
+	if mem.config.WALHMACKeyFile == "" {
+		return nil, nil
+	}
+	keyFile := mem.config.WALHMACKeyFilePath()
+	secret, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("can't read WAL HMAC key file %s: %w", keyFile, err)
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("WAL HMAC key file %s is empty", keyFile)
+	}
+	return secret, nil
+}
+
+// checkWritableDir returns an error if dir isn't a directory that files can
+// be created in, by creating and removing a temporary file in it.
+func checkWritableDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("can't access mempool WAL directory %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("mempool WAL directory %s is not a directory", dir)
+	}
+	f, err := ioutil.TempFile(dir, "wal-check-")
+	if err != nil {
+		return fmt.Errorf("mempool WAL directory %s is not writable: %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// walEntry returns the WAL entry for the given tx. Unsigned entries are the tx
+// followed by a newline. Signed entries are the hex encoded tx and its HMAC,
+// separated by a space and followed by a newline, so they can be told apart
+// even if the tx contains newlines.
+func walEntry(tx types.Tx, secret []byte) []byte {
+	if secret == nil {
+		return append([]byte(tx), newline...)
+	}
+	mac := walEntryMAC(tx, secret)
+	entry := make([]byte, 0, hex.EncodedLen(len(tx))+1+hex.EncodedLen(len(mac))+len(newline))
+	entry = append(entry, hex.EncodeToString(tx)...)
+	entry = append(entry, ' ')
+	entry = append(entry, hex.EncodeToString(mac)...)
+	return append(entry, newline...)
+}
+
+func walEntryMAC(tx types.Tx, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(tx)
+	return mac.Sum(nil)
+}
+
+// verifyWALEntry returns true if the given signed WAL entry, including its
+// trailing newline, has a valid HMAC for the given secret.
+func verifyWALEntry(entry, secret []byte) bool {
+	if !bytes.HasSuffix(entry, newline) {
+		return false
+	}
+	parts := bytes.Split(bytes.TrimSuffix(entry, newline), []byte{' '})
+	if len(parts) != 2 {
+		return false
+	}
+	tx, err := hex.DecodeString(string(parts[0]))
+	if err != nil {
+		return false
+	}
+	mac, err := hex.DecodeString(string(parts[1]))
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(mac, walEntryMAC(tx, secret))
+}
+
+// VerifyWAL checks that every entry of the WAL is signed with the given
+// secret, see MempoolConfig.WALHMACKeyFile. If some entries fail the
+// verification, it returns an ErrWALEntriesInvalid with their offsets in the
+// WAL file.
+func (mem *CListMempool) VerifyWAL(secret []byte) error {
+	if mem.wal != nil {
+		// make sure all the written entries are read back
+		if err := mem.wal.Sync(); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(mem.config.WalDir() + "/wal")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var (
+		r       = bufio.NewReader(f)
+		offset  int64
+		invalid []int64
+	)
+	for {
+		entry, err := r.ReadBytes('\n')
+		if len(entry) > 0 {
+			if !verifyWALEntry(entry, secret) {
+				invalid = append(invalid, offset)
+			}
+			offset += int64(len(entry))
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if len(invalid) > 0 {
+		return ErrWALEntriesInvalid{Offsets: invalid}
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ require (
 	github.com/ipfs/go-ipfs v0.8.0
 	github.com/ipfs/go-ipfs-api v0.2.0
 	github.com/ipfs/go-ipld-format v0.2.0
+	github.com/ipfs/go-log/v2 v2.1.1
 	github.com/ipfs/go-verifcid v0.0.1
 	github.com/lazyledger/nmt v0.2.0
 	// rsmt2d is only used in tests:
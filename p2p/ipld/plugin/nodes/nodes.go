@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	blocks "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-cid"
@@ -15,6 +16,7 @@ import (
 	"github.com/ipfs/go-ipfs/plugin"
 	format "github.com/ipfs/go-ipld-format"
 	node "github.com/ipfs/go-ipld-format"
+	logging "github.com/ipfs/go-log/v2"
 	"github.com/lazyledger/nmt"
 	mh "github.com/multiformats/go-multihash"
 )
@@ -44,8 +46,12 @@ const (
 	shareSize     = 256
 	// nmtHashSize is the size of a digest created by an NMT in bytes.
 	nmtHashSize = 2*namespaceSize + sha256.Size
+	// prefixOffset is the length of the domain separator for leaf and inner nodes.
+	prefixOffset = 1
 )
 
+var log = logging.Logger("nmt-nodes")
+
 func init() {
 	mustRegisterNamespacedCodec(
 		Sha256Namespace8Flagged,
@@ -96,15 +102,32 @@ var Plugins = []plugin.Plugin{&LazyLedgerPlugin{}}
 
 var _ plugin.PluginIPLD = &LazyLedgerPlugin{}
 
-type LazyLedgerPlugin struct{}
+type LazyLedgerPlugin struct {
+	// leafSize is the size of the leaves read by the input parser. It can be
+	// set with the LeafSize field of the plugin's config and defaults to
+	// shareSize+namespaceSize.
+	leafSize int
+	// strictParsing selects StrictNmtNodeParser over NmtNodeParser to decode
+	// blocks. It can be set with the StrictNodeParsing field of the plugin's
+	// config and defaults to false.
+	strictParsing bool
+}
 
 func (l LazyLedgerPlugin) RegisterBlockDecoders(dec format.BlockDecoder) error {
+	if l.strictParsing {
+		dec.Register(Nmt, StrictNmtNodeParser)
+		return nil
+	}
 	dec.Register(Nmt, NmtNodeParser)
 	return nil
 }
 
 func (l LazyLedgerPlugin) RegisterInputEncParsers(iec coredag.InputEncParsers) error {
-	iec.AddParser("raw", DagParserFormatName, DataSquareRowOrColumnRawInputParser)
+	leafSize := l.leafSize
+	if leafSize == 0 {
+		leafSize = shareSize + namespaceSize
+	}
+	iec.AddParser("raw", DagParserFormatName, NewDataSquareRowOrColumnRawInputParser(leafSize))
 	return nil
 }
 
@@ -116,10 +139,67 @@ func (l LazyLedgerPlugin) Version() string {
 	return "0.0.0"
 }
 
-func (l LazyLedgerPlugin) Init(env *plugin.Environment) error {
+func (l *LazyLedgerPlugin) Init(env *plugin.Environment) error {
+	if env == nil {
+		return nil
+	}
+	leafSize, err := leafSizeFromConfig(env.Config)
+	if err != nil {
+		return err
+	}
+	strictParsing, err := strictParsingFromConfig(env.Config)
+	if err != nil {
+		return err
+	}
+	l.leafSize = leafSize
+	l.strictParsing = strictParsing
 	return nil
 }
 
+// leafSizeFromConfig returns the LeafSize field of the given plugin config, or
+// 0 if it is not set.
+func leafSizeFromConfig(config interface{}) (int, error) {
+	if config == nil {
+		return 0, nil
+	}
+	fields, ok := config.(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("invalid plugin config: %v", config)
+	}
+	value, ok := fields["LeafSize"]
+	if !ok {
+		return 0, nil
+	}
+	// numbers are unmarshaled from JSON as float64
+	leafSize, ok := value.(float64)
+	if !ok || leafSize != float64(int(leafSize)) || int(leafSize) <= namespaceSize {
+		return 0, fmt.Errorf("invalid LeafSize: %v, must be an integer larger than the namespace size %d",
+			value, namespaceSize)
+	}
+	return int(leafSize), nil
+}
+
+// strictParsingFromConfig returns the StrictNodeParsing field of the given
+// plugin config, or false if it is not set.
+func strictParsingFromConfig(config interface{}) (bool, error) {
+	if config == nil {
+		return false, nil
+	}
+	fields, ok := config.(map[string]interface{})
+	if !ok {
+		return false, fmt.Errorf("invalid plugin config: %v", config)
+	}
+	value, ok := fields["StrictNodeParsing"]
+	if !ok {
+		return false, nil
+	}
+	strict, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("invalid StrictNodeParsing: %v, must be a boolean", value)
+	}
+	return strict, nil
+}
+
 // DataSquareRowOrColumnRawInputParser reads the raw shares and extract the IPLD nodes from the NMT tree.
 // Note, to parse without any error the input has to be of the form:
 //
@@ -127,36 +207,56 @@ func (l LazyLedgerPlugin) Init(env *plugin.Environment) error {
 //
 // To determine the share and the namespace size the constants
 // types.ShareSize and types.NamespaceSize are redefined here to avoid
-// lazyledger-core as a dependency.
+// lazyledger-core as a dependency. Use NewDataSquareRowOrColumnRawInputParser
+// for shares of a different size.
 //
 // Note while this coredag.DagParser is implemented here so this plugin can be used from
 // the commandline, the ipld Nodes will rather be created together with the NMT
 // root instead of re-computing it here.
-func DataSquareRowOrColumnRawInputParser(r io.Reader, _mhType uint64, _mhLen int) ([]node.Node, error) {
-	br := bufio.NewReader(r)
-	collector := newNodeCollector()
-
-	n := nmt.New(
-		sha256.New(),
-		nmt.NamespaceIDSize(namespaceSize),
-		nmt.NodeVisitor(collector.visit),
-	)
+func DataSquareRowOrColumnRawInputParser(r io.Reader, mhType uint64, mhLen int) ([]node.Node, error) {
+	return NewDataSquareRowOrColumnRawInputParser(shareSize+namespaceSize)(r, mhType, mhLen)
+}
+
+// NewDataSquareRowOrColumnRawInputParser returns a parser like
+// DataSquareRowOrColumnRawInputParser for leaves, i.e. namespaced shares, of
+// the given size. The parser returns an error if the input length is not a
+// multiple of the leaf size, instead of misreading the namespaces.
+func NewDataSquareRowOrColumnRawInputParser(leafSize int) coredag.DagParser {
+	return func(r io.Reader, _mhType uint64, _mhLen int) ([]node.Node, error) {
+		if leafSize <= namespaceSize {
+			return nil, fmt.Errorf("invalid leaf size %d, must be larger than the namespace size %d",
+				leafSize, namespaceSize)
+		}
+
+		br := bufio.NewReader(r)
+		collector := newNodeCollector()
 
-	for {
-		namespacedLeaf := make([]byte, shareSize+namespaceSize)
-		if _, err := io.ReadFull(br, namespacedLeaf); err != nil {
-			if err == io.EOF {
-				break
+		n := nmt.New(
+			sha256.New(),
+			nmt.NamespaceIDSize(namespaceSize),
+			nmt.NodeVisitor(collector.visit),
+		)
+
+		for i := 0; ; i++ {
+			namespacedLeaf := make([]byte, leafSize)
+			if read, err := io.ReadFull(br, namespacedLeaf); err != nil {
+				if err == io.EOF {
+					break
+				}
+				if err == io.ErrUnexpectedEOF {
+					return nil, fmt.Errorf("input length is not a multiple of the leaf size %d: leaf %d has only %d bytes",
+						leafSize, i, read)
+				}
+				return nil, err
+			}
+			if err := n.Push(namespacedLeaf[:namespaceSize], namespacedLeaf[namespaceSize:]); err != nil {
+				return nil, err
 			}
-			return nil, err
-		}
-		if err := n.Push(namespacedLeaf[:namespaceSize], namespacedLeaf[namespaceSize:]); err != nil {
-			return nil, err
 		}
+		// to trigger the collection of nodes:
+		_ = n.Root()
+		return collector.ipldNodes(), nil
 	}
-	// to trigger the collection of nodes:
-	_ = n.Root()
-	return collector.ipldNodes(), nil
 }
 
 // nmtNodeCollector creates and collects ipld.Nodes if inserted into a nmt tree.
@@ -202,81 +302,255 @@ func prependNode(newNode node.Node, nodes []node.Node) []node.Node {
 	return nodes
 }
 
+// ErrDuplicateLeaf is recorded by an NmtNodeAdder created with
+// DetectDuplicateLeaves if the same leaf is visited more than once.
+var ErrDuplicateLeaf = errors.New("duplicate leaf")
+
 // NmtNodeAdder adds ipld.Nodes to the underlying ipld.Batch if it is inserted
 // into an nmt tree
 type NmtNodeAdder struct {
-	batch *format.Batch
-	ctx   context.Context
+	batch  *format.Batch
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// leafCids is only non-nil if duplicate leaf detection is enabled.
+	leafCids map[cid.Cid]struct{}
+	err      error
+}
+
+// NmtNodeAdderOption sets an optional parameter on the NmtNodeAdder.
+type NmtNodeAdderOption func(*NmtNodeAdder)
+
+// DetectDuplicateLeaves makes the NmtNodeAdder keep track of the CIDs of all
+// visited leaves. If a leaf is visited twice, it is not added to the batch
+// again and an ErrDuplicateLeaf is recorded, see Err.
+func DetectDuplicateLeaves() NmtNodeAdderOption {
+	return func(n *NmtNodeAdder) { n.leafCids = make(map[cid.Cid]struct{}) }
 }
 
 // NewNmtNodeAdder returns a new NmtNodeAdder with the provided context and
-// batch. Note that the context provided should have a timeout
-func NewNmtNodeAdder(ctx context.Context, batch *format.Batch) *NmtNodeAdder {
-	return &NmtNodeAdder{
+// batch. Note that the context provided should have a timeout, a warning is
+// logged if it doesn't. See also NewNmtNodeAdderWithTimeout.
+func NewNmtNodeAdder(ctx context.Context, batch *format.Batch, options ...NmtNodeAdderOption) *NmtNodeAdder {
+	if _, ok := ctx.Deadline(); !ok {
+		log.Warn("NmtNodeAdder created with a context without deadline, committing the batch might hang forever")
+	}
+	return newNmtNodeAdder(ctx, batch, options...)
+}
+
+// NewNmtNodeAdderWithTimeout returns a new NmtNodeAdder that adds the nodes to
+// dag in a batch, using a child context of ctx that times out after the given
+// timeout, so that neither adding the nodes nor committing the batch can hang
+// forever. The child context is released by Commit.
+func NewNmtNodeAdderWithTimeout(
+	ctx context.Context,
+	dag format.NodeAdder,
+	timeout time.Duration,
+	options ...NmtNodeAdderOption,
+) *NmtNodeAdder {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	n := newNmtNodeAdder(ctx, format.NewBatch(ctx, dag), options...)
+	n.cancel = cancel
+	return n
+}
+
+// NewNmtNodeAdderWithBatchSize returns a new NmtNodeAdder that adds the nodes
+// to dag in a batch that commits the buffered nodes automatically once there
+// are more than batchSize of them, see format.MaxNodesBatchOption. This bounds
+// the number of nodes kept in memory. The remaining nodes still have to be committed by
+// calling Commit. Errors of the automatic commits are recorded, see Err.
+func NewNmtNodeAdderWithBatchSize(
+	ctx context.Context,
+	dag format.NodeAdder,
+	batchSize int,
+	options ...NmtNodeAdderOption,
+) *NmtNodeAdder {
+	return NewNmtNodeAdder(ctx, format.NewBatch(ctx, dag, format.MaxNodesBatchOption(batchSize)), options...)
+}
+
+func newNmtNodeAdder(ctx context.Context, batch *format.Batch, options ...NmtNodeAdderOption) *NmtNodeAdder {
+	n := &NmtNodeAdder{
 		batch: batch,
 		ctx:   ctx,
 	}
+	for _, option := range options {
+		option(n)
+	}
+	return n
 }
 
 // Visit can be inserted into an nmt tree to create ipld.Nodes while computing the root
 func (n *NmtNodeAdder) Visit(hash []byte, children ...[]byte) {
+	if err := n.ctx.Err(); err != nil {
+		n.setError(err)
+		return
+	}
 	cid := mustCidFromNamespacedSha256(hash)
 	switch len(children) {
 	case 1:
-		n.batch.Add(n.ctx, nmtLeafNode{
+		if n.leafCids != nil {
+			if _, seen := n.leafCids[cid]; seen {
+				n.setError(fmt.Errorf("%w: %s", ErrDuplicateLeaf, cid))
+				return
+			}
+			n.leafCids[cid] = struct{}{}
+		}
+		if err := n.batch.Add(n.ctx, nmtLeafNode{
 			cid:  cid,
 			Data: children[0],
-		})
+		}); err != nil {
+			n.setError(err)
+			return
+		}
 	case 2:
-		n.batch.Add(n.ctx, nmtNode{
+		if err := n.batch.Add(n.ctx, nmtNode{
 			cid: cid,
 			l:   children[0],
 			r:   children[1],
-		})
+		}); err != nil {
+			n.setError(err)
+			return
+		}
 	default:
 		panic("expected a binary tree")
 	}
 }
 
-// Batch return the ipld.Batch originally provided to the NmtNodeAdder
+// Batch return the ipld.Batch used by the NmtNodeAdder. Use Commit
+// rather than committing the batch directly, so that the context of an
+// NmtNodeAdder created with NewNmtNodeAdderWithTimeout is released.
 func (n *NmtNodeAdder) Batch() *format.Batch {
 	return n.batch
 }
 
+// Commit commits the underlying batch. The commit is aborted with the context's
+// error once the context of the batch is done.
+func (n *NmtNodeAdder) Commit() error {
+	if n.cancel != nil {
+		defer n.cancel()
+	}
+	return n.batch.Commit()
+}
+
+// Err returns the first error recorded while visiting the nodes of the tree,
+// or nil if there was none.
+func (n *NmtNodeAdder) Err() error {
+	return n.err
+}
+
+func (n *NmtNodeAdder) setError(err error) {
+	if n.err == nil {
+		n.err = err
+	}
+}
+
+// NmtNodeParser parses an IPLD block containing an NMT inner or leaf node.
 func NmtNodeParser(block blocks.Block) (node.Node, error) {
-	// length of the domain separator for leaf and inner nodes:
-	const prefixOffset = 1
-	var (
-		leafPrefix  = []byte{nmt.LeafPrefix}
-		innerPrefix = []byte{nmt.NodePrefix}
-	)
 	data := block.RawData()
+	if err := validateNmtNodeData(data); err != nil {
+		return nil, err
+	}
+	return parseNmtNode(block.Cid(), data), nil
+}
+
+// StrictNmtNodeParser is like NmtNodeParser, but for leaf nodes it also
+// recomputes the namespaced hash of the leaf data and checks that it matches
+// the digest of the block's CID. This catches blocks whose data doesn't match
+// their CID, e.g. a leaf with a different namespace than the one in its CID.
+func StrictNmtNodeParser(block blocks.Block) (node.Node, error) {
+	data := block.RawData()
+	if err := validateNmtNodeData(data); err != nil {
+		return nil, err
+	}
+	if len(data) > 0 && data[0] == nmt.LeafPrefix {
+		want, err := NamespacedSha256FromCID(block.Cid())
+		if err != nil {
+			return nil, err
+		}
+		got, err := sumSha256Namespace8Flagged(data, nmtHashSize)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(got, want) {
+			return nil, fmt.Errorf("leaf data doesn't match CID %v: hash %X, want %X", block.Cid(), got, want)
+		}
+	}
+	return parseNmtNode(block.Cid(), data), nil
+}
+
+// ParseNmtNode parses the raw data of an NMT inner or leaf node, as returned
+// by RawData, back into an ipld.Node. In contrast to NmtNodeParser, the CID is
+// not taken from a block but computed from the data itself.
+func ParseNmtNode(data []byte) (node.Node, error) {
+	if err := validateNmtNodeData(data); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return parseNmtNode(cid.Undef, data), nil
+	}
+	hash, err := sumSha256Namespace8Flagged(data, nmtHashSize)
+	if err != nil {
+		return nil, err
+	}
+	c, err := CidFromNamespacedSha256(hash)
+	if err != nil {
+		return nil, err
+	}
+	return parseNmtNode(c, data), nil
+}
+
+// parseNmtNode creates the node with CID c from data, which must have been
+// checked by validateNmtNodeData.
+func parseNmtNode(c cid.Cid, data []byte) node.Node {
 	if len(data) == 0 {
 		return &nmtLeafNode{
 			cid:  cid.Undef,
 			Data: nil,
-		}, nil
+		}
 	}
-	domainSeparator := data[:prefixOffset]
-	if bytes.Equal(domainSeparator, leafPrefix) {
+	if data[0] == nmt.LeafPrefix {
 		return &nmtLeafNode{
-			cid:  block.Cid(),
+			cid:  c,
 			Data: data[prefixOffset:],
-		}, nil
-	}
-	if bytes.Equal(domainSeparator, innerPrefix) {
-		return nmtNode{
-			cid: block.Cid(),
-			l:   data[prefixOffset : prefixOffset+nmtHashSize],
-			r:   data[prefixOffset+nmtHashSize:],
-		}, nil
-	}
-	return nil, fmt.Errorf(
-		"expected first byte of block to be either the leaf or inner node prefix: (%x, %x), got: %x)",
-		leafPrefix,
-		innerPrefix,
-		domainSeparator,
+		}
+	}
+	return nmtNode{
+		cid: c,
+		l:   data[prefixOffset : prefixOffset+nmtHashSize],
+		r:   data[prefixOffset+nmtHashSize:],
+	}
+}
+
+// validateNmtNodeData checks that the given block data starts with the leaf or
+// inner node prefix and is large enough to be sliced accordingly. Empty data
+// is valid, it is parsed as an empty leaf.
+func validateNmtNodeData(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var (
+		leafPrefix  = []byte{nmt.LeafPrefix}
+		innerPrefix = []byte{nmt.NodePrefix}
 	)
+	domainSeparator := data[:prefixOffset]
+	switch {
+	case bytes.Equal(domainSeparator, leafPrefix):
+		if got, want := len(data), prefixOffset+namespaceSize; got < want {
+			return fmt.Errorf("leaf node block too short: expected at least %d bytes, got: %d", want, got)
+		}
+	case bytes.Equal(domainSeparator, innerPrefix):
+		if got, want := len(data), prefixOffset+2*nmtHashSize; got != want {
+			return fmt.Errorf("inner node block has invalid size: expected %d bytes, got: %d", want, got)
+		}
+	default:
+		return fmt.Errorf(
+			"expected first byte of block to be either the leaf or inner node prefix: (%x, %x), got: %x)",
+			leafPrefix,
+			innerPrefix,
+			domainSeparator,
+		)
+	}
+	return nil
 }
 
 var _ node.Node = (*nmtNode)(nil)
@@ -455,6 +729,52 @@ func CidFromNamespacedSha256(namespacedHash []byte) (cid.Cid, error) {
 	return cid.NewCidV1(Nmt, mh.Multihash(buf)), nil
 }
 
+// IsNmtCID returns true if the given CID was created by
+// CidFromNamespacedSha256, i.e. if it is a CIDv1 with the Nmt codec and a
+// Sha256Namespace8Flagged multihash of the expected length.
+func IsNmtCID(c cid.Cid) bool {
+	if !c.Defined() || c.Version() != 1 || c.Type() != Nmt {
+		return false
+	}
+	decoded, err := mh.Decode(c.Hash())
+	if err != nil {
+		return false
+	}
+	return decoded.Code == Sha256Namespace8Flagged && decoded.Length == nmtHashSize
+}
+
+// NamespacedSha256FromCID returns the namespaced hash of the nmt node with the
+// given CID, i.e. it is the inverse of CidFromNamespacedSha256. For a leaf, the
+// min and max namespace of the hash are the namespace of the leaf.
+func NamespacedSha256FromCID(c cid.Cid) ([]byte, error) {
+	if !IsNmtCID(c) {
+		return nil, fmt.Errorf("not an nmt CID: %v", c)
+	}
+	decoded, err := mh.Decode(c.Hash())
+	if err != nil {
+		return nil, err
+	}
+	return decoded.Digest, nil
+}
+
+// routingKeyPrefix prefixes the keys returned by RoutingKeyForCID, to separate
+// them from other keys in the DHT.
+const routingKeyPrefix = "/nmt-ns/"
+
+// RoutingKeyForCID returns a routing key for the nmt node with the given CID,
+// derived from the node's min namespace, i.e. it is the same for all leaves of
+// a namespace. It can be used as a DHT provider key prefix, to find providers
+// that likely have the data of a namespace.
+func RoutingKeyForCID(c cid.Cid) ([]byte, error) {
+	hash, err := NamespacedSha256FromCID(c)
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 0, len(routingKeyPrefix)+namespaceSize)
+	key = append(key, routingKeyPrefix...)
+	return append(key, hash[:namespaceSize]...), nil
+}
+
 // mustCidFromNamespacedSha256 is a wrapper around cidFromNamespacedSha256 that panics
 // in case of an error. Use with care and only in places where no error should occur.
 func mustCidFromNamespacedSha256(hash []byte) cid.Cid {
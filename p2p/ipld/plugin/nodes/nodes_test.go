@@ -2,14 +2,22 @@ package nodes
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"math/rand"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
 	shell "github.com/ipfs/go-ipfs-api"
+	"github.com/ipfs/go-ipfs/plugin"
+	format "github.com/ipfs/go-ipld-format"
 	"github.com/ipfs/go-verifcid"
 	mh "github.com/multiformats/go-multihash"
 
@@ -62,6 +70,76 @@ func TestDataSquareRowOrColumnRawInputParserCidEqNmtRoot(t *testing.T) {
 	}
 }
 
+func TestDataSquareRowOrColumnRawInputParserLeafSize(t *testing.T) {
+	const smallShareSize = 16
+	small := generateRandNamespacedRawData(8, namespaceSize, smallShareSize)
+	full := generateRandNamespacedRawData(8, namespaceSize, shareSize)
+
+	tests := []struct {
+		name      string
+		leafSize  int
+		input     []byte
+		wantNodes int
+		wantErr   string
+	}{
+		{"small leaves", namespaceSize + smallShareSize, createByteBufFromRawData(t, small).Bytes(), 15, ""},
+		{"full leaves", namespaceSize + shareSize, createByteBufFromRawData(t, full).Bytes(), 15, ""},
+		{"small leaves as full leaves", namespaceSize + shareSize, createByteBufFromRawData(t, small).Bytes(), 0,
+			"not a multiple of the leaf size"},
+		{"truncated input", namespaceSize + smallShareSize,
+			createByteBufFromRawData(t, small).Bytes()[:8*(namespaceSize+smallShareSize)-1], 0, "leaf 7 has only"},
+		{"invalid leaf size", namespaceSize, createByteBufFromRawData(t, small).Bytes(), 0, "invalid leaf size"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewDataSquareRowOrColumnRawInputParser(tt.leafSize)
+			gotNodes, err := parser(bytes.NewReader(tt.input), 0, 0)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parser() error = %v, want error containing: %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parser() unexpected error = %v", err)
+			}
+			if len(gotNodes) != tt.wantNodes {
+				t.Errorf("parser() returned %d nodes, want: %d", len(gotNodes), tt.wantNodes)
+			}
+		})
+	}
+}
+
+func TestLeafSizeFromConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  interface{}
+		want    int
+		wantErr bool
+	}{
+		{"no config", nil, 0, false},
+		{"no leaf size", map[string]interface{}{}, 0, false},
+		{"leaf size", map[string]interface{}{"LeafSize": float64(24)}, 24, false},
+		{"fractional leaf size", map[string]interface{}{"LeafSize": 24.5}, 0, true},
+		{"leaf size too small", map[string]interface{}{"LeafSize": float64(namespaceSize)}, 0, true},
+		{"leaf size not a number", map[string]interface{}{"LeafSize": "24"}, 0, true},
+		{"invalid config", "LeafSize", 0, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := leafSizeFromConfig(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("leafSizeFromConfig() error = %v, wantErr: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("leafSizeFromConfig() = %d, want: %d", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestNodeCollector(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -126,6 +204,397 @@ func TestNodeCollector(t *testing.T) {
 	}
 }
 
+func TestNmtNodeAdderDuplicateLeaves(t *testing.T) {
+	share := generateRandNamespacedRawData(1, namespaceSize, shareSize)[0]
+	tests := []struct {
+		name     string
+		leafData [][]byte
+		wantErr  bool
+	}{
+		{"unique leaves", generateRandNamespacedRawData(16, namespaceSize, shareSize), false},
+		{"duplicate leaf", [][]byte{share, share}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			dag := newMapNodeAdder()
+			adder := NewNmtNodeAdder(ctx, format.NewBatch(ctx, dag), DetectDuplicateLeaves())
+			n := nmt.New(sha256.New(), nmt.NamespaceIDSize(namespaceSize), nmt.NodeVisitor(adder.Visit))
+
+			for _, share := range tt.leafData {
+				err := n.Push(share[:namespaceSize], share[namespaceSize:])
+				if err != nil {
+					t.Fatalf("nmt.Push() unexpected error = %v", err)
+				}
+			}
+			// to trigger the visitor:
+			_ = n.Root()
+
+			err := adder.Err()
+			if gotErr := errors.Is(err, ErrDuplicateLeaf); gotErr != tt.wantErr {
+				t.Errorf("Err() = %v, want duplicate leaf error: %v", err, tt.wantErr)
+			}
+			if err := adder.Commit(); err != nil {
+				t.Fatalf("Commit() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestParseNmtNodeRoundTrip(t *testing.T) {
+	collector := newNodeCollector()
+	n := nmt.New(sha256.New(), nmt.NamespaceIDSize(namespaceSize), nmt.NodeVisitor(collector.visit))
+	for _, share := range generateRandNamespacedRawData(8, namespaceSize, shareSize) {
+		if err := n.Push(share[:namespaceSize], share[namespaceSize:]); err != nil {
+			t.Fatalf("nmt.Push() unexpected error = %v", err)
+		}
+	}
+	_ = n.Root()
+
+	for _, want := range collector.ipldNodes() {
+		got, err := ParseNmtNode(want.RawData())
+		if err != nil {
+			t.Fatalf("ParseNmtNode() unexpected error = %v", err)
+		}
+		if !got.Cid().Equals(want.Cid()) {
+			t.Errorf("CIDs do not match\ngot: %v\nwant: %v", got.Cid(), want.Cid())
+		}
+		switch want := want.(type) {
+		case nmtLeafNode:
+			got, ok := got.(*nmtLeafNode)
+			if !ok {
+				t.Fatalf("expected a leaf node, got: %T", got)
+			}
+			if !bytes.Equal(got.Data, want.Data) {
+				t.Errorf("leaf data does not match\ngot: %x\nwant: %x", got.Data, want.Data)
+			}
+		case nmtNode:
+			got, ok := got.(nmtNode)
+			if !ok {
+				t.Fatalf("expected an inner node, got: %T", got)
+			}
+			if !bytes.Equal(got.l, want.l) || !bytes.Equal(got.r, want.r) {
+				t.Errorf("children do not match\ngot: %x, %x\nwant: %x, %x", got.l, got.r, want.l, want.r)
+			}
+		default:
+			t.Fatalf("unexpected node type: %T", want)
+		}
+	}
+}
+
+func TestNmtNodeParserInvalidSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"inner node without children", []byte{nmt.NodePrefix}, "inner node block has invalid size"},
+		{"truncated inner node", append([]byte{nmt.NodePrefix}, make([]byte, nmtHashSize)...), "inner node block has invalid size"},
+		{"oversized inner node", append([]byte{nmt.NodePrefix}, make([]byte, 2*nmtHashSize+1)...), "inner node block has invalid size"},
+		{"leaf without namespace", append([]byte{nmt.LeafPrefix}, make([]byte, namespaceSize-1)...), "leaf node block too short"},
+		{"unknown prefix", []byte{0x02}, "expected first byte of block"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NmtNodeParser(blocks.NewBlock(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NmtNodeParser() error = %v, want error containing %q", err, tt.wantErr)
+			}
+			_, err = ParseNmtNode(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseNmtNode() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// leafBlocks returns a block of a leaf under its CID, and a block of a leaf in
+// another namespace under the same CID.
+func leafBlocks(t *testing.T) (valid, mismatched blocks.Block) {
+	leafData := func(nsByte byte) []byte {
+		data := append([]byte{nmt.LeafPrefix}, bytes.Repeat([]byte{nsByte}, namespaceSize)...)
+		return append(data, bytes.Repeat([]byte{0xAA}, shareSize)...)
+	}
+	data := leafData(1)
+	hash, err := sumSha256Namespace8Flagged(data, nmtHashSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := CidFromNamespacedSha256(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid, err = blocks.NewBlockWithCid(data, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mismatched, err = blocks.NewBlockWithCid(leafData(2), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return valid, mismatched
+}
+
+func TestStrictNmtNodeParser(t *testing.T) {
+	block, mismatched := leafBlocks(t)
+	n, err := StrictNmtNodeParser(block)
+	if err != nil {
+		t.Fatalf("StrictNmtNodeParser() unexpected error: %v", err)
+	}
+	if !n.Cid().Equals(block.Cid()) {
+		t.Errorf("StrictNmtNodeParser() got CID %v, want %v", n.Cid(), block.Cid())
+	}
+
+	if _, err := NmtNodeParser(mismatched); err != nil {
+		t.Errorf("NmtNodeParser() unexpected error: %v", err)
+	}
+	if _, err := StrictNmtNodeParser(mismatched); err == nil || !strings.Contains(err.Error(), "doesn't match CID") {
+		t.Errorf("StrictNmtNodeParser() error = %v, want a CID mismatch", err)
+	}
+}
+
+func TestStrictParsingFromConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  interface{}
+		want    bool
+		wantErr bool
+	}{
+		{"no config", nil, false, false},
+		{"not set", map[string]interface{}{}, false, false},
+		{"enabled", map[string]interface{}{"StrictNodeParsing": true}, true, false},
+		{"disabled", map[string]interface{}{"StrictNodeParsing": false}, false, false},
+		{"not a boolean", map[string]interface{}{"StrictNodeParsing": "true"}, false, true},
+		{"invalid config", "StrictNodeParsing", false, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := strictParsingFromConfig(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("strictParsingFromConfig() error = %v, wantErr: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("strictParsingFromConfig() = %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// mapBlockDecoder is a format.BlockDecoder that records the registered
+// decoders.
+type mapBlockDecoder map[uint64]format.DecodeBlockFunc
+
+func (d mapBlockDecoder) Register(codec uint64, decoder format.DecodeBlockFunc) {
+	d[codec] = decoder
+}
+
+func (d mapBlockDecoder) Decode(block blocks.Block) (format.Node, error) {
+	return d[block.Cid().Type()](block)
+}
+
+func TestRegisterBlockDecoders(t *testing.T) {
+	_, mismatched := leafBlocks(t)
+	tests := []struct {
+		name    string
+		config  interface{}
+		wantErr bool
+	}{
+		{"default", nil, false},
+		{"strict", map[string]interface{}{"StrictNodeParsing": true}, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var p LazyLedgerPlugin
+			if err := p.Init(&plugin.Environment{Config: tt.config}); err != nil {
+				t.Fatalf("Init() unexpected error: %v", err)
+			}
+			dec := make(mapBlockDecoder)
+			if err := p.RegisterBlockDecoders(dec); err != nil {
+				t.Fatalf("RegisterBlockDecoders() unexpected error: %v", err)
+			}
+			if _, err := dec.Decode(mismatched); (err != nil) != tt.wantErr {
+				t.Errorf("Decode() error = %v, wantErr: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsNmtCID(t *testing.T) {
+	hash := nmt.Sha256Namespace8FlaggedLeaf(generateRandNamespacedRawData(1, namespaceSize, shareSize)[0])
+	nmtCid, err := CidFromNamespacedSha256(hash)
+	if err != nil {
+		t.Fatalf("CidFromNamespacedSha256() unexpected error = %v", err)
+	}
+
+	sha256Hash, err := mh.Sum([]byte("data"), mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatalf("mh.Sum() unexpected error = %v", err)
+	}
+	shortHash, err := mh.Encode(hash[:nmtHashSize-1], Sha256Namespace8Flagged)
+	if err != nil {
+		t.Fatalf("mh.Encode() unexpected error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		cid  cid.Cid
+		want bool
+	}{
+		{"nmt cid", nmtCid, true},
+		{"undefined cid", cid.Undef, false},
+		{"raw cid", cid.NewCidV1(cid.Raw, sha256Hash), false},
+		{"nmt codec with sha256 multihash", cid.NewCidV1(Nmt, sha256Hash), false},
+		{"raw codec with nmt multihash", cid.NewCidV1(cid.Raw, nmtCid.Hash()), false},
+		{"nmt cid with short digest", cid.NewCidV1(Nmt, shortHash), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsNmtCID(tt.cid); got != tt.want {
+				t.Errorf("IsNmtCID(%v) = %v, want: %v", tt.cid, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNamespacedSha256FromCID(t *testing.T) {
+	hash := nmt.Sha256Namespace8FlaggedLeaf(generateRandNamespacedRawData(1, namespaceSize, shareSize)[0])
+	nmtCid, err := CidFromNamespacedSha256(hash)
+	if err != nil {
+		t.Fatalf("CidFromNamespacedSha256() unexpected error = %v", err)
+	}
+
+	got, err := NamespacedSha256FromCID(nmtCid)
+	if err != nil {
+		t.Fatalf("NamespacedSha256FromCID() unexpected error = %v", err)
+	}
+	if !bytes.Equal(got, hash) {
+		t.Errorf("NamespacedSha256FromCID() = %x, want: %x", got, hash)
+	}
+
+	sha256Hash, err := mh.Sum([]byte("data"), mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatalf("mh.Sum() unexpected error = %v", err)
+	}
+	if _, err := NamespacedSha256FromCID(cid.NewCidV1(cid.Raw, sha256Hash)); err == nil {
+		t.Errorf("NamespacedSha256FromCID() expected an error for a raw CID")
+	}
+}
+
+func TestRoutingKeyForCID(t *testing.T) {
+	leafCid := func(nid byte, data string) cid.Cid {
+		leaf := append(bytes.Repeat([]byte{nid}, namespaceSize), data...)
+		c, err := CidFromNamespacedSha256(nmt.Sha256Namespace8FlaggedLeaf(leaf))
+		if err != nil {
+			t.Fatalf("CidFromNamespacedSha256() unexpected error = %v", err)
+		}
+		return c
+	}
+	routingKey := func(c cid.Cid) []byte {
+		key, err := RoutingKeyForCID(c)
+		if err != nil {
+			t.Fatalf("RoutingKeyForCID() unexpected error = %v", err)
+		}
+		return key
+	}
+
+	key := routingKey(leafCid(1, "a"))
+	if !bytes.HasPrefix(key, []byte(routingKeyPrefix)) {
+		t.Errorf("RoutingKeyForCID() = %x, want prefix: %q", key, routingKeyPrefix)
+	}
+	if got := routingKey(leafCid(1, "b")); !bytes.Equal(got, key) {
+		t.Errorf("RoutingKeyForCID() differs within a namespace, got: %x, want: %x", got, key)
+	}
+	if got := routingKey(leafCid(2, "a")); bytes.Equal(got, key) {
+		t.Errorf("RoutingKeyForCID() = %x for different namespaces", got)
+	}
+
+	sha256Hash, err := mh.Sum([]byte("data"), mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatalf("mh.Sum() unexpected error = %v", err)
+	}
+	if _, err := RoutingKeyForCID(cid.NewCidV1(cid.Raw, sha256Hash)); err == nil {
+		t.Errorf("RoutingKeyForCID() expected an error for a raw CID")
+	}
+}
+
+func TestNmtNodeAdderCommitTimeout(t *testing.T) {
+	ctx := context.Background()
+	dag := newBlockingNodeAdder()
+	defer dag.unblock()
+	adder := NewNmtNodeAdderWithTimeout(ctx, dag, 100*time.Millisecond)
+	n := nmt.New(sha256.New(), nmt.NamespaceIDSize(namespaceSize), nmt.NodeVisitor(adder.Visit))
+	for _, share := range generateRandNamespacedRawData(16, namespaceSize, shareSize) {
+		if err := n.Push(share[:namespaceSize], share[namespaceSize:]); err != nil {
+			t.Fatalf("nmt.Push() unexpected error = %v", err)
+		}
+	}
+	_ = n.Root()
+
+	result := make(chan error, 1)
+	go func() { result <- adder.Commit() }()
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Commit() = %v, want: %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Commit() did not respect the timeout")
+	}
+}
+
+func TestNmtNodeAdderWithBatchSize(t *testing.T) {
+	const (
+		numLeaves = 16
+		numNodes  = 2*numLeaves - 1
+		batchSize = 8
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	dag := newMapNodeAdder()
+	adder := NewNmtNodeAdderWithBatchSize(ctx, dag, batchSize)
+	collector := newNodeCollector()
+	visit := func(hash []byte, children ...[]byte) {
+		adder.Visit(hash, children...)
+		collector.visit(hash, children...)
+	}
+	n := nmt.New(sha256.New(), nmt.NamespaceIDSize(namespaceSize), nmt.NodeVisitor(visit))
+	for _, share := range generateRandNamespacedRawData(numLeaves, namespaceSize, shareSize) {
+		if err := n.Push(share[:namespaceSize], share[namespaceSize:]); err != nil {
+			t.Fatalf("nmt.Push() unexpected error = %v", err)
+		}
+	}
+	_ = n.Root()
+
+	if err := adder.Commit(); err != nil {
+		t.Fatalf("Commit() unexpected error = %v", err)
+	}
+	if err := adder.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if got := dag.len(); got != numNodes {
+		t.Errorf("expected %d nodes to be committed, got: %d", numNodes, got)
+	}
+	// the nodes were committed in several batches, each one committed once
+	// more than batchSize nodes were buffered
+	batches := dag.batchSizes()
+	if len(batches) < numNodes/(batchSize+1) {
+		t.Errorf("expected at least %d batches, got: %d", numNodes/(batchSize+1), len(batches))
+	}
+	for _, size := range batches {
+		if size > batchSize+1 {
+			t.Errorf("expected batches of at most %d nodes, got: %d", batchSize+1, size)
+		}
+	}
+	for _, nd := range collector.ipldNodes() {
+		if !dag.has(nd.Cid()) {
+			t.Errorf("node %s was not committed", nd.Cid())
+		}
+	}
+}
+
 func TestDagPutWithPlugin(t *testing.T) {
 	t.Skip("Requires running ipfs daemon (serving the HTTP Api) with the plugin compiled and installed")
 
@@ -235,6 +704,77 @@ func (n nmtWrapper) Root() []byte {
 	return n.NamespacedMerkleTree.Root().Bytes()
 }
 
+// mapNodeAdder is a simple in-memory format.NodeAdder.
+type mapNodeAdder struct {
+	mtx     sync.Mutex
+	nodes   map[cid.Cid]format.Node
+	batches []int // number of nodes added by each call to AddMany
+}
+
+func newMapNodeAdder() *mapNodeAdder {
+	return &mapNodeAdder{nodes: make(map[cid.Cid]format.Node)}
+}
+
+func (m *mapNodeAdder) Add(_ context.Context, nd format.Node) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.nodes[nd.Cid()] = nd
+	return nil
+}
+
+func (m *mapNodeAdder) len() int {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return len(m.nodes)
+}
+
+func (m *mapNodeAdder) has(c cid.Cid) bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	_, ok := m.nodes[c]
+	return ok
+}
+
+func (m *mapNodeAdder) batchSizes() []int {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return append([]int(nil), m.batches...)
+}
+
+func (m *mapNodeAdder) AddMany(ctx context.Context, nds []format.Node) error {
+	m.mtx.Lock()
+	m.batches = append(m.batches, len(nds))
+	m.mtx.Unlock()
+	for _, nd := range nds {
+		if err := m.Add(ctx, nd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blockingNodeAdder is a format.NodeAdder that blocks until unblock is called.
+type blockingNodeAdder struct {
+	done chan struct{}
+}
+
+func newBlockingNodeAdder() *blockingNodeAdder {
+	return &blockingNodeAdder{done: make(chan struct{})}
+}
+
+func (b *blockingNodeAdder) unblock() {
+	close(b.done)
+}
+
+func (b *blockingNodeAdder) Add(ctx context.Context, nd format.Node) error {
+	return b.AddMany(ctx, []format.Node{nd})
+}
+
+func (b *blockingNodeAdder) AddMany(context.Context, []format.Node) error {
+	<-b.done
+	return nil
+}
+
 func leafIdxToPath(cid string, idx int) string {
 	// currently this fmt directive assumes 32 leaves:
 	bin := fmt.Sprintf("%05b", idx)
@@ -0,0 +1,154 @@
+package ipld
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/lazyledger/nmt"
+)
+
+const (
+	// proofFlagMaxNamespaceIgnored is set if the tree the proof was generated
+	// from ignores the max namespace, see nmt.IgnoreMaxNamespace.
+	proofFlagMaxNamespaceIgnored byte = 1 << iota
+	// proofFlagAbsence is set if the proof is a proof of absence and is
+	// followed by the leaf hash.
+	proofFlagAbsence
+)
+
+// errTruncatedProof is returned by UnmarshalProof if the data ends before the
+// end of the encoded proof.
+var errTruncatedProof = errors.New("truncated proof")
+
+// MarshalProof encodes the given nmt proof for sending it to another process,
+// e.g. a light client, or storing it in a fraud proof. The encoding is a flags
+// byte, followed by the start and end index of the proof as uvarints, the
+// number of nodes as uvarint and each node prefixed with its length as uvarint,
+// and, for proofs of absence, the leaf hash prefixed with its length.
+func MarshalProof(p nmt.Proof) ([]byte, error) {
+	if p.Start() < 0 || p.End() < p.Start() {
+		return nil, fmt.Errorf("invalid proof range: [%d, %d)", p.Start(), p.End())
+	}
+
+	var flags byte
+	if p.IsMaxNamespaceIDIgnored() {
+		flags |= proofFlagMaxNamespaceIgnored
+	}
+	if p.IsOfAbsence() {
+		flags |= proofFlagAbsence
+	}
+
+	buf := make([]byte, 0, 1+3*binary.MaxVarintLen64)
+	buf = append(buf, flags)
+	buf = appendUvarint(buf, uint64(p.Start()))
+	buf = appendUvarint(buf, uint64(p.End()))
+	buf = appendUvarint(buf, uint64(len(p.Nodes())))
+	for _, node := range p.Nodes() {
+		buf = appendUvarint(buf, uint64(len(node)))
+		buf = append(buf, node...)
+	}
+	if p.IsOfAbsence() {
+		buf = appendUvarint(buf, uint64(len(p.LeafHash())))
+		buf = append(buf, p.LeafHash()...)
+	}
+	return buf, nil
+}
+
+// UnmarshalProof decodes a proof encoded by MarshalProof. It returns an error
+// if the data is truncated, malformed or followed by trailing bytes.
+func UnmarshalProof(data []byte) (nmt.Proof, error) {
+	if len(data) == 0 {
+		return nmt.Proof{}, errTruncatedProof
+	}
+	flags := data[0]
+	if flags&^(proofFlagMaxNamespaceIgnored|proofFlagAbsence) != 0 {
+		return nmt.Proof{}, fmt.Errorf("unknown proof flags: %08b", flags)
+	}
+	ignoreMaxNamespace := flags&proofFlagMaxNamespaceIgnored != 0
+	r := proofReader{data: data[1:]}
+
+	start, end, count := r.uvarint(), r.uvarint(), r.uvarint()
+	if r.err != nil {
+		return nmt.Proof{}, r.err
+	}
+	if end < start || end > uint64(maxInt) {
+		return nmt.Proof{}, fmt.Errorf("invalid proof range: [%d, %d)", start, end)
+	}
+	// every node takes at least one byte for its length
+	if count > uint64(len(r.data)) {
+		return nmt.Proof{}, errTruncatedProof
+	}
+
+	var nodes [][]byte
+	if count > 0 {
+		nodes = make([][]byte, count)
+	}
+	for i := range nodes {
+		nodes[i] = r.bytes()
+	}
+	var leafHash []byte
+	if flags&proofFlagAbsence != 0 {
+		leafHash = r.bytes()
+	}
+	if r.err != nil {
+		return nmt.Proof{}, r.err
+	}
+	if len(r.data) > 0 {
+		return nmt.Proof{}, fmt.Errorf("%d trailing bytes after proof", len(r.data))
+	}
+
+	if flags&proofFlagAbsence != 0 {
+		if len(leafHash) == 0 {
+			return nmt.Proof{}, errors.New("proof of absence without leaf hash")
+		}
+		return nmt.NewAbsenceProof(int(start), int(end), nodes, leafHash, ignoreMaxNamespace), nil
+	}
+	return nmt.NewInclusionProof(int(start), int(end), nodes, ignoreMaxNamespace), nil
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+func appendUvarint(buf []byte, x uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], x)
+	return append(buf, tmp[:n]...)
+}
+
+// proofReader reads the fields of an encoded proof. After the first error,
+// all reads return zero values and err is set.
+type proofReader struct {
+	data []byte
+	err  error
+}
+
+func (r *proofReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	x, n := binary.Uvarint(r.data)
+	switch {
+	case n == 0:
+		r.err = errTruncatedProof
+		return 0
+	case n < 0:
+		r.err = errors.New("malformed proof: varint overflows 64 bits")
+		return 0
+	}
+	r.data = r.data[n:]
+	return x
+}
+
+func (r *proofReader) bytes() []byte {
+	length := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if length > uint64(len(r.data)) {
+		r.err = errTruncatedProof
+		return nil
+	}
+	b := append([]byte(nil), r.data[:length]...)
+	r.data = r.data[length:]
+	return b
+}
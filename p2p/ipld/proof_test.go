@@ -0,0 +1,71 @@
+package ipld
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lazyledger/lazyledger-core/types"
+	"github.com/lazyledger/nmt"
+	"github.com/lazyledger/nmt/namespace"
+)
+
+func TestMarshalProof(t *testing.T) {
+	nid := func(b byte) namespace.ID {
+		id := make(namespace.ID, types.NamespaceSize)
+		id[types.NamespaceSize-1] = b
+		return id
+	}
+	tree := nmt.New(sha256.New(), nmt.NamespaceIDSize(types.NamespaceSize), nmt.IgnoreMaxNamespace(true))
+	for _, b := range []byte{1, 1, 3, 3, 3, 5, 6, 8} {
+		require.NoError(t, tree.Push(nid(b), []byte{b, 0xAA}))
+	}
+	root := tree.Root()
+
+	included, err := tree.ProveNamespace(nid(3))
+	require.NoError(t, err)
+	require.NotEmpty(t, included.Nodes())
+	data, err := MarshalProof(included)
+	require.NoError(t, err)
+	proof, err := UnmarshalProof(data)
+	require.NoError(t, err)
+	assert.Equal(t, included, proof)
+	leaf := append(append([]byte{}, nid(3)...), 3, 0xAA)
+	leaves := [][]byte{leaf, leaf, leaf}
+	assert.True(t, proof.VerifyNamespace(sha256.New(), nid(3), leaves, root))
+
+	absent, err := tree.ProveNamespace(nid(4))
+	require.NoError(t, err)
+	require.True(t, absent.IsOfAbsence())
+	data, err = MarshalProof(absent)
+	require.NoError(t, err)
+	proof, err = UnmarshalProof(data)
+	require.NoError(t, err)
+	assert.Equal(t, absent, proof)
+	assert.True(t, proof.VerifyNamespace(sha256.New(), nid(4), nil, root))
+
+	data, err = MarshalProof(nmt.NewEmptyRangeProof(false))
+	require.NoError(t, err)
+	proof, err = UnmarshalProof(data)
+	require.NoError(t, err)
+	assert.Equal(t, nmt.NewEmptyRangeProof(false), proof)
+
+	// truncated, trailing and malformed data
+	data, err = MarshalProof(included)
+	require.NoError(t, err)
+	for i := 0; i < len(data); i++ {
+		_, err = UnmarshalProof(data[:i])
+		assert.Error(t, err, "truncated to %d bytes", i)
+	}
+	_, err = UnmarshalProof(append(data, 0))
+	assert.Error(t, err)
+	_, err = UnmarshalProof([]byte{0xFF, 0, 0, 0})
+	assert.Error(t, err)
+	_, err = UnmarshalProof([]byte{0, 2, 1, 0})
+	assert.Error(t, err)
+
+	_, err = MarshalProof(nmt.NewInclusionProof(2, 1, nil, false))
+	assert.Error(t, err)
+}
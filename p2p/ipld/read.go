@@ -2,26 +2,52 @@ package ipld
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
-	"math"
+	"fmt"
+	"math/bits"
+	"sync"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	coreiface "github.com/ipfs/interface-go-ipfs-core"
 	"github.com/ipfs/interface-go-ipfs-core/path"
+	"github.com/lazyledger/lazyledger-core/p2p/ipld/plugin/nodes"
 	"github.com/lazyledger/lazyledger-core/types"
+	"github.com/lazyledger/nmt"
 )
 
 const (
 	AdjustedMessageSize = types.ShareSize - types.NamespaceSize
 )
 
+var (
+	// ErrLeafNotFound is returned if a leaf, or one of the nodes on the path to
+	// it, can't be retrieved.
+	ErrLeafNotFound = errors.New("leaf not found")
+	// ErrLeafCorrupt is returned if the retrieved data is not a valid leaf of
+	// the tree, e.g. if it doesn't match the hash it was retrieved by.
+	ErrLeafCorrupt = errors.New("leaf data is corrupt")
+	// ErrRetrievalTimeout is returned if the context is cancelled or its
+	// deadline is exceeded before a leaf could be retrieved.
+	ErrRetrievalTimeout = errors.New("leaf retrieval timed out")
+	// ErrMaxDepthExceeded is returned if a tree is deeper than expected for
+	// its number of leaves, e.g. if a malicious provider serves a cyclic DAG.
+	ErrMaxDepthExceeded = errors.New("tree exceeds the max depth")
+)
+
 // /////////////////////////////////////
 //	Get Leaf Data
 // /////////////////////////////////////
 
 // GetLeafData fetches and returns the data for leaf leafIndex of root rootCid.
 // It stops and returns an error if the provided context is cancelled before
-// finishing
+// finishing. totalLeafs must be a power of two (i.e. the width of the padded,
+// extended square) and leafIndex must be smaller than totalLeafs, otherwise an
+// error is returned.
+//
+// Retrieval failures wrap ErrLeafNotFound, ErrLeafCorrupt or
+// ErrRetrievalTimeout, which can be checked with errors.Is.
 func GetLeafData(
 	ctx context.Context,
 	rootCid cid.Cid,
@@ -38,14 +64,238 @@ func GetLeafData(
 	// use the root cid and the leafPath to create an ipld path
 	p := path.Join(path.IpldPath(rootCid), leafPath...)
 
+	return resolveLeafData(ctx, p, api)
+}
+
+// GetLeafDataWithRetry is like GetLeafData but retries fetching the leaf up to
+// maxRetries times if it fails, waiting backoff before the first retry and
+// doubling the wait after each further attempt, up to maxRetryBackoff.
+// Invalid leafIndex or totalLeafs and corrupt leaves (ErrLeafCorrupt) are not
+// retried. It stops and returns an error if the provided context is cancelled
+// before finishing.
+func GetLeafDataWithRetry(
+	ctx context.Context,
+	rootCid cid.Cid,
+	leafIndex uint32,
+	totalLeafs uint32, // this corresponds to the extended square width
+	api coreiface.CoreAPI,
+	maxRetries int,
+	backoff time.Duration,
+) ([]byte, error) {
+	// calculate the path to the leaf
+	leafPath, err := leafPath(leafIndex, totalLeafs)
+	if err != nil {
+		return nil, err
+	}
+
+	// use the root cid and the leafPath to create an ipld path
+	p := path.Join(path.IpldPath(rootCid), leafPath...)
+
+	for attempt := 0; ; attempt++ {
+		data, err := resolveLeafData(ctx, p, api)
+		if err == nil {
+			return data, nil
+		}
+		if attempt >= maxRetries || !errors.Is(err, ErrLeafNotFound) {
+			return nil, err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %v", ErrRetrievalTimeout, ctx.Err())
+		case <-timer.C:
+		}
+		backoff = nextBackoff(backoff)
+	}
+}
+
+// maxRetryBackoff is the longest GetLeafDataWithRetry waits between two
+// attempts, unless the initial backoff is longer.
+const maxRetryBackoff = 10 * time.Second
+
+// nextBackoff returns the wait before the next retry, given the wait before
+// the last one: twice as long, but at most maxRetryBackoff. A wait that is
+// already longer is kept.
+func nextBackoff(backoff time.Duration) time.Duration {
+	if backoff >= maxRetryBackoff {
+		return backoff
+	}
+	backoff *= 2
+	if backoff > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return backoff
+}
+
+// /////////////////////////////////////
+//	Square CIDs
+// /////////////////////////////////////
+
+// SquareCIDs returns the CIDs of all the nodes, inner nodes and leaves, of the
+// row and column trees with the given roots, e.g. to pin or unpin a committed
+// square as a unit. Nodes shared by several trees, like the leaves, which are
+// in both a row and a column tree, are only returned once.
+//
+// A tree deeper than expected for the width of the square fails the traversal
+// with ErrMaxDepthExceeded.
+func SquareCIDs(ctx context.Context, rowRoots, colRoots []cid.Cid, api coreiface.CoreAPI) ([]cid.Cid, error) {
+	maxDepth := treeDepth(uint32(len(rowRoots)))
+	seen := cid.NewSet()
+	var cids []cid.Cid
+	visit := func(c cid.Cid) bool {
+		if !seen.Visit(c) {
+			return false
+		}
+		cids = append(cids, c)
+		return true
+	}
+
+	for _, roots := range [][]cid.Cid{rowRoots, colRoots} {
+		for _, root := range roots {
+			if err := traverse(ctx, root, api, maxDepth, visit); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return cids, nil
+}
+
+const (
+	// availabilityProbeTimeout is how long DiffAvailability waits for each
+	// node before considering it missing.
+	availabilityProbeTimeout = time.Second
+	// availabilityProbeWorkers is the maximum number of nodes DiffAvailability
+	// probes at the same time.
+	availabilityProbeWorkers = 64
+)
+
+// DiffAvailability partitions the expected CIDs, e.g. the CIDs of a committed
+// square as returned by SquareCIDs, into the ones of the nodes that can be
+// retrieved and the ones of the missing nodes, e.g. to debug a partially
+// available square. Up to availabilityProbeWorkers nodes are probed
+// concurrently, each for at most availabilityProbeTimeout. Both results keep
+// the order of expected. It
+// returns the context's error if it is done before all the nodes are probed,
+// along with the partition, in which the nodes not probed in time are
+// missing.
+func DiffAvailability(
+	ctx context.Context,
+	expected []cid.Cid,
+	api coreiface.CoreAPI,
+) (present, missing []cid.Cid, err error) {
+	available := make([]bool, len(expected))
+
+	workers := availabilityProbeWorkers
+	if len(expected) < workers {
+		workers = len(expected)
+	}
+	var (
+		wg   sync.WaitGroup
+		jobs = make(chan int)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				probeCtx, cancel := context.WithTimeout(ctx, availabilityProbeTimeout)
+				_, err := api.Block().Stat(probeCtx, path.IpldPath(expected[i]))
+				cancel()
+				available[i] = err == nil
+			}
+		}()
+	}
+
+loop:
+	for i := range expected {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, c := range expected {
+		if available[i] {
+			present = append(present, c)
+		} else {
+			missing = append(missing, c)
+		}
+	}
+	return present, missing, ctx.Err()
+}
+
+// traverse walks the tree with the given root depth-first, calling visit with
+// the CID of every node. The children of a node are skipped if visit returns
+// false for it. It returns ErrMaxDepthExceeded if a node deeper than maxDepth
+// has to be retrieved, the root being at depth 0.
+func traverse(
+	ctx context.Context,
+	root cid.Cid,
+	api coreiface.CoreAPI,
+	maxDepth int,
+	visit func(cid.Cid) bool,
+) error {
+	if maxDepth < 0 {
+		return fmt.Errorf("%w: at node %v", ErrMaxDepthExceeded, root)
+	}
+	if !visit(root) {
+		return nil
+	}
+
+	node, err := api.Dag().Get(ctx, root)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: %v", ErrRetrievalTimeout, ctx.Err())
+		}
+		return fmt.Errorf("can't get node %v: %w", root, err)
+	}
+	for _, link := range node.Links() {
+		// leaves link to themselves
+		if link.Cid.Equals(root) {
+			continue
+		}
+		if err := traverse(ctx, link.Cid, api, maxDepth-1, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resolveLeafData(ctx context.Context, p path.Path, api coreiface.CoreAPI) ([]byte, error) {
 	// resolve the path
 	node, err := api.ResolveNode(ctx, p)
 	if err != nil {
-		return nil, err
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRetrievalTimeout, ctx.Err())
+		}
+		return nil, fmt.Errorf("%w: %v", ErrLeafNotFound, err)
+	}
+
+	// the path might end at an inner node if the tree is smaller than expected
+	data := node.RawData()
+	if len(data) == 0 || data[0] != nmt.LeafPrefix {
+		return nil, fmt.Errorf("%w: %v is not a leaf node", ErrLeafCorrupt, node.Cid())
+	}
+
+	// blocks from the local blockstore are not verified against their cid;
+	// nmt's default hasher is shared and not safe for concurrent use, so a
+	// new one is used for each leaf
+	hasher := nmt.NewNmtHasher(sha256.New(), types.NamespaceSize, true)
+	c, err := nodes.CidFromNamespacedSha256(hasher.HashLeaf(data[1:]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLeafCorrupt, err)
+	}
+	if !c.Equals(node.Cid()) {
+		return nil, fmt.Errorf("%w: hash mismatch, got: %v, want: %v", ErrLeafCorrupt, c, node.Cid())
 	}
 
 	// return the leaf, without the nmt-leaf-or-node byte
-	return node.RawData()[1:], nil
+	return data[1:], nil
 }
 
 func leafPath(index, total uint32) ([]string, error) {
@@ -58,7 +308,11 @@ func leafPath(index, total uint32) ([]string, error) {
 		return nil, nil
 	}
 
-	depth := int(math.Log2(float64(total)))
+	if index >= total {
+		return nil, fmt.Errorf("leaf index %d out of range for %d total leaves", index, total)
+	}
+
+	depth := treeDepth(total)
 	cursor := index
 	path := make([]string, depth)
 	for i := depth - 1; i >= 0; i-- {
@@ -73,6 +327,17 @@ func leafPath(index, total uint32) ([]string, error) {
 	return path, nil
 }
 
+// treeDepth returns the depth of the leaves of a tree with the given number of
+// leaves, i.e. the number of nodes on the path from the root to a leaf,
+// excluding the root. Numbers of leaves which are not a power of 2 are
+// rounded up.
+func treeDepth(total uint32) int {
+	if total <= 1 {
+		return 0
+	}
+	return bits.Len32(total - 1)
+}
+
 // nextPowerOf2 returns the next lowest power of 2 unless the input is a power
 // of two, in which case it returns the input
 func nextPowerOf2(v uint32) uint32 {
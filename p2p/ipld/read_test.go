@@ -1,24 +1,28 @@
 package ipld
 
 import (
-	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
-	"sort"
+	"errors"
 	"strings"
 	"testing"
 	"time"
 
+	blocks "github.com/ipfs/go-block-format"
 	cid "github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs/core/coreapi"
 
 	coremock "github.com/ipfs/go-ipfs/core/mock"
 	format "github.com/ipfs/go-ipld-format"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/ipfs/interface-go-ipfs-core/path"
 	"github.com/lazyledger/lazyledger-core/p2p/ipld/plugin/nodes"
 	"github.com/lazyledger/lazyledger-core/types"
 	"github.com/lazyledger/nmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLeafPath(t *testing.T) {
@@ -50,6 +54,30 @@ func TestLeafPath(t *testing.T) {
 	}
 }
 
+func TestLeafPathInvalidInput(t *testing.T) {
+	type test struct {
+		name         string
+		index, total uint32
+	}
+
+	tests := []test{
+		{"0 index 11 total leaves", 0, 11},
+		{"10 index 11 total leaves", 10, 11},
+		{"16 index 16 total leaves", 16, 16},
+		{"20 index 16 total leaves", 20, 16},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := leafPath(tt.index, tt.total)
+			assert.Error(t, err)
+			assert.Nil(t, result)
+		},
+		)
+	}
+}
+
 func TestNextPowerOf2(t *testing.T) {
 	type test struct {
 		input    uint32
@@ -151,9 +179,281 @@ func TestGetLeafData(t *testing.T) {
 		},
 		)
 	}
+
+	// the total has to be the power of two width of the square
+	_, err = GetLeafData(ctx, rootCid, 0, 11, ipfsAPI)
+	assert.Error(t, err)
+}
+
+// flakyCoreAPI is a CoreAPI whose ResolveNode fails the first failures times.
+type flakyCoreAPI struct {
+	coreiface.CoreAPI
+	failures int
+	calls    int
+}
+
+func (api *flakyCoreAPI) ResolveNode(ctx context.Context, p path.Path) (format.Node, error) {
+	api.calls++
+	if api.calls <= api.failures {
+		return nil, errors.New("transient failure")
+	}
+	return api.CoreAPI.ResolveNode(ctx, p)
+}
+
+func TestGetLeafDataWithRetry(t *testing.T) {
+	ipfsNode, err := coremock.NewMockNode()
+	require.NoError(t, err)
+	ipfsAPI, err := coreapi.NewCoreAPI(ipfsNode)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	batch := format.NewBatch(ctx, ipfsAPI.Dag().Pinning())
+	data := generateRandNamespacedRawData(16, types.NamespaceSize, types.ShareSize)
+	tree, err := createNmtTree(ctx, batch, data)
+	require.NoError(t, err)
+	root := tree.Root()
+	require.NoError(t, batch.Commit())
+	rootCid, err := nodes.CidFromNamespacedSha256(root.Bytes())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// 1. succeeds after the first attempt failed
+	api := &flakyCoreAPI{CoreAPI: ipfsAPI, failures: 1}
+	leaf, err := GetLeafDataWithRetry(ctx, rootCid, 3, 16, api, 2, time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, data[3], leaf)
+	assert.Equal(t, 2, api.calls)
+
+	// 2. gives up after maxRetries
+	api = &flakyCoreAPI{CoreAPI: ipfsAPI, failures: 10}
+	_, err = GetLeafDataWithRetry(ctx, rootCid, 3, 16, api, 2, time.Millisecond)
+	assert.True(t, errors.Is(err, ErrLeafNotFound))
+	assert.Contains(t, err.Error(), "transient failure")
+	assert.Equal(t, 3, api.calls)
+
+	// 3. invalid input is not retried
+	api = &flakyCoreAPI{CoreAPI: ipfsAPI}
+	_, err = GetLeafDataWithRetry(ctx, rootCid, 16, 16, api, 2, time.Millisecond)
+	assert.Error(t, err)
+	assert.Zero(t, api.calls)
+
+	// 4. stops waiting once the context is done
+	api = &flakyCoreAPI{CoreAPI: ipfsAPI, failures: 10}
+	shortCtx, shortCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer shortCancel()
+	_, err = GetLeafDataWithRetry(shortCtx, rootCid, 3, 16, api, 10, time.Hour)
+	assert.True(t, errors.Is(err, ErrRetrievalTimeout))
+	assert.Equal(t, 1, api.calls)
+
+	// 5. corrupt leaves are not retried
+	api = &flakyCoreAPI{CoreAPI: ipfsAPI}
+	_, err = GetLeafDataWithRetry(ctx, rootCid, 3, 8, api, 2, time.Millisecond)
+	assert.True(t, errors.Is(err, ErrLeafCorrupt))
+	assert.Equal(t, 1, api.calls)
+}
+
+func TestNextBackoff(t *testing.T) {
+	tests := []struct {
+		backoff, want time.Duration
+	}{
+		{time.Millisecond, 2 * time.Millisecond},
+		{maxRetryBackoff / 2, maxRetryBackoff},
+		{maxRetryBackoff/2 + 1, maxRetryBackoff},
+		{maxRetryBackoff, maxRetryBackoff},
+		{time.Hour, time.Hour},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, nextBackoff(tt.backoff), "backoff %v", tt.backoff)
+	}
+
+	// the wait doesn't grow without bound, nor overflow
+	backoff := time.Millisecond
+	for i := 0; i < 100; i++ {
+		backoff = nextBackoff(backoff)
+	}
+	assert.Equal(t, maxRetryBackoff, backoff)
+}
+
+func TestGetLeafDataErrors(t *testing.T) {
+	ipfsNode, err := coremock.NewMockNode()
+	require.NoError(t, err)
+	ipfsAPI, err := coreapi.NewCoreAPI(ipfsNode)
+	require.NoError(t, err)
+	offlineAPI, err := ipfsAPI.WithOptions(options.Api.Offline(true))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data := generateRandNamespacedRawData(16, types.NamespaceSize, types.ShareSize)
+	rootCid, err := PutSharesToIPFS(ctx, data, ipfsAPI.Dag())
+	require.NoError(t, err)
+
+	leafCid := func(index uint32) cid.Cid {
+		p, err := leafPath(index, 16)
+		require.NoError(t, err)
+		resolved, err := ipfsAPI.ResolvePath(ctx, path.Join(path.IpldPath(rootCid), p...))
+		require.NoError(t, err)
+		return resolved.Cid()
+	}
+
+	// the path ends at an inner node if the total is too small
+	_, err = GetLeafData(ctx, rootCid, 3, 8, offlineAPI)
+	assert.True(t, errors.Is(err, ErrLeafCorrupt), err)
+
+	// a leaf stored under the cid of another leaf
+	tampered, err := blocks.NewBlockWithCid(append([]byte{nmt.LeafPrefix}, data[2]...), leafCid(1))
+	require.NoError(t, err)
+	require.NoError(t, ipfsNode.Blockstore.DeleteBlock(tampered.Cid()))
+	require.NoError(t, ipfsNode.Blockstore.Put(tampered))
+	_, err = GetLeafData(ctx, rootCid, 1, 16, offlineAPI)
+	assert.True(t, errors.Is(err, ErrLeafCorrupt), err)
+
+	// a missing leaf fails right away with an offline api
+	require.NoError(t, ipfsNode.Blockstore.DeleteBlock(leafCid(5)))
+	_, err = GetLeafData(ctx, rootCid, 5, 16, offlineAPI)
+	assert.True(t, errors.Is(err, ErrLeafNotFound), err)
+
+	// an online api waits for the missing leaf until the deadline
+	shortCtx, shortCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer shortCancel()
+	_, err = GetLeafData(shortCtx, rootCid, 5, 16, ipfsAPI)
+	assert.True(t, errors.Is(err, ErrRetrievalTimeout), err)
+
+	// the other leaves are unaffected
+	got, err := GetLeafData(ctx, rootCid, 0, 16, offlineAPI)
+	require.NoError(t, err)
+	assert.Equal(t, data[0], got)
 }
 
 // nmtcommitment generates the nmt root of some namespaced data
+func TestSquareCIDs(t *testing.T) {
+	ipfsNode, err := coremock.NewMockNode()
+	require.NoError(t, err)
+	ipfsAPI, err := coreapi.NewCoreAPI(ipfsNode)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const width = 4
+	leaves := generateExtendedSquareLeaves(t, width/2)
+	rows := make([][][]byte, width)
+	for i := range rows {
+		rows[i] = leaves[i*width : (i+1)*width]
+	}
+	rowRoots := make([]cid.Cid, width)
+	colRoots := make([]cid.Cid, width)
+	for i := 0; i < width; i++ {
+		rowRoots[i], err = PutSharesToIPFS(ctx, rows[i], ipfsAPI.Dag())
+		require.NoError(t, err)
+		colRoots[i], err = PutSharesToIPFS(ctx, ColumnLeaves(rows, i), ipfsAPI.Dag())
+		require.NoError(t, err)
+	}
+
+	cids, err := SquareCIDs(ctx, rowRoots, colRoots, ipfsAPI)
+	require.NoError(t, err)
+	// every leaf is shared by a row and a column tree, each tree has
+	// width-1 inner nodes of its own
+	assert.Len(t, cids, width*width+2*width*(width-1))
+	unique := cid.NewSet()
+	for _, c := range cids {
+		assert.True(t, unique.Visit(c), "duplicate cid %v", c)
+	}
+	for _, root := range append(rowRoots, colRoots...) {
+		assert.True(t, unique.Has(root))
+	}
+
+	// a missing node fails the traversal
+	offlineAPI, err := ipfsAPI.WithOptions(options.Api.Offline(true))
+	require.NoError(t, err)
+	require.NoError(t, ipfsNode.Blockstore.DeleteBlock(cids[len(cids)-1]))
+	_, err = SquareCIDs(ctx, rowRoots, colRoots, offlineAPI)
+	assert.Error(t, err)
+}
+
+func TestDiffAvailability(t *testing.T) {
+	ipfsNode, err := coremock.NewMockNode()
+	require.NoError(t, err)
+	ipfsAPI, err := coreapi.NewCoreAPI(ipfsNode)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const width = 4
+	leaves := generateExtendedSquareLeaves(t, width/2)
+	rows := make([][][]byte, width)
+	for i := range rows {
+		rows[i] = leaves[i*width : (i+1)*width]
+	}
+	rowRoots := make([]cid.Cid, width)
+	colRoots := make([]cid.Cid, width)
+	for i := 0; i < width; i++ {
+		rowRoots[i], err = PutSharesToIPFS(ctx, rows[i], ipfsAPI.Dag())
+		require.NoError(t, err)
+		colRoots[i], err = PutSharesToIPFS(ctx, ColumnLeaves(rows, i), ipfsAPI.Dag())
+		require.NoError(t, err)
+	}
+	expected, err := SquareCIDs(ctx, rowRoots, colRoots, ipfsAPI)
+	require.NoError(t, err)
+
+	// probe offline, so missing nodes aren't searched for in the network
+	offlineAPI, err := ipfsAPI.WithOptions(options.Api.Offline(true))
+	require.NoError(t, err)
+
+	present, missing, err := DiffAvailability(ctx, expected, offlineAPI)
+	require.NoError(t, err)
+	assert.Equal(t, expected, present)
+	assert.Empty(t, missing)
+
+	// delete a root, an inner node and a leaf
+	deleted := []cid.Cid{expected[0], expected[1], expected[len(expected)-1]}
+	for _, c := range deleted {
+		require.NoError(t, ipfsNode.Blockstore.DeleteBlock(c))
+	}
+	present, missing, err = DiffAvailability(ctx, expected, offlineAPI)
+	require.NoError(t, err)
+	assert.Equal(t, deleted, missing)
+	assert.Equal(t, expected[2:len(expected)-1], present)
+}
+
+func TestTraverseMaxDepth(t *testing.T) {
+	ipfsNode, err := coremock.NewMockNode()
+	require.NoError(t, err)
+	ipfsAPI, err := coreapi.NewCoreAPI(ipfsNode)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// a tree of 8 leaves has its leaves at depth 3
+	deepRoot, err := PutSharesToIPFS(ctx, generateRandNamespacedRawData(8, types.NamespaceSize, types.ShareSize), ipfsAPI.Dag())
+	require.NoError(t, err)
+
+	visited := 0
+	visit := func(cid.Cid) bool { visited++; return true }
+	require.NoError(t, traverse(ctx, deepRoot, ipfsAPI, 3, visit))
+	assert.Equal(t, 15, visited)
+	err = traverse(ctx, deepRoot, ipfsAPI, 2, visit)
+	assert.True(t, errors.Is(err, ErrMaxDepthExceeded), err)
+
+	// among the roots of a square of width 4, the tree is too deep
+	shallowRoot, err := PutSharesToIPFS(ctx, generateRandNamespacedRawData(4, types.NamespaceSize, types.ShareSize), ipfsAPI.Dag())
+	require.NoError(t, err)
+	roots := []cid.Cid{shallowRoot, shallowRoot, shallowRoot, shallowRoot}
+	_, err = SquareCIDs(ctx, roots, roots, ipfsAPI)
+	require.NoError(t, err)
+	roots[2] = deepRoot
+	_, err = SquareCIDs(ctx, roots, roots, ipfsAPI)
+	assert.True(t, errors.Is(err, ErrMaxDepthExceeded), err)
+}
+
+func TestTreeDepth(t *testing.T) {
+	for total, want := range map[uint32]int{0: 0, 1: 0, 2: 1, 4: 2, 5: 3, 128: 7} {
+		assert.Equal(t, want, treeDepth(total), "total %d", total)
+	}
+}
+
 func createNmtTree(
 	ctx context.Context,
 	batch *format.Batch,
@@ -173,29 +473,9 @@ func createNmtTree(
 
 // this code is copy pasted from the plugin, and should likely be exported in the plugin instead
 func generateRandNamespacedRawData(total int, nidSize int, leafSize int) [][]byte {
-	data := make([][]byte, total)
-	for i := 0; i < total; i++ {
-		nid := make([]byte, nidSize)
-		_, err := rand.Read(nid)
-		if err != nil {
-			panic(err)
-		}
-		data[i] = nid
-	}
-
-	sortByteArrays(data)
-	for i := 0; i < total; i++ {
-		d := make([]byte, leafSize)
-		_, err := rand.Read(d)
-		if err != nil {
-			panic(err)
-		}
-		data[i] = append(data[i], d...)
+	data, err := GenerateRandNamespacedRawData(rand.Reader, total, nidSize, leafSize)
+	if err != nil {
+		panic(err)
 	}
-
 	return data
 }
-
-func sortByteArrays(src [][]byte) {
-	sort.Slice(src, func(i, j int) bool { return bytes.Compare(src[i], src[j]) < 0 })
-}
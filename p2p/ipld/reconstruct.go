@@ -0,0 +1,354 @@
+package ipld
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+	"github.com/lazyledger/lazyledger-core/p2p/ipld/plugin/nodes"
+	"github.com/lazyledger/lazyledger-core/types"
+	"github.com/lazyledger/nmt"
+	"github.com/lazyledger/nmt/namespace"
+	"github.com/lazyledger/rsmt2d"
+)
+
+// ErrUnrepairableSquare is returned by ReconstructSquare if too few leaves of
+// the square could be retrieved to recover the missing ones.
+var ErrUnrepairableSquare = errors.New("not enough leaves to reconstruct the square")
+
+// /////////////////////////////////////
+//	Reconstruct Square
+// /////////////////////////////////////
+
+// ReconstructSquare retrieves the leaves of the extended data square with the
+// given row and column roots and recovers the missing ones by erasure decoding
+// the rows and columns with enough leaves, until the square is complete. The
+// complete square is verified against all the roots. It returns the leaves of
+// the square, i.e. the shares prefixed with their namespace, row by row.
+//
+// Leaves that can't be retrieved through the api are treated as missing. Note
+// that an online api might wait for a missing leaf until the provided context
+// is cancelled, in which case the context's error is returned.
+//
+// If progress is not nil, it is called each time a leaf is retrieved.
+func ReconstructSquare(
+	ctx context.Context,
+	rowRoots []cid.Cid,
+	colRoots []cid.Cid,
+	api coreiface.CoreAPI,
+	progress ProgressFunc,
+) ([][]byte, error) {
+	if len(rowRoots) != len(colRoots) {
+		return nil, fmt.Errorf("number of row roots (%d) and column roots (%d) differ", len(rowRoots), len(colRoots))
+	}
+	width := uint32(len(rowRoots))
+	if width < 2 || width != nextPowerOf2(width) {
+		return nil, fmt.Errorf("invalid square width %d, must be a power of 2 and at least 2", width)
+	}
+
+	leaves, err := retrieveLeaves(ctx, rowRoots, api, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	// the erasure coding only covers the shares, not the namespaces
+	shares := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		if leaf != nil {
+			shares[i] = leaf[types.NamespaceSize:]
+		}
+	}
+	if err := solveSquare(shares, width); err != nil {
+		return nil, err
+	}
+
+	// add the namespaces back to the recovered shares
+	originalWidth := width / 2
+	for r := uint32(0); r < width; r++ {
+		for c := uint32(0); c < width; c++ {
+			if leaves[r*width+c] != nil {
+				continue
+			}
+			nid := namespace.ID(types.ParitySharesNamespaceID)
+			if r < originalWidth && c < originalWidth {
+				nid, err = leafNamespace(ctx, rowRoots[r], colRoots[c], r, c, width, api)
+				if err != nil {
+					return nil, err
+				}
+			}
+			leaf := make([]byte, 0, types.NamespaceSize+len(shares[r*width+c]))
+			leaf = append(leaf, nid...)
+			leaves[r*width+c] = append(leaf, shares[r*width+c]...)
+		}
+	}
+
+	for i := uint32(0); i < width; i++ {
+		col := make([][]byte, width)
+		for j := uint32(0); j < width; j++ {
+			col[j] = leaves[j*width+i]
+		}
+		if err := VerifyRow(leaves[i*width:(i+1)*width], rowRoots[i], types.NamespaceSize); err != nil {
+			return nil, fmt.Errorf("row %d: %w", i, err)
+		}
+		if err := VerifyRow(col, colRoots[i], types.NamespaceSize); err != nil {
+			return nil, fmt.Errorf("column %d: %w", i, err)
+		}
+	}
+
+	return leaves, nil
+}
+
+// ProgressFunc is called by the functions retrieving many leaves each time a
+// leaf is retrieved, with the number of leaves retrieved so far and the total
+// number of leaves to retrieve. The calls are serialized, so retrieved
+// increases by one with each call. It only reaches total if no leaf is
+// missing.
+type ProgressFunc func(retrieved, total int)
+
+// progressTracker serializes the calls of a ProgressFunc from the goroutines
+// retrieving leaves concurrently.
+type progressTracker struct {
+	mtx       sync.Mutex
+	progress  ProgressFunc
+	retrieved int
+	total     int
+}
+
+func newProgressTracker(progress ProgressFunc, total int) *progressTracker {
+	return &progressTracker{progress: progress, total: total}
+}
+
+func (p *progressTracker) leafRetrieved() {
+	if p.progress == nil {
+		return
+	}
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.retrieved++
+	p.progress(p.retrieved, p.total)
+}
+
+// RetrieveRow fetches the leaves of the row (or column) of the given width
+// with the given root, concurrently. Leaves that can't be retrieved are nil.
+// If progress is not nil, it is called each time a leaf is retrieved.
+func RetrieveRow(
+	ctx context.Context,
+	rowRoot cid.Cid,
+	width uint32,
+	api coreiface.CoreAPI,
+	progress ProgressFunc,
+) ([][]byte, error) {
+	leaves := make([][]byte, width)
+	tracker := newProgressTracker(progress, int(width))
+
+	var wg sync.WaitGroup
+	for c := uint32(0); c < width; c++ {
+		wg.Add(1)
+		go func(c uint32) {
+			defer wg.Done()
+			leaf, err := GetLeafData(ctx, rowRoot, c, width, api)
+			if err != nil {
+				return
+			}
+			leaves[c] = leaf
+			tracker.leafRetrieved()
+		}(c)
+	}
+	wg.Wait()
+
+	return leaves, ctx.Err()
+}
+
+// retrieveLeaves fetches all the leaves of the square with the given row
+// roots, concurrently for each row. Missing leaves are nil.
+func retrieveLeaves(
+	ctx context.Context,
+	rowRoots []cid.Cid,
+	api coreiface.CoreAPI,
+	progress ProgressFunc,
+) ([][]byte, error) {
+	width := uint32(len(rowRoots))
+	leaves := make([][]byte, width*width)
+	tracker := newProgressTracker(progress, len(leaves))
+
+	var wg sync.WaitGroup
+	for r := uint32(0); r < width; r++ {
+		wg.Add(1)
+		go func(r uint32) {
+			defer wg.Done()
+			for c := uint32(0); c < width; c++ {
+				leaf, err := GetLeafData(ctx, rowRoots[r], c, width, api)
+				if err != nil || len(leaf) != types.NamespaceSize+types.ShareSize {
+					continue
+				}
+				leaves[r*width+c] = leaf
+				tracker.leafRetrieved()
+			}
+		}(r)
+	}
+	wg.Wait()
+
+	return leaves, ctx.Err()
+}
+
+// solveSquare fills in the missing (nil) shares of the extended square of the
+// given width, by repeatedly decoding every incomplete row and column that
+// has at least half of its shares, until no share is missing.
+func solveSquare(shares [][]byte, width uint32) error {
+	originalWidth := width / 2
+	for {
+		solved, progressMade := true, false
+		for i := uint32(0); i < width; i++ {
+			for _, isRow := range []bool{true, false} {
+				index := func(j uint32) uint32 {
+					if isRow {
+						return i*width + j
+					}
+					return j*width + i
+				}
+
+				vector := make([][]byte, width)
+				present := uint32(0)
+				for j := uint32(0); j < width; j++ {
+					vector[j] = shares[index(j)]
+					if vector[j] != nil {
+						present++
+					}
+				}
+				if present == width {
+					continue
+				}
+				if present < originalWidth {
+					solved = false
+					continue
+				}
+
+				original, err := rsmt2d.Decode(vector, rsmt2d.RSGF8)
+				if err != nil {
+					return err
+				}
+				parity, err := rsmt2d.Encode(original, rsmt2d.RSGF8)
+				if err != nil {
+					return err
+				}
+				for j := uint32(0); j < width; j++ {
+					if shares[index(j)] != nil {
+						continue
+					}
+					if j < originalWidth {
+						shares[index(j)] = original[j]
+					} else {
+						shares[index(j)] = parity[j-originalWidth]
+					}
+				}
+				progressMade = true
+			}
+		}
+
+		if solved {
+			return nil
+		} else if !progressMade {
+			return ErrUnrepairableSquare
+		}
+	}
+}
+
+// leafNamespace returns the namespace of the leaf at row r and column c of the
+// original data, without retrieving the leaf: the CIDs of nmt nodes contain
+// their namespace range, so it is taken from the link to the leaf in the row
+// or column tree.
+func leafNamespace(
+	ctx context.Context,
+	rowRoot, colRoot cid.Cid,
+	r, c, width uint32,
+	api coreiface.CoreAPI,
+) (namespace.ID, error) {
+	var err error
+	for _, lookup := range []struct {
+		root  cid.Cid
+		index uint32
+	}{{rowRoot, c}, {colRoot, r}} {
+		var p []string
+		p, err = leafPath(lookup.index, width)
+		if err != nil {
+			return nil, err
+		}
+
+		var resolved path.Resolved
+		resolved, err = api.ResolvePath(ctx, path.Join(path.IpldPath(lookup.root), p...))
+		if err != nil {
+			continue
+		}
+
+		var hash []byte
+		hash, err = nodes.NamespacedSha256FromCID(resolved.Cid())
+		if err != nil {
+			continue
+		}
+		return hash[:types.NamespaceSize], nil
+	}
+	return nil, fmt.Errorf("can't determine the namespace of leaf (%d, %d): %w", r, c, err)
+}
+
+// VerifyRow checks that the nmt of the given leaves of a row (or column),
+// each prefixed with a namespace of nsSize bytes, has the root with the given
+// CID. On mismatch, the returned error contains both the computed and the
+// expected root.
+func VerifyRow(leaves [][]byte, rootCid cid.Cid, nsSize int) error {
+	if nsSize <= 0 {
+		return fmt.Errorf("invalid namespace size: %d", nsSize)
+	}
+	root, err := nmtRoot(leaves, nsSize)
+	if err != nil {
+		return err
+	}
+	got, err := nodes.CidFromNamespacedSha256(root)
+	if err != nil {
+		return err
+	}
+	if !got.Equals(rootCid) {
+		return fmt.Errorf("root mismatch, computed: %v, expected: %v", got, rootCid)
+	}
+	return nil
+}
+
+// VerifyNamespaceAbsence checks that the given nmt proof proves that no leaf
+// of namespace nID is included in the tree with the root with the given CID.
+// It returns an error if the proof doesn't verify or if it is a proof of
+// inclusion rather than of absence.
+func VerifyNamespaceAbsence(nID namespace.ID, proof nmt.Proof, rootCid cid.Cid) error {
+	hash, err := nodes.NamespacedSha256FromCID(rootCid)
+	if err != nil {
+		return err
+	}
+	nsSize := nID.Size()
+	if len(hash) != 2*int(nsSize)+sha256.Size {
+		return fmt.Errorf("root %v doesn't match namespace size %d", rootCid, nsSize)
+	}
+	root := namespace.IntervalDigestFromBytes(nsSize, hash)
+
+	// an empty range proof proves absence only if the namespace is outside the
+	// range of the tree
+	if !proof.IsNonEmptyRange() && len(proof.Nodes()) == 0 && !proof.IsOfAbsence() {
+		if nID.Less(root.Min()) || root.Max().Less(nID) {
+			return nil
+		}
+		return fmt.Errorf("namespace %X is within the range of root %v, the proof is empty", nID, rootCid)
+	}
+	if !proof.IsOfAbsence() {
+		return fmt.Errorf("not a proof of absence of namespace %X", nID)
+	}
+	// the leaf of the proof is the first one after the absent namespace
+	if leafHash := proof.LeafHash(); len(leafHash) < int(nsSize) || !nID.Less(leafHash[:nsSize]) {
+		return fmt.Errorf("invalid proof of absence of namespace %X: leaf namespace is not greater", nID)
+	}
+	if !proof.VerifyNamespace(sha256.New(), nID, nil, root) {
+		return fmt.Errorf("invalid proof of absence of namespace %X for root %v", nID, rootCid)
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package ipld
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipfs/core/coreapi"
+	coremock "github.com/ipfs/go-ipfs/core/mock"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+	"github.com/lazyledger/nmt"
+	"github.com/lazyledger/nmt/namespace"
+	"github.com/lazyledger/rsmt2d"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lazyledger/lazyledger-core/p2p/ipld/plugin/nodes"
+	"github.com/lazyledger/lazyledger-core/types"
+)
+
+func TestReconstructSquare(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name    string
+		remove  func(r, c uint32) bool
+		wantErr error
+	}{
+		{"nothing missing", func(r, c uint32) bool { return false }, nil},
+		{"every third leaf missing", func(r, c uint32) bool { return (r+c)%3 == 0 }, nil},
+		{"original data missing", func(r, c uint32) bool { return r < 4 && c < 4 }, nil},
+		{"too many leaves missing", func(r, c uint32) bool { return r < 5 && c < 5 }, ErrUnrepairableSquare},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ipfsNode, err := coremock.NewMockNode()
+			require.NoError(t, err)
+			ipfsAPI, err := coreapi.NewCoreAPI(ipfsNode)
+			require.NoError(t, err)
+
+			leaves := generateExtendedSquareLeaves(t, 4)
+			width := uint32(8)
+			rowRoots := make([]cid.Cid, width)
+			colRoots := make([]cid.Cid, width)
+			for i := uint32(0); i < width; i++ {
+				col := make([][]byte, width)
+				for j := uint32(0); j < width; j++ {
+					col[j] = leaves[j*width+i]
+				}
+				rowRoots[i], err = PutSharesToIPFS(ctx, leaves[i*width:(i+1)*width], ipfsAPI.Dag())
+				require.NoError(t, err)
+				colRoots[i], err = PutSharesToIPFS(ctx, col, ipfsAPI.Dag())
+				require.NoError(t, err)
+			}
+
+			// the leaves are shared by the row and column trees
+			removed := 0
+			for r := uint32(0); r < width; r++ {
+				for c := uint32(0); c < width; c++ {
+					if !tt.remove(r, c) {
+						continue
+					}
+					p, err := leafPath(c, width)
+					require.NoError(t, err)
+					resolved, err := ipfsAPI.ResolvePath(ctx, path.Join(path.IpldPath(rowRoots[r]), p...))
+					require.NoError(t, err)
+					require.NoError(t, ipfsNode.Blockstore.DeleteBlock(resolved.Cid()))
+					removed++
+				}
+			}
+
+			// an offline api fails right away for the removed leaves
+			offlineAPI, err := ipfsAPI.WithOptions(options.Api.Offline(true))
+			require.NoError(t, err)
+			retrieved, err := retrieveLeaves(ctx, rowRoots, offlineAPI, nil)
+			require.NoError(t, err)
+			missing := 0
+			for _, leaf := range retrieved {
+				if leaf == nil {
+					missing++
+				}
+			}
+			require.Equal(t, removed, missing)
+
+			var lastRetrieved, lastTotal int
+			progress := func(retrieved, total int) {
+				assert.Equal(t, lastRetrieved+1, retrieved)
+				lastRetrieved, lastTotal = retrieved, total
+			}
+			got, err := ReconstructSquare(ctx, rowRoots, colRoots, offlineAPI, progress)
+			assert.Equal(t, len(leaves)-removed, lastRetrieved)
+			assert.Equal(t, len(leaves), lastTotal)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, leaves, got)
+		})
+	}
+}
+
+func TestReconstructSquareInvalidRoots(t *testing.T) {
+	ipfsNode, err := coremock.NewMockNode()
+	require.NoError(t, err)
+	ipfsAPI, err := coreapi.NewCoreAPI(ipfsNode)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = ReconstructSquare(ctx, make([]cid.Cid, 4), make([]cid.Cid, 2), ipfsAPI, nil)
+	assert.Error(t, err)
+	_, err = ReconstructSquare(ctx, make([]cid.Cid, 6), make([]cid.Cid, 6), ipfsAPI, nil)
+	assert.Error(t, err)
+}
+
+func TestRetrieveRowProgress(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ipfsNode, err := coremock.NewMockNode()
+	require.NoError(t, err)
+	ipfsAPI, err := coreapi.NewCoreAPI(ipfsNode)
+	require.NoError(t, err)
+
+	const width = 16
+	leaves := generateRandNamespacedRawData(width, types.NamespaceSize, types.ShareSize)
+	rowRoot, err := PutSharesToIPFS(ctx, leaves, ipfsAPI.Dag())
+	require.NoError(t, err)
+
+	// the calls are serialized, so appending without a lock is safe; running
+	// marks a call in progress to catch overlapping calls
+	var (
+		calls   [][2]int
+		running int32
+	)
+	got, err := RetrieveRow(ctx, rowRoot, width, ipfsAPI, func(retrieved, total int) {
+		if !atomic.CompareAndSwapInt32(&running, 0, 1) {
+			t.Error("progress called concurrently")
+			return
+		}
+		defer atomic.StoreInt32(&running, 0)
+		calls = append(calls, [2]int{retrieved, total})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, leaves, got)
+
+	// each leaf is reported once, in strictly increasing order, against the
+	// full width
+	require.Len(t, calls, width)
+	seen := make(map[int]bool, width)
+	prev := 0
+	for i, call := range calls {
+		retrieved, total := call[0], call[1]
+		assert.Equal(t, width, total, "call %d", i)
+		assert.Greater(t, retrieved, prev, "call %d", i)
+		assert.False(t, seen[retrieved], "call %d: %d reported twice", i, retrieved)
+		seen[retrieved] = true
+		prev = retrieved
+	}
+	assert.Equal(t, width, prev)
+
+	// the callback is optional
+	got, err = RetrieveRow(ctx, rowRoot, width, ipfsAPI, nil)
+	require.NoError(t, err)
+	assert.Equal(t, leaves, got)
+}
+
+func TestVerifyRow(t *testing.T) {
+	leaves := generateRandNamespacedRawData(8, types.NamespaceSize, types.ShareSize)
+	tree := nmt.New(sha256.New(), nmt.NamespaceIDSize(types.NamespaceSize))
+	for _, leaf := range leaves {
+		require.NoError(t, tree.Push(leaf[:types.NamespaceSize], leaf[types.NamespaceSize:]))
+	}
+	rootCid, err := nodes.CidFromNamespacedSha256(tree.Root().Bytes())
+	require.NoError(t, err)
+
+	assert.NoError(t, VerifyRow(leaves, rootCid, types.NamespaceSize))
+
+	// a single flipped byte of a share changes the root
+	leaves[3][types.NamespaceSize] ^= 0xFF
+	err = VerifyRow(leaves, rootCid, types.NamespaceSize)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "root mismatch")
+	assert.Contains(t, err.Error(), rootCid.String())
+
+	assert.Error(t, VerifyRow(leaves, rootCid, 0))
+	assert.Error(t, VerifyRow([][]byte{{1, 2}}, rootCid, types.NamespaceSize))
+}
+
+func TestVerifyNamespaceAbsence(t *testing.T) {
+	nid := func(b byte) namespace.ID {
+		id := make(namespace.ID, types.NamespaceSize)
+		id[types.NamespaceSize-1] = b
+		return id
+	}
+	tree := nmt.New(sha256.New(), nmt.NamespaceIDSize(types.NamespaceSize))
+	for _, b := range []byte{1, 1, 3, 5} {
+		require.NoError(t, tree.Push(nid(b), []byte{b, 0xAA}))
+	}
+	rootCid, err := nodes.CidFromNamespacedSha256(tree.Root().Bytes())
+	require.NoError(t, err)
+
+	absent, err := tree.ProveNamespace(nid(2))
+	require.NoError(t, err)
+	require.True(t, absent.IsOfAbsence())
+	assert.NoError(t, VerifyNamespaceAbsence(nid(2), absent, rootCid))
+
+	// a namespace outside the range of the tree has an empty range proof
+	outside, err := tree.ProveNamespace(nid(9))
+	require.NoError(t, err)
+	assert.NoError(t, VerifyNamespaceAbsence(nid(9), outside, rootCid))
+	assert.Error(t, VerifyNamespaceAbsence(nid(2), outside, rootCid))
+
+	// a proof of inclusion isn't a proof of absence
+	included, err := tree.ProveNamespace(nid(3))
+	require.NoError(t, err)
+	assert.Error(t, VerifyNamespaceAbsence(nid(3), included, rootCid))
+
+	// the proof of absence of one namespace doesn't prove the absence of another
+	assert.Error(t, VerifyNamespaceAbsence(nid(4), absent, rootCid))
+
+	// a tampered proof doesn't verify
+	leafHash := append([]byte(nil), absent.LeafHash()...)
+	leafHash[len(leafHash)-1] ^= 0xFF
+	tampered := nmt.NewAbsenceProof(absent.Start(), absent.End(), absent.Nodes(), leafHash, absent.IsMaxNamespaceIDIgnored())
+	assert.Error(t, VerifyNamespaceAbsence(nid(2), tampered, rootCid))
+}
+
+// generateExtendedSquareLeaves returns the leaves of a random extended data
+// square with the given original width, row by row, like they are put on
+// IPFS by types.Block.PutBlock.
+func generateExtendedSquareLeaves(t *testing.T, originalWidth int) [][]byte {
+	data := generateRandNamespacedRawData(originalWidth*originalWidth, types.NamespaceSize, types.ShareSize)
+	shares := make([][]byte, len(data))
+	for i, leaf := range data {
+		shares[i] = leaf[types.NamespaceSize:]
+	}
+	eds, err := rsmt2d.ComputeExtendedDataSquare(shares, rsmt2d.RSGF8, rsmt2d.NewDefaultTree)
+	require.NoError(t, err)
+
+	width := eds.Width()
+	leaves := make([][]byte, 0, width*width)
+	for r := uint(0); r < width; r++ {
+		for c, share := range eds.Row(r) {
+			if r < uint(originalWidth) && c < originalWidth {
+				leaves = append(leaves, data[int(r)*originalWidth+c])
+				continue
+			}
+			leaf := append([]byte{}, types.ParitySharesNamespaceID...)
+			leaves = append(leaves, append(leaf, share...))
+		}
+	}
+	return leaves
+}
@@ -0,0 +1,262 @@
+package ipld
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/lazyledger/lazyledger-core/types"
+	"github.com/lazyledger/nmt"
+	"github.com/lazyledger/nmt/namespace"
+)
+
+// MakeNamespacedShare returns the NMT leaf for the given namespace and share
+// data, i.e. the namespace prepended to the data. It returns an error if the
+// namespace is not types.NamespaceSize bytes long or if the data is not
+// types.ShareSize bytes long.
+func MakeNamespacedShare(nid namespace.ID, data []byte) ([]byte, error) {
+	if len(nid) != types.NamespaceSize {
+		return nil, fmt.Errorf("invalid namespace length, got: %d, want: %d", len(nid), types.NamespaceSize)
+	}
+	if len(data) != types.ShareSize {
+		return nil, fmt.Errorf("invalid share length, got: %d, want: %d", len(data), types.ShareSize)
+	}
+
+	share := make([]byte, 0, types.NamespaceSize+types.ShareSize)
+	share = append(share, nid...)
+	return append(share, data...), nil
+}
+
+// ValidateRowNamespaces checks that the given leaves of a row, each prefixed
+// with a namespace of nsSize bytes, are sorted by namespace as required by the
+// NMT. It returns an error with the index of the first leaf that is too short
+// to contain a namespace or whose namespace is smaller than the one before it.
+func ValidateRowNamespaces(leaves [][]byte, nsSize int) error {
+	if nsSize <= 0 {
+		return fmt.Errorf("invalid namespace size: %d", nsSize)
+	}
+	for i, leaf := range leaves {
+		if len(leaf) < nsSize {
+			return fmt.Errorf("leaf %d is too short to contain a namespace, got: %d bytes, want at least: %d",
+				i, len(leaf), nsSize)
+		}
+		if i > 0 && bytes.Compare(leaf[:nsSize], leaves[i-1][:nsSize]) < 0 {
+			return fmt.Errorf("leaf %d is out of order: namespace %X is smaller than previous namespace %X",
+				i, leaf[:nsSize], leaves[i-1][:nsSize])
+		}
+	}
+	return nil
+}
+
+// IsPaddingShare returns true if the given share, prefixed with a namespace of
+// nsSize bytes, is in paddingNamespace (e.g. types.TailPaddingNamespaceID).
+// Shares too short to contain a namespace are not padding.
+func IsPaddingShare(share []byte, paddingNamespace namespace.ID, nsSize int) bool {
+	if nsSize <= 0 || len(share) < nsSize {
+		return false
+	}
+	return paddingNamespace.Equal(share[:nsSize])
+}
+
+// CountRealShares returns the number of shares of the given row that are not
+// padding shares, as determined by IsPaddingShare.
+func CountRealShares(row [][]byte, paddingNamespace namespace.ID, nsSize int) int {
+	count := 0
+	for _, share := range row {
+		if !IsPaddingShare(share, paddingNamespace, nsSize) {
+			count++
+		}
+	}
+	return count
+}
+
+// ValidateSquareSize returns the edge length of the square formed by
+// shareCount shares. It returns an error if shareCount is not the square of a
+// power of 2, as required to erasure code and extend the square.
+func ValidateSquareSize(shareCount int) (int, error) {
+	if shareCount <= 0 {
+		return 0, fmt.Errorf("invalid share count: %d, must be positive", shareCount)
+	}
+	edgeLen := int(math.Sqrt(float64(shareCount)))
+	if edgeLen*edgeLen != shareCount {
+		return 0, fmt.Errorf("share count %d is not a perfect square", shareCount)
+	}
+	if edgeLen&(edgeLen-1) != 0 {
+		return 0, fmt.Errorf("square edge length %d (share count %d) is not a power of 2", edgeLen, shareCount)
+	}
+	return edgeLen, nil
+}
+
+// FitSquareSize returns the edge length of the smallest square with a power
+// of 2 edge length that fits totalShares shares, e.g. to build the square of
+// a block from the shares of the reaped txs. It returns an error if that edge
+// length exceeds maxEdge, the maximum allowed by consensus, as a block with a
+// larger square is invalid.
+func FitSquareSize(totalShares int, maxEdge int) (edge int, err error) {
+	if totalShares <= 0 {
+		return 0, fmt.Errorf("invalid share count: %d, must be positive", totalShares)
+	}
+	if maxEdge <= 0 {
+		return 0, fmt.Errorf("invalid max edge length: %d, must be positive", maxEdge)
+	}
+	edge = 1
+	for edge*edge < totalShares {
+		if edge > maxEdge {
+			break
+		}
+		edge *= 2
+	}
+	if edge > maxEdge {
+		return 0, fmt.Errorf("%d shares need a square edge length of at least %d, exceeding the max of %d",
+			totalShares, edge, maxEdge)
+	}
+	return edge, nil
+}
+
+// RangeIntersects returns true if the namespace range [nodeMin, nodeMax] of an
+// nmt node intersects the queried namespace range [queryMin, queryMax]. Both
+// ranges are inclusive. A subtree whose root doesn't intersect the query range
+// can't contain any leaf of the queried namespaces and doesn't need to be
+// traversed.
+func RangeIntersects(nodeMin, nodeMax, queryMin, queryMax namespace.ID) bool {
+	return nodeMin.LessOrEqual(queryMax) && queryMin.LessOrEqual(nodeMax)
+}
+
+// ColumnLeaves returns the leaves of column colIndex of the square with the
+// given rows, i.e. the colIndex-th leaf of each row, in row order. The leaves
+// are not copied. It returns nil if colIndex is out of range for any row.
+func ColumnLeaves(rows [][][]byte, colIndex int) [][]byte {
+	if colIndex < 0 {
+		return nil
+	}
+	col := make([][]byte, len(rows))
+	for i, row := range rows {
+		if colIndex >= len(row) {
+			return nil
+		}
+		col[i] = row[colIndex]
+	}
+	return col
+}
+
+// ComputeSquareRoots returns the nmt roots of the rows and columns of the
+// square of edgeLen x edgeLen shares, given row by row and each prefixed with
+// a namespace of nsSize bytes. The trees are computed in memory, nothing is
+// added to a dag.
+func ComputeSquareRoots(shares [][]byte, edgeLen int, nsSize int) (rowRoots, colRoots [][]byte, err error) {
+	if edgeLen <= 0 {
+		return nil, nil, fmt.Errorf("invalid edge length: %d", edgeLen)
+	}
+	if len(shares) != edgeLen*edgeLen {
+		return nil, nil, fmt.Errorf("got %d shares, want %d for edge length %d", len(shares), edgeLen*edgeLen, edgeLen)
+	}
+	if nsSize <= 0 {
+		return nil, nil, fmt.Errorf("invalid namespace size: %d", nsSize)
+	}
+
+	rows := make([][][]byte, edgeLen)
+	for i := range rows {
+		rows[i] = shares[i*edgeLen : (i+1)*edgeLen]
+	}
+	rowRoots = make([][]byte, edgeLen)
+	colRoots = make([][]byte, edgeLen)
+	for i := 0; i < edgeLen; i++ {
+		if rowRoots[i], err = nmtRoot(rows[i], nsSize); err != nil {
+			return nil, nil, fmt.Errorf("row %d: %w", i, err)
+		}
+		if colRoots[i], err = nmtRoot(ColumnLeaves(rows, i), nsSize); err != nil {
+			return nil, nil, fmt.Errorf("column %d: %w", i, err)
+		}
+	}
+	return rowRoots, colRoots, nil
+}
+
+// nmtRoot returns the root of the nmt of the given namespaced leaves.
+func nmtRoot(leaves [][]byte, nsSize int) ([]byte, error) {
+	tree := nmt.New(sha256.New(), nmt.NamespaceIDSize(nsSize))
+	for i, leaf := range leaves {
+		if len(leaf) < nsSize {
+			return nil, fmt.Errorf("leaf %d is too short to contain a namespace, got: %d bytes, want at least: %d",
+				i, len(leaf), nsSize)
+		}
+		if err := tree.Push(leaf[:nsSize], leaf[nsSize:]); err != nil {
+			return nil, fmt.Errorf("leaf %d: %w", i, err)
+		}
+	}
+	return tree.Root().Bytes(), nil
+}
+
+// GenerateRandNamespacedRawData returns total leaves of a namespace of nidSize
+// bytes followed by leafSize bytes of data, sorted by namespace, with the
+// randomness read from r. Leaves with equal namespaces keep the order in which
+// they were generated, so the output is the same for the same randomness,
+// e.g. a math/rand source with a fixed seed.
+func GenerateRandNamespacedRawData(r io.Reader, total, nidSize, leafSize int) ([][]byte, error) {
+	data := make([][]byte, total)
+	for i := range data {
+		data[i] = make([]byte, nidSize+leafSize)
+		if _, err := io.ReadFull(r, data[i]); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(data, func(i, j int) bool {
+		return bytes.Compare(data[i][:nidSize], data[j][:nidSize]) < 0
+	})
+	return data, nil
+}
+
+// ReassembleTxs parses the txs from the given shares, each prefixed with a
+// namespace of nsSize bytes, in the order they appear in the square. Every tx
+// is length-delimited and starts at the beginning of a share; a tx longer than
+// a share continues in the data of the following shares and the remainder of
+// its last share is zero padding. A zero length delimiter marks the padding at
+// the end of the txs and ends the parsing. It returns an error if a delimiter
+// is malformed or a tx is truncated.
+func ReassembleTxs(shares [][]byte, nsSize int) (types.Txs, error) {
+	if nsSize <= 0 {
+		return nil, fmt.Errorf("invalid namespace size: %d", nsSize)
+	}
+	payloads := make([][]byte, len(shares))
+	for i, share := range shares {
+		if len(share) <= nsSize {
+			return nil, fmt.Errorf("share %d is too short to contain data, got: %d bytes, want more than: %d",
+				i, len(share), nsSize)
+		}
+		payloads[i] = share[nsSize:]
+	}
+
+	txs := make(types.Txs, 0)
+	for i := 0; i < len(payloads); {
+		length, n := binary.Uvarint(payloads[i])
+		if n <= 0 {
+			return nil, fmt.Errorf("share %d: malformed tx length delimiter", i)
+		}
+		if length == 0 {
+			break
+		}
+
+		start := i
+		var tx []byte
+		data := payloads[i][n:]
+		for {
+			if remaining := length - uint64(len(tx)); uint64(len(data)) >= remaining {
+				tx = append(tx, data[:remaining]...)
+				break
+			}
+			tx = append(tx, data...)
+			i++
+			if i == len(payloads) {
+				return nil, fmt.Errorf("tx starting at share %d is truncated, got: %d bytes, want: %d",
+					start, len(tx), length)
+			}
+			data = payloads[i]
+		}
+		txs = append(txs, tx)
+		i++
+	}
+	return txs, nil
+}
@@ -0,0 +1,369 @@
+package ipld
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/ipfs/go-ipfs/core/coreapi"
+	coremock "github.com/ipfs/go-ipfs/core/mock"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lazyledger/lazyledger-core/types"
+	"github.com/lazyledger/nmt/namespace"
+)
+
+func TestMakeNamespacedShare(t *testing.T) {
+	nid := namespace.ID{1, 2, 3, 4, 5, 6, 7, 8}
+	data := bytes.Repeat([]byte{0xAB}, types.ShareSize)
+
+	share, err := MakeNamespacedShare(nid, data)
+	require.NoError(t, err)
+	assert.Len(t, share, types.NamespaceSize+types.ShareSize)
+	assert.EqualValues(t, nid, share[:types.NamespaceSize])
+	assert.Equal(t, data, share[types.NamespaceSize:])
+
+	tests := []struct {
+		name string
+		nid  namespace.ID
+		data []byte
+	}{
+		{"short namespace", nid[:types.NamespaceSize-1], data},
+		{"long namespace", append(nid, 9), data},
+		{"short data", nid, data[:types.ShareSize-1]},
+		{"long data", nid, append(data, 0)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			share, err := MakeNamespacedShare(tt.nid, tt.data)
+			assert.Error(t, err)
+			assert.Nil(t, share)
+		})
+	}
+}
+
+func TestValidateRowNamespaces(t *testing.T) {
+	leaf := func(nid byte) []byte {
+		return append(bytes.Repeat([]byte{nid}, types.NamespaceSize), 0xFF)
+	}
+
+	tests := []struct {
+		name    string
+		leaves  [][]byte
+		wantErr string
+	}{
+		{"empty row", nil, ""},
+		{"sorted row", [][]byte{leaf(1), leaf(1), leaf(2), leaf(5)}, ""},
+		{"out of order row", [][]byte{leaf(1), leaf(3), leaf(2), leaf(1)}, "leaf 2 is out of order"},
+		{"wrong length leaf", [][]byte{leaf(1), leaf(2)[:types.NamespaceSize-1], leaf(3)}, "leaf 1 is too short"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRowNamespaces(tt.leaves, types.NamespaceSize)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	assert.Error(t, ValidateRowNamespaces([][]byte{leaf(1)}, 0))
+}
+
+func TestIsPaddingShare(t *testing.T) {
+	padding := types.TailPaddingNamespaceID
+	paddingShare := append(append([]byte{}, padding...), make([]byte, types.ShareSize)...)
+	realShare := append(bytes.Repeat([]byte{1}, types.NamespaceSize), make([]byte, types.ShareSize)...)
+
+	assert.True(t, IsPaddingShare(paddingShare, padding, types.NamespaceSize))
+	assert.False(t, IsPaddingShare(realShare, padding, types.NamespaceSize))
+	assert.False(t, IsPaddingShare(paddingShare[:types.NamespaceSize-1], padding, types.NamespaceSize))
+	assert.False(t, IsPaddingShare(nil, padding, types.NamespaceSize))
+	assert.False(t, IsPaddingShare(paddingShare, padding, 0))
+}
+
+func TestCountRealShares(t *testing.T) {
+	padding := types.TailPaddingNamespaceID
+	share := func(nid namespace.ID) []byte {
+		return append(append([]byte{}, nid...), make([]byte, types.ShareSize)...)
+	}
+
+	tests := []struct {
+		name string
+		row  [][]byte
+		want int
+	}{
+		{"empty row", nil, 0},
+		{"only real shares", [][]byte{share(namespace.ID{1, 1, 1, 1, 1, 1, 1, 1}), share(types.TxNamespaceID)}, 2},
+		{"only padding shares", [][]byte{share(padding), share(padding)}, 0},
+		{"mixed row", [][]byte{
+			share(types.TxNamespaceID),
+			share(namespace.ID{1, 1, 1, 1, 1, 1, 1, 1}),
+			share(namespace.ID{2, 2, 2, 2, 2, 2, 2, 2}),
+			share(padding),
+			share(padding),
+			share(padding),
+		}, 3},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CountRealShares(tt.row, padding, types.NamespaceSize))
+		})
+	}
+}
+
+func TestValidateSquareSize(t *testing.T) {
+	tests := []struct {
+		shareCount int
+		want       int
+		wantErr    string
+	}{
+		{1, 1, ""},
+		{4, 2, ""},
+		{16, 4, ""},
+		{64, 8, ""},
+		{types.MaxSquareSize * types.MaxSquareSize, types.MaxSquareSize, ""},
+		{0, 0, "must be positive"},
+		{-4, 0, "must be positive"},
+		{15, 0, "not a perfect square"},
+		{17, 0, "not a perfect square"},
+		{9, 0, "not a power of 2"},
+		{36, 0, "not a power of 2"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprintf("%d shares", tt.shareCount), func(t *testing.T) {
+			got, err := ValidateSquareSize(tt.shareCount)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFitSquareSize(t *testing.T) {
+	tests := []struct {
+		name        string
+		totalShares int
+		maxEdge     int
+		want        int
+		wantErr     string
+	}{
+		{"exact fit 1", 1, 4, 1, ""},
+		{"exact fit 4", 4, 4, 2, ""},
+		{"exact fit 16", 16, 4, 4, ""},
+		{"exact fit max", types.MaxSquareSize * types.MaxSquareSize, types.MaxSquareSize, types.MaxSquareSize, ""},
+		{"round up 2", 2, 4, 2, ""},
+		{"round up 5", 5, 4, 4, ""},
+		{"round up 9", 9, 8, 4, ""},
+		{"round up 17", 17, 8, 8, ""},
+		{"non power of 2 max", 10, 6, 4, ""},
+		{"over max", 17, 4, 0, "exceeding the max of 4"},
+		{"over non power of 2 max", 17, 7, 0, "exceeding the max of 7"},
+		{"over consensus max", types.MaxSquareSize*types.MaxSquareSize + 1, types.MaxSquareSize, 0, "exceeding the max"},
+		{"no shares", 0, 4, 0, "must be positive"},
+		{"invalid max", 4, 0, 0, "must be positive"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FitSquareSize(tt.totalShares, tt.maxEdge)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRangeIntersects(t *testing.T) {
+	nid := func(b byte) namespace.ID {
+		return bytes.Repeat([]byte{b}, types.NamespaceSize)
+	}
+
+	tests := []struct {
+		name               string
+		nodeMin, nodeMax   namespace.ID
+		queryMin, queryMax namespace.ID
+		want               bool
+	}{
+		{"disjoint, query before node", nid(5), nid(8), nid(1), nid(4), false},
+		{"disjoint, query after node", nid(5), nid(8), nid(9), nid(12), false},
+		{"overlapping node start", nid(5), nid(8), nid(3), nid(6), true},
+		{"overlapping node end", nid(5), nid(8), nid(7), nid(10), true},
+		{"touching node start", nid(5), nid(8), nid(2), nid(5), true},
+		{"touching node end", nid(5), nid(8), nid(8), nid(9), true},
+		{"query contains node", nid(5), nid(8), nid(1), nid(9), true},
+		{"node contains query", nid(1), nid(9), nid(5), nid(5), true},
+		{"single namespace node, same namespace", nid(5), nid(5), nid(5), nid(5), true},
+		{"single namespace node, other namespace", nid(5), nid(5), nid(6), nid(6), false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RangeIntersects(tt.nodeMin, tt.nodeMax, tt.queryMin, tt.queryMax))
+		})
+	}
+}
+
+func TestColumnLeaves(t *testing.T) {
+	// the leaf at row r and column c is {r, c}
+	rows := make([][][]byte, 4)
+	for r := range rows {
+		rows[r] = make([][]byte, 4)
+		for c := range rows[r] {
+			rows[r][c] = []byte{byte(r), byte(c)}
+		}
+	}
+
+	for c := 0; c < 4; c++ {
+		assert.Equal(t, [][]byte{{0, byte(c)}, {1, byte(c)}, {2, byte(c)}, {3, byte(c)}}, ColumnLeaves(rows, c))
+	}
+	assert.Nil(t, ColumnLeaves(rows, -1))
+	assert.Nil(t, ColumnLeaves(rows, 4))
+	assert.Nil(t, ColumnLeaves(append(rows, rows[0][:2]), 2))
+	assert.Empty(t, ColumnLeaves(nil, 0))
+
+	// columns of an extended square of namespaced shares match the
+	// transposed row-major leaves
+	square := generateExtendedSquareLeaves(t, 2)
+	width := 4
+	squareRows := make([][][]byte, width)
+	for r := range squareRows {
+		squareRows[r] = square[r*width : (r+1)*width]
+	}
+	for c := 0; c < width; c++ {
+		col := ColumnLeaves(squareRows, c)
+		require.Len(t, col, width)
+		for r := range col {
+			assert.Equal(t, square[r*width+c], col[r])
+		}
+	}
+}
+
+func TestComputeSquareRoots(t *testing.T) {
+	ipfsNode, err := coremock.NewMockNode()
+	require.NoError(t, err)
+	ipfsAPI, err := coreapi.NewCoreAPI(ipfsNode)
+	require.NoError(t, err)
+	ctx := context.Background()
+	batch := format.NewBatch(ctx, ipfsAPI.Dag().Pinning())
+
+	const width = 4
+	leaves := generateExtendedSquareLeaves(t, width/2)
+	rowRoots, colRoots, err := ComputeSquareRoots(leaves, width, types.NamespaceSize)
+	require.NoError(t, err)
+	require.Len(t, rowRoots, width)
+	require.Len(t, colRoots, width)
+
+	rows := make([][][]byte, width)
+	for i := range rows {
+		rows[i] = leaves[i*width : (i+1)*width]
+	}
+	for i := 0; i < width; i++ {
+		tree, err := createNmtTree(ctx, batch, rows[i])
+		require.NoError(t, err)
+		assert.Equal(t, tree.Root().Bytes(), rowRoots[i], "row %d", i)
+
+		tree, err = createNmtTree(ctx, batch, ColumnLeaves(rows, i))
+		require.NoError(t, err)
+		assert.Equal(t, tree.Root().Bytes(), colRoots[i], "column %d", i)
+	}
+
+	_, _, err = ComputeSquareRoots(leaves, 3, types.NamespaceSize)
+	assert.Error(t, err)
+	_, _, err = ComputeSquareRoots(leaves, 0, types.NamespaceSize)
+	assert.Error(t, err)
+	_, _, err = ComputeSquareRoots(leaves, width, 0)
+	assert.Error(t, err)
+}
+
+func TestGenerateRandNamespacedRawData(t *testing.T) {
+	const seed = 42
+	// a namespace size of 1 makes equal namespaces likely
+	first, err := GenerateRandNamespacedRawData(mrand.New(mrand.NewSource(seed)), 64, 1, types.ShareSize)
+	require.NoError(t, err)
+	second, err := GenerateRandNamespacedRawData(mrand.New(mrand.NewSource(seed)), 64, 1, types.ShareSize)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.NoError(t, ValidateRowNamespaces(first, 1))
+
+	other, err := GenerateRandNamespacedRawData(mrand.New(mrand.NewSource(seed+1)), 64, 1, types.ShareSize)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, other)
+
+	// too little randomness
+	_, err = GenerateRandNamespacedRawData(bytes.NewReader(make([]byte, 10)), 1, types.NamespaceSize, types.ShareSize)
+	assert.Error(t, err)
+}
+
+func TestReassembleTxs(t *testing.T) {
+	txs := types.Txs{
+		types.Tx("short"),
+		bytes.Repeat([]byte{1}, types.ShareSize-1),
+		bytes.Repeat([]byte{2}, types.ShareSize),
+		bytes.Repeat([]byte{3}, 3*types.ShareSize+17),
+		types.Tx("last"),
+	}
+	shares := txsToShares(t, txs)
+	require.Greater(t, len(shares), len(txs))
+	// tail padding in the tx namespace
+	shares = append(shares, append(append([]byte{}, types.TxNamespaceID...), make([]byte, types.ShareSize)...))
+
+	got, err := ReassembleTxs(shares, types.NamespaceSize)
+	require.NoError(t, err)
+	assert.Equal(t, txs, got)
+
+	// the last share of the long tx is missing
+	_, err = ReassembleTxs(shares[:7], types.NamespaceSize)
+	assert.Error(t, err)
+
+	// malformed delimiter
+	malformed := append(append([]byte{}, types.TxNamespaceID...), bytes.Repeat([]byte{0xFF}, types.ShareSize)...)
+	_, err = ReassembleTxs([][]byte{malformed}, types.NamespaceSize)
+	assert.Error(t, err)
+
+	// share without data
+	_, err = ReassembleTxs([][]byte{types.TxNamespaceID}, types.NamespaceSize)
+	assert.Error(t, err)
+}
+
+// txsToShares splits the given txs into namespaced shares the same way blocks
+// do: every length-delimited tx starts a new share and its last share is zero
+// padded.
+func txsToShares(t *testing.T, txs types.Txs) [][]byte {
+	var shares [][]byte
+	for _, tx := range txs {
+		rawData, err := tx.MarshalDelimited()
+		require.NoError(t, err)
+		for len(rawData) > 0 {
+			n := types.ShareSize
+			if len(rawData) < n {
+				n = len(rawData)
+			}
+			data := make([]byte, types.ShareSize)
+			copy(data, rawData[:n])
+			share, err := MakeNamespacedShare(types.TxNamespaceID, data)
+			require.NoError(t, err)
+			shares = append(shares, share)
+			rawData = rawData[n:]
+		}
+	}
+	return shares
+}
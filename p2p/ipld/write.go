@@ -0,0 +1,50 @@
+package ipld
+
+import (
+	"context"
+	"crypto/sha256"
+
+	"github.com/ipfs/go-cid"
+	format "github.com/ipfs/go-ipld-format"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/lazyledger/lazyledger-core/p2p/ipld/plugin/nodes"
+	"github.com/lazyledger/lazyledger-core/types"
+	"github.com/lazyledger/nmt"
+)
+
+// /////////////////////////////////////
+//	Put Shares
+// /////////////////////////////////////
+
+// PutSharesToIPFS computes the nmt of the given namespaced shares, adds all its
+// nodes to the dag and returns the CID of the root. The shares must be prefixed
+// with their namespace and sorted by it, see ValidateRowNamespaces.
+func PutSharesToIPFS(ctx context.Context, shares [][]byte, dag coreiface.APIDagService) (cid.Cid, error) {
+	if err := ValidateRowNamespaces(shares, types.NamespaceSize); err != nil {
+		return cid.Undef, err
+	}
+
+	// create the batch and the adder used to collect the nodes of the tree
+	batch := format.NewBatch(ctx, dag.Pinning())
+	na := nodes.NewNmtNodeAdder(ctx, batch)
+
+	tree := nmt.New(sha256.New(), nmt.NamespaceIDSize(types.NamespaceSize), nmt.NodeVisitor(na.Visit))
+	for _, share := range shares {
+		if err := tree.Push(share[:types.NamespaceSize], share[types.NamespaceSize:]); err != nil {
+			return cid.Undef, err
+		}
+	}
+
+	// computing the root visits all the nodes of the tree
+	root := tree.Root()
+	if err := na.Err(); err != nil {
+		return cid.Undef, err
+	}
+
+	// commit the nodes to IPFS
+	if err := na.Commit(); err != nil {
+		return cid.Undef, err
+	}
+
+	return nodes.CidFromNamespacedSha256(root.Bytes())
+}
@@ -0,0 +1,50 @@
+package ipld
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-ipfs/core/coreapi"
+	coremock "github.com/ipfs/go-ipfs/core/mock"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lazyledger/lazyledger-core/p2p/ipld/plugin/nodes"
+	"github.com/lazyledger/lazyledger-core/types"
+)
+
+func TestPutSharesToIPFS(t *testing.T) {
+	ipfsNode, err := coremock.NewMockNode()
+	require.NoError(t, err)
+	ipfsAPI, err := coreapi.NewCoreAPI(ipfsNode)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data := generateRandNamespacedRawData(16, types.NamespaceSize, types.ShareSize)
+	rootCid, err := PutSharesToIPFS(ctx, data, ipfsAPI.Dag())
+	require.NoError(t, err)
+
+	// compute the root of the same shares manually
+	batch := format.NewBatch(ctx, ipfsAPI.Dag().Pinning())
+	tree, err := createNmtTree(ctx, batch, data)
+	require.NoError(t, err)
+	expected, err := nodes.CidFromNamespacedSha256(tree.Root().Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, expected, rootCid)
+
+	// the nodes were added to the dag
+	for i, leaf := range data {
+		got, err := GetLeafData(ctx, rootCid, uint32(i), uint32(len(data)), ipfsAPI)
+		require.NoError(t, err)
+		assert.Equal(t, leaf, got)
+	}
+
+	// unsorted shares are rejected
+	data[0], data[1] = data[1], data[0]
+	_, err = PutSharesToIPFS(ctx, data, ipfsAPI.Dag())
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package state_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -15,10 +16,12 @@ import (
 	cryptoenc "github.com/lazyledger/lazyledger-core/crypto/encoding"
 	"github.com/lazyledger/lazyledger-core/crypto/tmhash"
 	"github.com/lazyledger/lazyledger-core/libs/log"
+	mempl "github.com/lazyledger/lazyledger-core/mempool"
 	mmock "github.com/lazyledger/lazyledger-core/mempool/mock"
 	tmproto "github.com/lazyledger/lazyledger-core/proto/tendermint/types"
 	tmversion "github.com/lazyledger/lazyledger-core/proto/tendermint/version"
 	"github.com/lazyledger/lazyledger-core/proxy"
+	proxymocks "github.com/lazyledger/lazyledger-core/proxy/mocks"
 	sm "github.com/lazyledger/lazyledger-core/state"
 	"github.com/lazyledger/lazyledger-core/state/mocks"
 	"github.com/lazyledger/lazyledger-core/types"
@@ -57,6 +60,36 @@ func TestApplyBlock(t *testing.T) {
 	assert.EqualValues(t, 1, state.Version.Consensus.App, "App version wasn't updated")
 }
 
+// updateRecordingMempool is a mock mempool that counts the calls to Update.
+type updateRecordingMempool struct {
+	mmock.Mempool
+	updates int
+}
+
+func (m *updateRecordingMempool) Update(
+	int64, types.Txs, []*abci.ResponseDeliverTx, mempl.PreCheckFunc, mempl.PostCheckFunc,
+) error {
+	m.updates++
+	return nil
+}
+
+// TestCommitFailureKeepsMempoolTxs ensures the mempool isn't updated, i.e. the
+// txs of the block stay in the mempool, if the app fails to commit the block.
+func TestCommitFailureKeepsMempoolTxs(t *testing.T) {
+	proxyApp := &proxymocks.AppConnConsensus{}
+	proxyApp.On("CommitSync", mock.Anything).Return(nil, errors.New("commit failed"))
+
+	state, stateDB, _ := makeState(1, 1)
+	mempool := &updateRecordingMempool{}
+	blockExec := sm.NewBlockExecutor(sm.NewStore(stateDB), log.TestingLogger(), proxyApp,
+		mempool, sm.EmptyEvidencePool{})
+
+	_, _, err := blockExec.Commit(state, makeBlock(state, 1), nil)
+	require.Error(t, err)
+	assert.Zero(t, mempool.updates)
+	proxyApp.AssertExpectations(t)
+}
+
 // TestBeginBlockValidators ensures we send absent validators list.
 func TestBeginBlockValidators(t *testing.T) {
 	app := &testApp{}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 
 	"github.com/lazyledger/lazyledger-core/abci/example/code"
 	abci "github.com/lazyledger/lazyledger-core/abci/types"
@@ -25,6 +27,33 @@ type Application struct {
 	cfg             *Config
 	restoreSnapshot *abci.Snapshot
 	restoreChunks   [][]byte
+
+	// gasWanted records the gas wanted by each tx seen in CheckTx, so that it
+	// can be compared with the gas the tx uses in DeliverTx. Entries of txs
+	// that weren't checked again for gasWantedRetainBlocks blocks are pruned
+	// on Commit.
+	gasMtx    sync.Mutex
+	gasWanted map[string]gasWantedEntry
+	// gasHeight is the last committed height, as seen by the gasWanted
+	// bookkeeping.
+	gasHeight uint64
+	// gasUsed returns the gas used by a tx in DeliverTx, it is overridden in
+	// tests.
+	gasUsed func(tx []byte) int64
+}
+
+// txGas is the gas wanted and used by every tx.
+const txGas = 1
+
+// gasWantedRetainBlocks is the number of blocks a tx's gas wanted is kept
+// after it was last seen in CheckTx.
+const gasWantedRetainBlocks = 100
+
+// gasWantedEntry is the gas wanted by a tx and the last committed height
+// when it was checked.
+type gasWantedEntry struct {
+	gas    int64
+	height uint64
 }
 
 // NewApplication creates the application.
@@ -42,6 +71,8 @@ func NewApplication(cfg *Config) (*Application, error) {
 		state:     state,
 		snapshots: snapshots,
 		cfg:       cfg,
+		gasWanted: make(map[string]gasWantedEntry),
+		gasUsed:   func([]byte) int64 { return txGas },
 	}, nil
 }
 
@@ -78,12 +109,19 @@ func (app *Application) InitChain(req abci.RequestInitChain) abci.ResponseInitCh
 func (app *Application) CheckTx(req abci.RequestCheckTx) abci.ResponseCheckTx {
 	_, _, err := parseTx(req.Tx)
 	if err != nil {
+		// the tx may have passed an earlier check
+		app.gasMtx.Lock()
+		delete(app.gasWanted, string(req.Tx))
+		app.gasMtx.Unlock()
 		return abci.ResponseCheckTx{
 			Code: code.CodeTypeEncodingError,
 			Log:  err.Error(),
 		}
 	}
-	return abci.ResponseCheckTx{Code: code.CodeTypeOK, GasWanted: 1}
+	app.gasMtx.Lock()
+	app.gasWanted[string(req.Tx)] = gasWantedEntry{gas: txGas, height: app.gasHeight}
+	app.gasMtx.Unlock()
+	return abci.ResponseCheckTx{Code: code.CodeTypeOK, GasWanted: txGas}
 }
 
 // DeliverTx implements ABCI.
@@ -92,8 +130,43 @@ func (app *Application) DeliverTx(req abci.RequestDeliverTx) abci.ResponseDelive
 	if err != nil {
 		panic(err) // shouldn't happen since we verified it in CheckTx
 	}
+	gasUsed := app.gasUsed(req.Tx)
+	if err := app.checkGasUsed(req.Tx, gasUsed); err != nil {
+		panic(err)
+	}
 	app.state.Set(key, value)
-	return abci.ResponseDeliverTx{Code: code.CodeTypeOK}
+	return abci.ResponseDeliverTx{Code: code.CodeTypeOK, GasUsed: gasUsed}
+}
+
+// checkGasUsed compares the gas used by a delivered tx with the gas it wanted
+// in CheckTx, if the tx was checked by this node, and returns an error if
+// they diverge.
+func (app *Application) checkGasUsed(tx []byte, gasUsed int64) error {
+	app.gasMtx.Lock()
+	defer app.gasMtx.Unlock()
+	entry, ok := app.gasWanted[string(tx)]
+	if !ok {
+		return nil
+	}
+	delete(app.gasWanted, string(tx))
+	if gasUsed != entry.gas {
+		return fmt.Errorf("tx %q used %v gas in DeliverTx, but wanted %v gas in CheckTx", tx, gasUsed, entry.gas)
+	}
+	return nil
+}
+
+// pruneGasWanted records the committed height and removes the gas wanted by
+// txs that weren't checked for gasWantedRetainBlocks blocks, e.g. because
+// they were evicted from the mempool and will never be delivered.
+func (app *Application) pruneGasWanted(height uint64) {
+	app.gasMtx.Lock()
+	defer app.gasMtx.Unlock()
+	app.gasHeight = height
+	for tx, entry := range app.gasWanted {
+		if entry.height+gasWantedRetainBlocks <= height {
+			delete(app.gasWanted, tx)
+		}
+	}
 }
 
 // EndBlock implements ABCI.
@@ -112,6 +185,7 @@ func (app *Application) Commit() abci.ResponseCommit {
 	if err != nil {
 		panic(err)
 	}
+	app.pruneGasWanted(height)
 	if app.cfg.SnapshotInterval > 0 && height%app.cfg.SnapshotInterval == 0 {
 		snapshot, err := app.snapshots.Create(app.state)
 		if err != nil {
@@ -119,16 +193,23 @@ func (app *Application) Commit() abci.ResponseCommit {
 		}
 		logger.Info("Created state sync snapshot", "height", snapshot.Height)
 	}
-	retainHeight := int64(0)
-	if app.cfg.RetainBlocks > 0 {
-		retainHeight = int64(height - app.cfg.RetainBlocks + 1)
-	}
 	return abci.ResponseCommit{
 		Data:         hash,
-		RetainHeight: retainHeight,
+		RetainHeight: app.retainHeight(height),
 	}
 }
 
+// retainHeight returns the lowest height the node should retain blocks for
+// after committing the given height, such that the last RetainBlocks blocks
+// are kept. It returns 0, i.e. retain all blocks, if RetainBlocks is 0 or no
+// more than RetainBlocks blocks have been committed yet.
+func (app *Application) retainHeight(height uint64) int64 {
+	if app.cfg.RetainBlocks == 0 || height <= app.cfg.RetainBlocks {
+		return 0
+	}
+	return int64(height - app.cfg.RetainBlocks + 1)
+}
+
 // Query implements ABCI.
 func (app *Application) Query(req abci.RequestQuery) abci.ResponseQuery {
 	return abci.ResponseQuery{
@@ -200,14 +281,20 @@ func (app *Application) validatorUpdates(height uint64) (abci.ValidatorUpdates,
 		return nil, nil
 	}
 
-	valUpdates := abci.ValidatorUpdates{}
-	for keyString, power := range updates {
+	// sort the keys, so that all nodes return the updates in the same order
+	keyStrings := make([]string, 0, len(updates))
+	for keyString := range updates {
+		keyStrings = append(keyStrings, keyString)
+	}
+	sort.Strings(keyStrings)
 
+	valUpdates := abci.ValidatorUpdates{}
+	for _, keyString := range keyStrings {
 		keyBytes, err := base64.StdEncoding.DecodeString(keyString)
 		if err != nil {
 			return nil, fmt.Errorf("invalid base64 pubkey value %q: %w", keyString, err)
 		}
-		valUpdates = append(valUpdates, abci.UpdateValidator(keyBytes, int64(power), app.cfg.KeyType))
+		valUpdates = append(valUpdates, abci.UpdateValidator(keyBytes, updates[keyString], app.cfg.KeyType))
 	}
 	return valUpdates, nil
 }
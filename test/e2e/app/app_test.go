@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lazyledger/lazyledger-core/abci/example/code"
+	abci "github.com/lazyledger/lazyledger-core/abci/types"
+	"github.com/lazyledger/lazyledger-core/crypto/ed25519"
+	tmproto "github.com/lazyledger/lazyledger-core/proto/tendermint/types"
+)
+
+func newTestApplication(t *testing.T) *Application {
+	dir, err := ioutil.TempDir("", "e2e-app")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	app, err := NewApplication(&Config{Dir: dir, PersistInterval: 1})
+	require.NoError(t, err)
+	return app
+}
+
+func TestApplicationGasUsedMatchesGasWanted(t *testing.T) {
+	app := newTestApplication(t)
+
+	tx := []byte("key=value")
+	checkRes := app.CheckTx(abci.RequestCheckTx{Tx: tx})
+	require.EqualValues(t, code.CodeTypeOK, checkRes.Code)
+
+	deliverRes := app.DeliverTx(abci.RequestDeliverTx{Tx: tx})
+	assert.EqualValues(t, code.CodeTypeOK, deliverRes.Code)
+	assert.Equal(t, checkRes.GasWanted, deliverRes.GasUsed)
+
+	// txs that were not checked by this node are not compared
+	app.gasUsed = func([]byte) int64 { return txGas + 1 }
+	assert.NotPanics(t, func() {
+		app.DeliverTx(abci.RequestDeliverTx{Tx: []byte("other=value")})
+	})
+}
+
+func TestApplicationGasUsedDiverges(t *testing.T) {
+	app := newTestApplication(t)
+	app.gasUsed = func([]byte) int64 { return txGas + 1 }
+
+	tx := []byte("key=value")
+	checkRes := app.CheckTx(abci.RequestCheckTx{Tx: tx})
+	require.EqualValues(t, code.CodeTypeOK, checkRes.Code)
+
+	assert.Panics(t, func() {
+		app.DeliverTx(abci.RequestDeliverTx{Tx: tx})
+	})
+}
+
+func TestApplicationGasWantedPruned(t *testing.T) {
+	app := newTestApplication(t)
+
+	// a failed check removes the entry of an earlier check
+	app.gasWanted["invalid"] = gasWantedEntry{gas: txGas}
+	checkRes := app.CheckTx(abci.RequestCheckTx{Tx: []byte("invalid")})
+	require.NotEqualValues(t, code.CodeTypeOK, checkRes.Code)
+	assert.NotContains(t, app.gasWanted, "invalid")
+
+	// entries of txs that aren't delivered are dropped after
+	// gasWantedRetainBlocks blocks, unless the tx is checked again
+	checkRes = app.CheckTx(abci.RequestCheckTx{Tx: []byte("a=1")})
+	require.EqualValues(t, code.CodeTypeOK, checkRes.Code)
+	checkRes = app.CheckTx(abci.RequestCheckTx{Tx: []byte("b=1")})
+	require.EqualValues(t, code.CodeTypeOK, checkRes.Code)
+	for i := 0; i < gasWantedRetainBlocks-1; i++ {
+		app.Commit()
+	}
+	checkRes = app.CheckTx(abci.RequestCheckTx{Tx: []byte("b=1")})
+	require.EqualValues(t, code.CodeTypeOK, checkRes.Code)
+	assert.Len(t, app.gasWanted, 2)
+
+	app.Commit()
+	assert.NotContains(t, app.gasWanted, "a=1")
+	assert.Contains(t, app.gasWanted, "b=1")
+}
+
+func TestApplicationValidatorUpdates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "e2e-app")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keys := make([]string, 3)
+	for i := range keys {
+		keys[i] = base64.StdEncoding.EncodeToString(ed25519.GenPrivKey().PubKey().Bytes())
+	}
+	// the config as written by the runner's MakeAppConfig
+	cfgFile := filepath.Join(dir, "app.toml")
+	require.NoError(t, ioutil.WriteFile(cfgFile, []byte(fmt.Sprintf(`
+chain_id = "test"
+dir = %q
+
+[validator_update.0]
+%q = 100
+%q = 100
+
+[validator_update.2]
+%q = 1000
+
+[validator_update.4]
+%q = 0
+%q = 50
+`, dir, keys[0], keys[1], keys[2], keys[0], keys[2])), 0644))
+	cfg, err := LoadConfig(cfgFile)
+	require.NoError(t, err)
+	app, err := NewApplication(cfg)
+	require.NoError(t, err)
+
+	update := func(key string, power int64) abci.ValidatorUpdate {
+		keyBytes, err := base64.StdEncoding.DecodeString(key)
+		require.NoError(t, err)
+		return abci.UpdateValidator(keyBytes, power, "")
+	}
+	sorted := func(updates ...abci.ValidatorUpdate) abci.ValidatorUpdates {
+		sort.Slice(updates, func(i, j int) bool {
+			return base64.StdEncoding.EncodeToString(updates[i].PubKey.GetEd25519()) <
+				base64.StdEncoding.EncodeToString(updates[j].PubKey.GetEd25519())
+		})
+		return updates
+	}
+
+	initRes := app.InitChain(abci.RequestInitChain{InitialHeight: 1})
+	assert.Equal(t, sorted(update(keys[0], 100), update(keys[1], 100)), abci.ValidatorUpdates(initRes.Validators))
+
+	want := map[int64]abci.ValidatorUpdates{
+		2: {update(keys[2], 1000)},
+		4: sorted(update(keys[0], 0), update(keys[2], 50)),
+	}
+	for height := int64(1); height <= 5; height++ {
+		app.BeginBlock(abci.RequestBeginBlock{Header: tmproto.Header{Height: height}})
+		res := app.EndBlock(abci.RequestEndBlock{Height: height})
+		app.Commit()
+		assert.Equal(t, want[height], abci.ValidatorUpdates(res.ValidatorUpdates), "height %v", height)
+	}
+}
+
+func TestApplicationRetainHeight(t *testing.T) {
+	dir, err := ioutil.TempDir("", "e2e-app")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	app, err := NewApplication(&Config{Dir: dir, PersistInterval: 1, RetainBlocks: 3})
+	require.NoError(t, err)
+
+	// blocks are only pruned once more than retain_blocks have been committed
+	for height, want := range []int64{0, 0, 0, 2, 3, 4} {
+		res := app.Commit()
+		assert.Equal(t, want, res.RetainHeight, "height %v", height+1)
+	}
+
+	// all blocks are retained if retain_blocks is not set
+	app.cfg.RetainBlocks = 0
+	assert.Zero(t, app.Commit().RetainHeight)
+}
@@ -50,6 +50,12 @@ type Manifest struct {
 	// KeyType sets the curve that will be used by validators.
 	// Options are ed25519 & secp256k1
 	KeyType string `toml:"key_type"`
+
+	// NodeKeySeed, if set, derives the node key of each node from the seed
+	// and the node name (see DeriveNodeKey), so that node IDs don't depend on
+	// the other nodes of the testnet. Defaults to keys generated in the order
+	// of the node names.
+	NodeKeySeed string `toml:"node_key_seed"`
 }
 
 // ManifestNode represents a node in a testnet manifest.
@@ -118,7 +124,7 @@ type ManifestNode struct {
 	// disconnect: temporarily disconnects the node from the network
 	// kill:       kills the node with SIGKILL then restarts it
 	// pause:      temporarily pauses (freezes) the node
-	// restart:    restarts the node, shutting it down with SIGTERM
+	// restart:    restarts the node at a random point, shutting it down with SIGTERM
 	Perturb []string `toml:"perturb"`
 
 	// Misbehaviors sets how a validator behaves during consensus at a
@@ -130,6 +136,32 @@ type ManifestNode struct {
 	// For more information, look at the readme in the maverick folder.
 	// A list of all behaviors can be found in ../maverick/consensus/behavior.go
 	Misbehaviors map[string]string `toml:"misbehaviors"`
+
+	// Mempool optionally overrides the default mempool configuration of the
+	// node, e.g. to test a full mempool or disabled rechecks.
+	Mempool *ManifestMempool `toml:"mempool"`
+}
+
+// ManifestMempool represents mempool configuration overrides for a node in a
+// testnet manifest. Zero (or unset) fields keep the default value.
+type ManifestMempool struct {
+	// Recheck enables or disables rechecking txs after each block.
+	Recheck *bool `toml:"recheck"`
+
+	// Broadcast enables or disables gossiping txs to peers.
+	Broadcast *bool `toml:"broadcast"`
+
+	// Size is the maximum number of txs in the mempool.
+	Size int `toml:"size"`
+
+	// MaxTxsBytes is the maximum total size of all txs in the mempool.
+	MaxTxsBytes int64 `toml:"max_txs_bytes"`
+
+	// CacheSize is the size of the cache of already seen txs.
+	CacheSize int `toml:"cache_size"`
+
+	// MaxTxBytes is the maximum size of a single tx.
+	MaxTxBytes int `toml:"max_tx_bytes"`
 }
 
 // Save saves the testnet manifest to a file.
@@ -11,10 +11,13 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lazyledger/lazyledger-core/crypto"
 	"github.com/lazyledger/lazyledger-core/crypto/ed25519"
 	"github.com/lazyledger/lazyledger-core/crypto/secp256k1"
+	"github.com/lazyledger/lazyledger-core/p2p"
+	tmproto "github.com/lazyledger/lazyledger-core/proto/tendermint/types"
 	rpchttp "github.com/lazyledger/lazyledger-core/rpc/client/http"
 	mcs "github.com/lazyledger/lazyledger-core/test/maverick/consensus"
 	"github.com/lazyledger/lazyledger-core/types"
@@ -60,6 +63,21 @@ type Testnet struct {
 	ValidatorUpdates map[int64]map[*Node]int64
 	Nodes            []*Node
 	KeyType          string
+
+	// ConsensusParams optionally overrides the default consensus params
+	// written to genesis. If nil, types.DefaultConsensusParams() is used.
+	ConsensusParams *tmproto.ConsensusParams
+
+	// InitialAppHash optionally sets the app hash written to genesis.
+	InitialAppHash []byte
+
+	// GenesisTime optionally sets the genesis time, e.g. to generate
+	// reproducible genesis files. If zero, the current time is used.
+	GenesisTime time.Time
+
+	// GenesisPath is the optional path of an existing genesis file to use
+	// instead of generating one. It is copied as-is to every node.
+	GenesisPath string
 }
 
 // Node represents a Tendermint node in a testnet.
@@ -84,6 +102,7 @@ type Node struct {
 	PersistentPeers  []*Node
 	Perturbations    []Perturbation
 	Misbehaviors     map[int64]string
+	Mempool          ManifestMempool
 }
 
 // LoadTestnet loads a testnet from a manifest file, using the filename to
@@ -160,6 +179,10 @@ func LoadTestnet(file string) (*Testnet, error) {
 			Perturbations:    []Perturbation{},
 			Misbehaviors:     make(map[int64]string),
 		}
+		// the generated key is still drawn above to keep the other keys the same
+		if manifest.NodeKeySeed != "" {
+			node.NodeKey = DeriveNodeKey(manifest.NodeKeySeed, name).PrivKey
+		}
 		if node.StartAt == testnet.InitialHeight {
 			node.StartAt = 0 // normalize to 0 for initial nodes, since code expects this
 		}
@@ -178,6 +201,9 @@ func LoadTestnet(file string) (*Testnet, error) {
 		if nodeManifest.PersistInterval != nil {
 			node.PersistInterval = *nodeManifest.PersistInterval
 		}
+		if nodeManifest.Mempool != nil {
+			node.Mempool = *nodeManifest.Mempool
+		}
 		for _, p := range nodeManifest.Perturb {
 			node.Perturbations = append(node.Perturbations, Perturbation(p))
 		}
@@ -469,6 +495,17 @@ func (n Node) Client() (*rpchttp.HTTP, error) {
 	return rpchttp.New(fmt.Sprintf("http://127.0.0.1:%v", n.ProxyPort), "/websocket")
 }
 
+// DeriveNodeKey returns the Ed25519 node key of the node with the given name
+// in a testnet with the given seed. The same seed and name always yield the
+// same key, and thus the same node ID.
+func DeriveNodeKey(testnetSeed, nodeName string) p2p.NodeKey {
+	// prefix the seed with its length, so that e.g. seed "a" and node "bc"
+	// don't yield the same key as seed "ab" and node "c"
+	secret := fmt.Sprintf("%d:%s:%s", len(testnetSeed), testnetSeed, nodeName)
+	privKey := ed25519.GenPrivKeyFromSecret([]byte(secret))
+	return p2p.NodeKey{ID: p2p.PubKeyToID(privKey.PubKey()), PrivKey: privKey}
+}
+
 // keyGenerator generates pseudorandom Ed25519 keys based on a seed.
 type keyGenerator struct {
 	random *rand.Rand
@@ -0,0 +1,111 @@
+package e2e
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadTestnetFromManifest writes the given TOML manifest to a temporary file
+// and loads a testnet from it.
+func loadTestnetFromManifest(t *testing.T, manifest string) (*Testnet, error) {
+	dir, err := ioutil.TempDir("", "e2e-manifest")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	file := filepath.Join(dir, "test.toml")
+	require.NoError(t, ioutil.WriteFile(file, []byte(manifest), 0644))
+	return LoadTestnet(file)
+}
+
+func TestLoadTestnetPerturbations(t *testing.T) {
+	testnet, err := loadTestnetFromManifest(t, `
+[node.validator01]
+[node.validator02]
+perturb = ["restart"]
+[node.validator03]
+perturb = ["kill", "restart"]
+`)
+	require.NoError(t, err)
+	require.True(t, testnet.HasPerturbations())
+
+	assert.Empty(t, testnet.LookupNode("validator01").Perturbations)
+	assert.Equal(t, []Perturbation{PerturbationRestart},
+		testnet.LookupNode("validator02").Perturbations)
+	assert.Equal(t, []Perturbation{PerturbationKill, PerturbationRestart},
+		testnet.LookupNode("validator03").Perturbations)
+}
+
+func TestLoadTestnetPartitionPerturbations(t *testing.T) {
+	testnet, err := loadTestnetFromManifest(t, `
+[node.validator01]
+perturb = ["disconnect"]
+[node.validator02]
+perturb = ["pause", "disconnect"]
+`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Perturbation{PerturbationDisconnect},
+		testnet.LookupNode("validator01").Perturbations)
+	assert.Equal(t, []Perturbation{PerturbationPause, PerturbationDisconnect},
+		testnet.LookupNode("validator02").Perturbations)
+}
+
+func TestLoadTestnetInvalidPerturbation(t *testing.T) {
+	_, err := loadTestnetFromManifest(t, `
+[node.validator01]
+perturb = ["reboot"]
+`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid perturbation")
+}
+
+func TestLoadTestnetMempool(t *testing.T) {
+	testnet, err := loadTestnetFromManifest(t, `
+[node.validator01]
+[node.validator02]
+mempool = { size = 10, recheck = false }
+`)
+	require.NoError(t, err)
+
+	assert.Equal(t, ManifestMempool{}, testnet.LookupNode("validator01").Mempool)
+	mempool := testnet.LookupNode("validator02").Mempool
+	assert.Equal(t, 10, mempool.Size)
+	if assert.NotNil(t, mempool.Recheck) {
+		assert.False(t, *mempool.Recheck)
+	}
+	assert.Nil(t, mempool.Broadcast)
+}
+
+func TestDeriveNodeKey(t *testing.T) {
+	key := DeriveNodeKey("seed", "validator01")
+	assert.Equal(t, key.ID, DeriveNodeKey("seed", "validator01").ID)
+	assert.NotEqual(t, key.ID, DeriveNodeKey("seed", "validator02").ID)
+	assert.NotEqual(t, key.ID, DeriveNodeKey("other", "validator01").ID)
+	assert.NotEqual(t, DeriveNodeKey("a", "bc").ID, DeriveNodeKey("ab", "c").ID)
+}
+
+func TestLoadTestnetNodeKeySeed(t *testing.T) {
+	testnet, err := loadTestnetFromManifest(t, `
+node_key_seed = "seed"
+[node.validator01]
+[node.validator02]
+`)
+	require.NoError(t, err)
+	for _, node := range testnet.Nodes {
+		assert.Equal(t, DeriveNodeKey("seed", node.Name).PrivKey, node.NodeKey)
+	}
+
+	// the node IDs don't depend on the other nodes
+	other, err := loadTestnetFromManifest(t, `
+node_key_seed = "seed"
+[node.full01]
+[node.validator02]
+`)
+	require.NoError(t, err)
+	assert.Equal(t, testnet.LookupNode("validator02").NodeKey, other.LookupNode("validator02").NodeKey)
+}
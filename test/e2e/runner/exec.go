@@ -30,21 +30,30 @@ func execVerbose(args ...string) error {
 	return cmd.Run()
 }
 
+// composeArgs returns the full command line of a Docker Compose command for a
+// testnet.
+func composeArgs(dir string, args ...string) []string {
+	return append(
+		[]string{"docker-compose", "-f", filepath.Join(dir, "docker-compose.yml")},
+		args...)
+}
+
 // execCompose runs a Docker Compose command for a testnet.
 func execCompose(dir string, args ...string) error {
-	return exec(append(
-		[]string{"docker-compose", "-f", filepath.Join(dir, "docker-compose.yml")},
-		args...)...)
+	return exec(composeArgs(dir, args...)...)
 }
 
 // execComposeVerbose runs a Docker Compose command for a testnet and displays its output.
 func execComposeVerbose(dir string, args ...string) error {
-	return execVerbose(append(
-		[]string{"docker-compose", "-f", filepath.Join(dir, "docker-compose.yml")},
-		args...)...)
+	return execVerbose(composeArgs(dir, args...)...)
+}
+
+// dockerArgs returns the full command line of a Docker command.
+func dockerArgs(args ...string) []string {
+	return append([]string{"docker"}, args...)
 }
 
 // execDocker runs a Docker command.
 func execDocker(args ...string) error {
-	return exec(append([]string{"docker"}, args...)...)
+	return exec(dockerArgs(args...)...)
 }
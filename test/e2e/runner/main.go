@@ -21,9 +21,10 @@ func main() {
 
 // CLI is the Cobra-based command-line interface.
 type CLI struct {
-	root     *cobra.Command
-	testnet  *e2e.Testnet
-	preserve bool
+	root            *cobra.Command
+	testnet         *e2e.Testnet
+	preserve        bool
+	ipfsInitWorkers int
 }
 
 // NewCLI sets up the CLI.
@@ -51,7 +52,7 @@ func NewCLI() *CLI {
 			if err := Cleanup(cli.testnet); err != nil {
 				return err
 			}
-			if err := Setup(cli.testnet); err != nil {
+			if err := Setup(cli.testnet, cli.ipfsInitWorkers); err != nil {
 				return err
 			}
 
@@ -113,6 +114,9 @@ func NewCLI() *CLI {
 	cli.root.PersistentFlags().StringP("file", "f", "", "Testnet TOML manifest")
 	_ = cli.root.MarkPersistentFlagRequired("file")
 
+	cli.root.PersistentFlags().IntVar(&cli.ipfsInitWorkers, "ipfs-init-workers", DefaultIPFSInitWorkers,
+		"Maximum number of IPFS repos initialized concurrently during setup")
+
 	cli.root.Flags().BoolVarP(&cli.preserve, "preserve", "p", false,
 		"Preserves the running of the test net after tests are completed")
 
@@ -120,7 +124,7 @@ func NewCLI() *CLI {
 		Use:   "setup",
 		Short: "Generates the testnet directory and configuration",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return Setup(cli.testnet)
+			return Setup(cli.testnet, cli.ipfsInitWorkers)
 		},
 	})
 
@@ -130,7 +134,7 @@ func NewCLI() *CLI {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			_, err := os.Stat(cli.testnet.Dir)
 			if os.IsNotExist(err) {
-				err = Setup(cli.testnet)
+				err = Setup(cli.testnet, cli.ipfsInitWorkers)
 			}
 			if err != nil {
 				return err
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"math/rand"
 	"time"
 
 	rpctypes "github.com/lazyledger/lazyledger-core/rpc/core/types"
@@ -22,49 +23,84 @@ func Perturb(testnet *e2e.Testnet) error {
 	return nil
 }
 
-// PerturbNode perturbs a node with a given perturbation, returning its status
-// after recovering.
-func PerturbNode(node *e2e.Node, perturbation e2e.Perturbation) (*rpctypes.ResultStatus, error) {
+// maxRestartDelay is the longest the runner waits before restarting a node, so
+// that the restart hits the node at a random point, e.g. while it is
+// committing a block or writing its WAL.
+const maxRestartDelay = 5 * time.Second
+
+// perturbRand picks the random delays of the perturbations.
+var perturbRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// perturbStep is a step of a perturbation plan: a command to execute, or a
+// duration to wait for if there is no command.
+type perturbStep struct {
+	args []string
+	wait time.Duration
+}
+
+// perturbationPlan returns the steps to perturb the given node with the given
+// perturbation, e.g. disconnecting its container from the testnet's Docker
+// network and reconnecting it after a while. Random delays are drawn from r.
+func perturbationPlan(node *e2e.Node, perturbation e2e.Perturbation, r *rand.Rand) ([]perturbStep, error) {
 	testnet := node.Testnet
+	// Docker Compose prefixes the network name with the project (directory) name
+	network := testnet.Name + "_" + testnet.Name
 	switch perturbation {
 	case e2e.PerturbationDisconnect:
-		logger.Info(fmt.Sprintf("Disconnecting node %v...", node.Name))
-		if err := execDocker("network", "disconnect", testnet.Name+"_"+testnet.Name, node.Name); err != nil {
-			return nil, err
-		}
-		time.Sleep(10 * time.Second)
-		if err := execDocker("network", "connect", testnet.Name+"_"+testnet.Name, node.Name); err != nil {
-			return nil, err
-		}
+		return []perturbStep{
+			{args: dockerArgs("network", "disconnect", network, node.Name)},
+			{wait: 10 * time.Second},
+			{args: dockerArgs("network", "connect", network, node.Name)},
+		}, nil
 
 	case e2e.PerturbationKill:
-		logger.Info(fmt.Sprintf("Killing node %v...", node.Name))
-		if err := execCompose(testnet.Dir, "kill", "-s", "SIGKILL", node.Name); err != nil {
-			return nil, err
-		}
-		if err := execCompose(testnet.Dir, "start", node.Name); err != nil {
-			return nil, err
-		}
+		return []perturbStep{
+			{args: composeArgs(testnet.Dir, "kill", "-s", "SIGKILL", node.Name)},
+			{args: composeArgs(testnet.Dir, "start", node.Name)},
+		}, nil
 
 	case e2e.PerturbationPause:
-		logger.Info(fmt.Sprintf("Pausing node %v...", node.Name))
-		if err := execCompose(testnet.Dir, "pause", node.Name); err != nil {
-			return nil, err
-		}
-		time.Sleep(10 * time.Second)
-		if err := execCompose(testnet.Dir, "unpause", node.Name); err != nil {
-			return nil, err
-		}
+		return []perturbStep{
+			{args: composeArgs(testnet.Dir, "pause", node.Name)},
+			{wait: 10 * time.Second},
+			{args: composeArgs(testnet.Dir, "unpause", node.Name)},
+		}, nil
 
 	case e2e.PerturbationRestart:
-		logger.Info(fmt.Sprintf("Restarting node %v...", node.Name))
-		if err := execCompose(testnet.Dir, "restart", node.Name); err != nil {
-			return nil, err
-		}
+		return []perturbStep{
+			{wait: time.Duration(r.Int63n(int64(maxRestartDelay)))},
+			{args: composeArgs(testnet.Dir, "restart", node.Name)},
+		}, nil
 
 	default:
 		return nil, fmt.Errorf("unexpected perturbation %q", perturbation)
 	}
+}
+
+// PerturbNode perturbs a node with a given perturbation, returning its status
+// after recovering. Since seed nodes don't serve RPC, no status is returned
+// for them.
+func PerturbNode(node *e2e.Node, perturbation e2e.Perturbation) (*rpctypes.ResultStatus, error) {
+	plan, err := perturbationPlan(node, perturbation, perturbRand)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Perturbing node %v with %v...", node.Name, perturbation))
+	for _, step := range plan {
+		if len(step.args) == 0 {
+			time.Sleep(step.wait)
+			continue
+		}
+		if err := exec(step.args...); err != nil {
+			return nil, err
+		}
+	}
+
+	if node.Mode == e2e.ModeSeed {
+		logger.Info(fmt.Sprintf("Seed node %v perturbed", node.Name))
+		return nil, nil
+	}
 
 	status, err := waitForNode(node, 0, 10*time.Second)
 	if err != nil {
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	e2e "github.com/lazyledger/lazyledger-core/test/e2e/pkg"
+)
+
+func TestPerturbationPlan(t *testing.T) {
+	testnet := newTestTestnet(t)
+	testnet.Dir = "networks/test"
+	node := testnet.Nodes[0]
+	compose := []string{"docker-compose", "-f", "networks/test/docker-compose.yml"}
+
+	tests := []struct {
+		perturbation e2e.Perturbation
+		want         []perturbStep
+	}{
+		{e2e.PerturbationDisconnect, []perturbStep{
+			{args: []string{"docker", "network", "disconnect", "test_test", node.Name}},
+			{wait: 10 * time.Second},
+			{args: []string{"docker", "network", "connect", "test_test", node.Name}},
+		}},
+		{e2e.PerturbationPause, []perturbStep{
+			{args: append(compose, "pause", node.Name)},
+			{wait: 10 * time.Second},
+			{args: append(compose, "unpause", node.Name)},
+		}},
+		{e2e.PerturbationKill, []perturbStep{
+			{args: append(compose, "kill", "-s", "SIGKILL", node.Name)},
+			{args: append(compose, "start", node.Name)},
+		}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(string(tt.perturbation), func(t *testing.T) {
+			plan, err := perturbationPlan(node, tt.perturbation, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, plan)
+		})
+	}
+
+	_, err := perturbationPlan(node, "reboot", nil)
+	assert.Error(t, err)
+}
+
+func TestPerturbationPlanRestart(t *testing.T) {
+	testnet := newTestTestnet(t)
+	testnet.Dir = "networks/test"
+	node := testnet.Nodes[0]
+	node.Perturbations = []e2e.Perturbation{e2e.PerturbationRestart}
+	require.NoError(t, node.Validate(*testnet))
+	restart := []string{"docker-compose", "-f", "networks/test/docker-compose.yml", "restart", node.Name}
+
+	// the restart is scheduled at a random point, within maxRestartDelay
+	r := rand.New(rand.NewSource(1))
+	waits := make(map[time.Duration]bool)
+	for i := 0; i < 10; i++ {
+		plan, err := perturbationPlan(node, node.Perturbations[0], r)
+		require.NoError(t, err)
+		require.Len(t, plan, 2)
+		assert.Empty(t, plan[0].args)
+		assert.GreaterOrEqual(t, int64(plan[0].wait), int64(0))
+		assert.Less(t, int64(plan[0].wait), int64(maxRestartDelay))
+		assert.Equal(t, perturbStep{args: restart}, plan[1])
+		waits[plan[0].wait] = true
+	}
+	assert.Greater(t, len(waits), 1, "the restart delay is not random")
+}
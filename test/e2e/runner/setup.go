@@ -14,6 +14,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 	"github.com/lazyledger/lazyledger-core/cmd/tendermint/commands"
 	"github.com/lazyledger/lazyledger-core/config"
 	"github.com/lazyledger/lazyledger-core/crypto/ed25519"
+	tmjson "github.com/lazyledger/lazyledger-core/libs/json"
 	"github.com/lazyledger/lazyledger-core/p2p"
 	"github.com/lazyledger/lazyledger-core/privval"
 	e2e "github.com/lazyledger/lazyledger-core/test/e2e/pkg"
@@ -38,10 +40,25 @@ const (
 	PrivvalStateFile      = "data/priv_validator_state.json"
 	PrivvalDummyKeyFile   = "config/dummy_validator_key.json"
 	PrivvalDummyStateFile = "data/dummy_validator_state.json"
+
+	// DefaultIPFSInitWorkers is the default number of IPFS repos that are
+	// initialized concurrently by Setup.
+	DefaultIPFSInitWorkers = 4
 )
 
-// Setup sets up the testnet configuration.
-func Setup(testnet *e2e.Testnet) error {
+// nodeNameRegexp matches the node names that are valid both as docker-compose
+// service and container names and as directory names.
+var nodeNameRegexp = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// Setup sets up the testnet configuration. The IPFS repos of the nodes are
+// initialized concurrently, using at most ipfsInitWorkers workers.
+func Setup(testnet *e2e.Testnet, ipfsInitWorkers int) error {
+	for _, node := range testnet.Nodes {
+		if err := validateNodeName(node.Name); err != nil {
+			return err
+		}
+	}
+
 	logger.Info(fmt.Sprintf("Generating testnet files in %q", testnet.Dir))
 
 	err := os.MkdirAll(testnet.Dir, os.ModePerm)
@@ -58,11 +75,12 @@ func Setup(testnet *e2e.Testnet) error {
 		return err
 	}
 
-	genesis, err := MakeGenesis(testnet)
+	genesis, err := makeGenesisFile(testnet)
 	if err != nil {
 		return err
 	}
 
+	ipfsConfigs := make([]*config.Config, 0, len(testnet.Nodes))
 	for _, node := range testnet.Nodes {
 		nodeDir := filepath.Join(testnet.Dir, node.Name)
 		dirs := []string{
@@ -77,7 +95,7 @@ func Setup(testnet *e2e.Testnet) error {
 			}
 		}
 
-		err = genesis.SaveAs(filepath.Join(nodeDir, "config", "genesis.json"))
+		err = ioutil.WriteFile(filepath.Join(nodeDir, "config", "genesis.json"), genesis, 0644)
 		if err != nil {
 			return err
 		}
@@ -115,15 +133,83 @@ func Setup(testnet *e2e.Testnet) error {
 			filepath.Join(nodeDir, PrivvalDummyKeyFile),
 			filepath.Join(nodeDir, PrivvalDummyStateFile),
 		)).Save()
-		err = commands.InitIpfs(cfg)
-		if err != nil {
-			return err
+		ipfsConfigs = append(ipfsConfigs, cfg)
+	}
+
+	return initIPFSRepos(ipfsConfigs, ipfsInitWorkers)
+}
+
+// makeGenesisFile returns the contents of the genesis file of the nodes: the
+// file at testnet.GenesisPath as-is if set, after checking that it is a valid
+// genesis, or else the genesis generated by MakeGenesis.
+func makeGenesisFile(testnet *e2e.Testnet) ([]byte, error) {
+	if testnet.GenesisPath != "" {
+		if _, err := types.GenesisDocFromFile(testnet.GenesisPath); err != nil {
+			return nil, err
 		}
+		return ioutil.ReadFile(testnet.GenesisPath)
 	}
 
+	genesis, err := MakeGenesis(testnet)
+	if err != nil {
+		return nil, err
+	}
+	return tmjson.MarshalIndent(genesis, "", "  ")
+}
+
+// validateNodeName checks that name only contains lowercase letters, digits
+// and dashes, as it is used for docker-compose services and node directories.
+func validateNodeName(name string) error {
+	if !nodeNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid node name %q: must only contain lowercase letters, digits and dashes", name)
+	}
 	return nil
 }
 
+// initIPFSRepos initializes the IPFS repos for the given node configs, with at
+// most workers initializations running concurrently. No further repos are
+// initialized once one of them failed, and the first error is returned.
+func initIPFSRepos(cfgs []*config.Config, workers int) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		jobs     = make(chan *config.Config)
+		failed   = make(chan struct{})
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cfg := range jobs {
+				if err := commands.InitIpfs(cfg); err != nil {
+					errOnce.Do(func() {
+						firstErr = fmt.Errorf("failed to initialize IPFS repo %q: %w", cfg.IPFS.ConfigRootPath, err)
+						close(failed)
+					})
+				}
+			}
+		}()
+	}
+
+loop:
+	for _, cfg := range cfgs {
+		select {
+		case jobs <- cfg:
+		case <-failed:
+			break loop
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return firstErr
+}
+
 // MakeDockerCompose generates a Docker Compose config for a testnet.
 func MakeDockerCompose(testnet *e2e.Testnet) ([]byte, error) {
 	// Must use version 2 Docker Compose format, to support IPv6.
@@ -193,10 +279,27 @@ services:
 // MakeGenesis generates a genesis document.
 func MakeGenesis(testnet *e2e.Testnet) (types.GenesisDoc, error) {
 	genesis := types.GenesisDoc{
-		GenesisTime:     time.Now(),
+		GenesisTime:     testnet.GenesisTime,
 		ChainID:         testnet.Name,
 		ConsensusParams: types.DefaultConsensusParams(),
 		InitialHeight:   testnet.InitialHeight,
+		AppHash:         testnet.InitialAppHash,
+	}
+	if genesis.GenesisTime.IsZero() {
+		genesis.GenesisTime = time.Now()
+	}
+	switch {
+	case genesis.InitialHeight == 0:
+		genesis.InitialHeight = 1
+	case genesis.InitialHeight < 0:
+		return genesis, fmt.Errorf("invalid initial height %d, must be at least 1", genesis.InitialHeight)
+	}
+	if testnet.ConsensusParams != nil {
+		// Copy the params so that the key type handling below doesn't mutate
+		// the testnet's own params.
+		params := *testnet.ConsensusParams
+		params.Validator.PubKeyTypes = append([]string{}, params.Validator.PubKeyTypes...)
+		genesis.ConsensusParams = &params
 	}
 	switch testnet.KeyType {
 	case "", types.ABCIPubKeyTypeEd25519, types.ABCIPubKeyTypeSecp256k1:
@@ -273,16 +376,43 @@ func MakeConfig(node *e2e.Node) (*config.Config, error) {
 	case e2e.ModeSeed:
 		cfg.P2P.SeedMode = true
 		cfg.P2P.PexReactor = true
+		// Seeds only crawl the network and hand out addresses, they don't serve RPC.
+		cfg.RPC.ListenAddress = ""
 	case e2e.ModeFull:
 		// Don't need to do anything, since we're using a dummy privval key by default.
 	default:
 		return nil, fmt.Errorf("unexpected mode %q", node.Mode)
 	}
 
-	if node.FastSync == "" {
+	switch node.FastSync {
+	case "":
 		cfg.FastSyncMode = false
-	} else {
+	case "v0", "v2":
 		cfg.FastSync.Version = node.FastSync
+	default:
+		return nil, fmt.Errorf("invalid fast sync setting %q", node.FastSync)
+	}
+
+	if node.Mempool.Recheck != nil {
+		cfg.Mempool.Recheck = *node.Mempool.Recheck
+	}
+	if node.Mempool.Broadcast != nil {
+		cfg.Mempool.Broadcast = *node.Mempool.Broadcast
+	}
+	if node.Mempool.Size > 0 {
+		cfg.Mempool.Size = node.Mempool.Size
+	}
+	if node.Mempool.MaxTxsBytes > 0 {
+		cfg.Mempool.MaxTxsBytes = node.Mempool.MaxTxsBytes
+	}
+	if node.Mempool.CacheSize > 0 {
+		cfg.Mempool.CacheSize = node.Mempool.CacheSize
+	}
+	if node.Mempool.MaxTxBytes > 0 {
+		cfg.Mempool.MaxTxBytes = node.Mempool.MaxTxBytes
+	}
+	if err := cfg.Mempool.ValidateBasic(); err != nil {
+		return nil, fmt.Errorf("invalid mempool settings: %w", err)
 	}
 
 	if node.StateSync {
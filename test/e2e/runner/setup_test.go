@@ -0,0 +1,360 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lazyledger/lazyledger-core/config"
+	"github.com/lazyledger/lazyledger-core/crypto/ed25519"
+	tmjson "github.com/lazyledger/lazyledger-core/libs/json"
+	e2e "github.com/lazyledger/lazyledger-core/test/e2e/pkg"
+	"github.com/lazyledger/lazyledger-core/types"
+)
+
+func newTestTestnet(t *testing.T) *e2e.Testnet {
+	return newTestTestnetWithNodes(t, 1)
+}
+
+// newTestTestnetWithNodes returns a testnet with the given number of
+// validator nodes.
+func newTestTestnetWithNodes(t *testing.T, numNodes int) *e2e.Testnet {
+	_, ipNet, err := net.ParseCIDR("10.186.73.0/24")
+	require.NoError(t, err)
+
+	testnet := &e2e.Testnet{
+		Name:             "test",
+		IP:               ipNet,
+		InitialHeight:    1,
+		Validators:       map[*e2e.Node]int64{},
+		ValidatorUpdates: map[int64]map[*e2e.Node]int64{},
+		KeyType:          types.ABCIPubKeyTypeEd25519,
+	}
+	for i := 0; i < numNodes; i++ {
+		validator := &e2e.Node{
+			Name:            fmt.Sprintf("validator%02d", i+1),
+			Testnet:         testnet,
+			Mode:            e2e.ModeValidator,
+			PrivvalKey:      ed25519.GenPrivKey(),
+			NodeKey:         ed25519.GenPrivKey(),
+			IP:              net.IPv4(10, 186, 73, byte(i+2)),
+			Database:        "badgerdb",
+			ABCIProtocol:    e2e.ProtocolBuiltin,
+			PrivvalProtocol: e2e.ProtocolFile,
+			PersistInterval: 1,
+		}
+		testnet.Nodes = append(testnet.Nodes, validator)
+		testnet.Validators[validator] = 100
+	}
+	return testnet
+}
+
+func TestMakeGenesisDefaultConsensusParams(t *testing.T) {
+	testnet := newTestTestnet(t)
+
+	genesis, err := MakeGenesis(testnet)
+	require.NoError(t, err)
+
+	defaults := types.DefaultConsensusParams()
+	assert.Equal(t, defaults.Block, genesis.ConsensusParams.Block)
+	assert.Equal(t, defaults.Evidence, genesis.ConsensusParams.Evidence)
+}
+
+func TestMakeGenesisConsensusParamsOverride(t *testing.T) {
+	testnet := newTestTestnet(t)
+
+	params := types.DefaultConsensusParams()
+	params.Block.MaxBytes = 1024
+	params.Evidence.MaxAgeNumBlocks = 5
+	params.Evidence.MaxBytes = 512
+	testnet.ConsensusParams = params
+
+	genesis, err := MakeGenesis(testnet)
+	require.NoError(t, err)
+	require.NoError(t, genesis.ValidateAndComplete())
+
+	assert.EqualValues(t, 1024, genesis.ConsensusParams.Block.MaxBytes)
+	assert.EqualValues(t, 5, genesis.ConsensusParams.Evidence.MaxAgeNumBlocks)
+	assert.EqualValues(t, 512, genesis.ConsensusParams.Evidence.MaxBytes)
+	assert.Contains(t, genesis.ConsensusParams.Validator.PubKeyTypes, types.ABCIPubKeyTypeSecp256k1)
+
+	// the testnet's own params must not be modified
+	assert.Equal(t, []string{types.ABCIPubKeyTypeEd25519}, params.Validator.PubKeyTypes)
+}
+
+func TestMakeGenesisInitialAppHash(t *testing.T) {
+	testnet := newTestTestnet(t)
+	testnet.InitialAppHash = []byte{0x01, 0x02, 0x03, 0x04}
+
+	genesis, err := MakeGenesis(testnet)
+	require.NoError(t, err)
+	assert.EqualValues(t, testnet.InitialAppHash, genesis.AppHash)
+
+	dir, err := ioutil.TempDir("", "e2e-genesis")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "genesis.json")
+	require.NoError(t, genesis.SaveAs(file))
+	loaded, err := types.GenesisDocFromFile(file)
+	require.NoError(t, err)
+	assert.EqualValues(t, testnet.InitialAppHash, loaded.AppHash)
+}
+
+func TestMakeGenesisInitialHeight(t *testing.T) {
+	tests := []struct {
+		name          string
+		initialHeight int64
+		want          int64
+		wantErr       bool
+	}{
+		{"unset", 0, 1, false},
+		{"explicit", 1000, 1000, false},
+		{"negative", -1, 0, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			testnet := newTestTestnet(t)
+			testnet.InitialHeight = tt.initialHeight
+
+			genesis, err := MakeGenesis(testnet)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid initial height")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, genesis.InitialHeight)
+		})
+	}
+}
+
+func TestMakeGenesisTime(t *testing.T) {
+	testnet := newTestTestnet(t)
+	before := time.Now()
+	genesis, err := MakeGenesis(testnet)
+	require.NoError(t, err)
+	assert.False(t, genesis.GenesisTime.Before(before))
+	assert.False(t, genesis.GenesisTime.After(time.Now()))
+
+	testnet.GenesisTime = time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	genesis, err = MakeGenesis(testnet)
+	require.NoError(t, err)
+	assert.Equal(t, testnet.GenesisTime, genesis.GenesisTime)
+}
+
+func TestSetupGenesisTimeReproducible(t *testing.T) {
+	testnet := newTestTestnetWithNodes(t, 2)
+	testnet.GenesisTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	dir, err := ioutil.TempDir("", "e2e-setup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var genesisFiles [][]byte
+	for _, run := range []string{"first", "second"} {
+		testnet.Dir = filepath.Join(dir, run)
+		require.NoError(t, Setup(testnet, 1))
+		bz, err := ioutil.ReadFile(filepath.Join(testnet.Dir, testnet.Nodes[0].Name, "config", "genesis.json"))
+		require.NoError(t, err)
+		genesisFiles = append(genesisFiles, bz)
+	}
+	assert.Equal(t, genesisFiles[0], genesisFiles[1])
+}
+
+func TestSetupInitializesIPFSReposConcurrently(t *testing.T) {
+	testnet := newTestTestnetWithNodes(t, 10)
+	dir, err := ioutil.TempDir("", "e2e-setup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	testnet.Dir = dir
+
+	require.NoError(t, Setup(testnet, 3))
+
+	for _, node := range testnet.Nodes {
+		assert.FileExists(t, filepath.Join(dir, node.Name, ".ipfs", "config"))
+	}
+}
+
+func TestSetupAbortsOnIPFSInitError(t *testing.T) {
+	testnet := newTestTestnetWithNodes(t, 4)
+	dir, err := ioutil.TempDir("", "e2e-setup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	testnet.Dir = dir
+
+	// a regular file in place of the plugins directory makes the IPFS init fail
+	badRepoDir := filepath.Join(dir, testnet.Nodes[1].Name, ".ipfs")
+	require.NoError(t, os.MkdirAll(badRepoDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(badRepoDir, "plugins"), []byte{}, 0644))
+
+	err = Setup(testnet, 2)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to initialize IPFS repo")
+}
+
+func TestSetupGenesisPath(t *testing.T) {
+	testnet := newTestTestnetWithNodes(t, 2)
+	dir, err := ioutil.TempDir("", "e2e-setup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	testnet.Dir = filepath.Join(dir, "testnet")
+
+	// a compact genesis, unlike the indented one written by Setup
+	genesis, err := MakeGenesis(testnet)
+	require.NoError(t, err)
+	genesis.ChainID = "captured-chain"
+	genesisJSON, err := tmjson.Marshal(genesis)
+	require.NoError(t, err)
+	testnet.GenesisPath = filepath.Join(dir, "genesis.json")
+	require.NoError(t, ioutil.WriteFile(testnet.GenesisPath, genesisJSON, 0644))
+
+	require.NoError(t, Setup(testnet, 1))
+	for _, node := range testnet.Nodes {
+		bz, err := ioutil.ReadFile(filepath.Join(testnet.Dir, node.Name, "config", "genesis.json"))
+		require.NoError(t, err)
+		assert.Equal(t, genesisJSON, bz)
+	}
+
+	// an invalid genesis is rejected
+	require.NoError(t, ioutil.WriteFile(testnet.GenesisPath, []byte(`{"chain_id": ""}`), 0644))
+	assert.Error(t, Setup(testnet, 1))
+}
+
+func TestSetupValidatesNodeNames(t *testing.T) {
+	for _, name := range []string{"validator01", "full-node-2", "seed"} {
+		assert.NoError(t, validateNodeName(name), name)
+	}
+
+	for _, name := range []string{"", "Validator01", "full node", "full_node", "node.1", "node/1", "nöde"} {
+		name := name
+		t.Run(fmt.Sprintf("%q", name), func(t *testing.T) {
+			testnet := newTestTestnetWithNodes(t, 2)
+			dir, err := ioutil.TempDir("", "e2e-setup")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+			testnet.Dir = filepath.Join(dir, "testnet")
+			testnet.Nodes[1].Name = name
+
+			err = Setup(testnet, 1)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), fmt.Sprintf("%q", name))
+			// nothing was generated
+			assert.NoDirExists(t, testnet.Dir)
+		})
+	}
+}
+
+func TestMakeConfigFastSync(t *testing.T) {
+	for _, version := range []string{"v0", "v2"} {
+		t.Run(version, func(t *testing.T) {
+			node := newTestTestnet(t).Nodes[0]
+			node.FastSync = version
+
+			cfg, err := MakeConfig(node)
+			require.NoError(t, err)
+			assert.True(t, cfg.FastSyncMode)
+			assert.Equal(t, version, cfg.FastSync.Version)
+			assert.NoError(t, cfg.FastSync.ValidateBasic())
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		node := newTestTestnet(t).Nodes[0]
+		node.FastSync = ""
+
+		cfg, err := MakeConfig(node)
+		require.NoError(t, err)
+		assert.False(t, cfg.FastSyncMode)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		node := newTestTestnet(t).Nodes[0]
+		node.FastSync = "v1"
+
+		_, err := MakeConfig(node)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid fast sync setting "v1"`)
+	})
+}
+
+func TestMakeConfigMempoolOverrides(t *testing.T) {
+	node := newTestTestnet(t).Nodes[0]
+	recheck := false
+	node.Mempool = e2e.ManifestMempool{
+		Recheck:     &recheck,
+		Size:        10,
+		MaxTxsBytes: 1024,
+	}
+
+	cfg, err := MakeConfig(node)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "e2e-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.toml")
+	config.WriteConfigFile(file, cfg)
+
+	var written struct {
+		Mempool struct {
+			Recheck     bool  `toml:"recheck"`
+			Broadcast   bool  `toml:"broadcast"`
+			Size        int   `toml:"size"`
+			MaxTxsBytes int64 `toml:"max-txs-bytes"`
+			CacheSize   int   `toml:"cache-size"`
+		} `toml:"mempool"`
+	}
+	_, err = toml.DecodeFile(file, &written)
+	require.NoError(t, err)
+
+	defaults := config.DefaultMempoolConfig()
+	assert.False(t, written.Mempool.Recheck)
+	assert.Equal(t, 10, written.Mempool.Size)
+	assert.EqualValues(t, 1024, written.Mempool.MaxTxsBytes)
+	// fields without overrides keep their defaults
+	assert.Equal(t, defaults.Broadcast, written.Mempool.Broadcast)
+	assert.Equal(t, defaults.CacheSize, written.Mempool.CacheSize)
+}
+
+func TestMakeConfigInvalidMempool(t *testing.T) {
+	node := newTestTestnet(t).Nodes[0]
+	// larger than the default max-batch-bytes
+	node.Mempool.MaxTxBytes = 20 * 1024 * 1024
+
+	_, err := MakeConfig(node)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid mempool settings")
+}
+
+func TestMakeConfigSeedWithoutRPC(t *testing.T) {
+	testnet := newTestTestnetWithNodes(t, 1)
+	seed := &e2e.Node{
+		Name:         "seed01",
+		Testnet:      testnet,
+		Mode:         e2e.ModeSeed,
+		NodeKey:      ed25519.GenPrivKey(),
+		IP:           net.IPv4(10, 186, 73, 10),
+		Database:     "badgerdb",
+		ABCIProtocol: e2e.ProtocolBuiltin,
+	}
+	testnet.Nodes = append(testnet.Nodes, seed)
+
+	cfg, err := MakeConfig(seed)
+	require.NoError(t, err)
+	assert.True(t, cfg.P2P.SeedMode)
+	assert.Empty(t, cfg.RPC.ListenAddress)
+
+	cfg, err = MakeConfig(testnet.Nodes[0])
+	require.NoError(t, err)
+	assert.False(t, cfg.P2P.SeedMode)
+	assert.Equal(t, "tcp://0.0.0.0:26657", cfg.RPC.ListenAddress)
+}
@@ -86,6 +86,7 @@ func TestBlock_Range(t *testing.T) {
 		for h := node.Testnet.InitialHeight; h < first; h++ {
 			_, err := client.Block(ctx, &(h))
 			require.Error(t, err)
+			assert.Contains(t, err.Error(), "is not available")
 		}
 	})
 }
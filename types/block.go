@@ -305,7 +305,7 @@ func (b *Block) PutBlock(ctx context.Context, nodeAdder format.NodeAdder) error
 		tree.Root()
 
 		// commit the batch to ipfs
-		err = batchAdder.Batch().Commit()
+		err = batchAdder.Commit()
 		if err != nil {
 			return err
 		}